@@ -17,10 +17,18 @@ typedef struct _directmq_v1_SupportedProtocolVersions {
 
 typedef struct _directmq_v1_InitConnection {
     uint64_t *max_message_size;
+    uint64_t *supported_features;
+    uint32_t *frame_credit;
+    uint64_t *byte_credit;
+    uint32_t *max_topic_aliases;
 } directmq_v1_InitConnection;
 
 typedef struct _directmq_v1_ConnectionAccepted {
     uint64_t *max_message_size;
+    uint64_t *supported_features;
+    uint32_t *frame_credit;
+    uint64_t *byte_credit;
+    uint32_t *max_topic_aliases;
 } directmq_v1_ConnectionAccepted;
 
 typedef struct _directmq_v1_GracefullyClose {
@@ -38,20 +46,28 @@ extern "C" {
 
 /* Initializer values for message structs */
 #define directmq_v1_SupportedProtocolVersions_init_default {0, NULL}
-#define directmq_v1_InitConnection_init_default  {NULL}
-#define directmq_v1_ConnectionAccepted_init_default {NULL}
+#define directmq_v1_InitConnection_init_default  {NULL, NULL, NULL, NULL, NULL}
+#define directmq_v1_ConnectionAccepted_init_default {NULL, NULL, NULL, NULL, NULL}
 #define directmq_v1_GracefullyClose_init_default {NULL}
 #define directmq_v1_TerminateNetwork_init_default {NULL}
 #define directmq_v1_SupportedProtocolVersions_init_zero {0, NULL}
-#define directmq_v1_InitConnection_init_zero     {NULL}
-#define directmq_v1_ConnectionAccepted_init_zero {NULL}
+#define directmq_v1_InitConnection_init_zero     {NULL, NULL, NULL, NULL, NULL}
+#define directmq_v1_ConnectionAccepted_init_zero {NULL, NULL, NULL, NULL, NULL}
 #define directmq_v1_GracefullyClose_init_zero    {NULL}
 #define directmq_v1_TerminateNetwork_init_zero   {NULL}
 
 /* Field tags (for use in manual encoding/decoding) */
 #define directmq_v1_SupportedProtocolVersions_supported_protocol_versions_tag 1
 #define directmq_v1_InitConnection_max_message_size_tag 1
+#define directmq_v1_InitConnection_supported_features_tag 2
+#define directmq_v1_InitConnection_frame_credit_tag 3
+#define directmq_v1_InitConnection_byte_credit_tag 4
+#define directmq_v1_InitConnection_max_topic_aliases_tag 5
 #define directmq_v1_ConnectionAccepted_max_message_size_tag 1
+#define directmq_v1_ConnectionAccepted_supported_features_tag 2
+#define directmq_v1_ConnectionAccepted_frame_credit_tag 3
+#define directmq_v1_ConnectionAccepted_byte_credit_tag 4
+#define directmq_v1_ConnectionAccepted_max_topic_aliases_tag 5
 #define directmq_v1_GracefullyClose_reason_tag   1
 #define directmq_v1_TerminateNetwork_reason_tag  1
 
@@ -62,12 +78,20 @@ X(a, POINTER,  REPEATED, UINT32,   supported_protocol_versions,   1)
 #define directmq_v1_SupportedProtocolVersions_DEFAULT NULL
 
 #define directmq_v1_InitConnection_FIELDLIST(X, a) \
-X(a, POINTER,  SINGULAR, UINT64,   max_message_size,   1)
+X(a, POINTER,  SINGULAR, UINT64,   max_message_size,   1) \
+X(a, POINTER,  SINGULAR, UINT64,   supported_features,   2) \
+X(a, POINTER,  SINGULAR, UINT32,   frame_credit,      3) \
+X(a, POINTER,  SINGULAR, UINT64,   byte_credit,       4) \
+X(a, POINTER,  SINGULAR, UINT32,   max_topic_aliases,   5)
 #define directmq_v1_InitConnection_CALLBACK NULL
 #define directmq_v1_InitConnection_DEFAULT NULL
 
 #define directmq_v1_ConnectionAccepted_FIELDLIST(X, a) \
-X(a, POINTER,  SINGULAR, UINT64,   max_message_size,   1)
+X(a, POINTER,  SINGULAR, UINT64,   max_message_size,   1) \
+X(a, POINTER,  SINGULAR, UINT64,   supported_features,   2) \
+X(a, POINTER,  SINGULAR, UINT32,   frame_credit,      3) \
+X(a, POINTER,  SINGULAR, UINT64,   byte_credit,       4) \
+X(a, POINTER,  SINGULAR, UINT32,   max_topic_aliases,   5)
 #define directmq_v1_ConnectionAccepted_CALLBACK NULL
 #define directmq_v1_ConnectionAccepted_DEFAULT NULL
 
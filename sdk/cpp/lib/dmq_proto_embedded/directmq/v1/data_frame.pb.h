@@ -8,6 +8,9 @@
 #include "directmq/v1/publish.pb.h"
 #include "directmq/v1/subscribe.pb.h"
 #include "directmq/v1/unsubscribe.pb.h"
+#include "directmq/v1/flow_control.pb.h"
+#include "directmq/v1/topology.pb.h"
+#include "directmq/v1/echo.pb.h"
 
 #if PB_PROTO_HEADER_VERSION != 40
 #error Regenerate this file with the current version of nanopb generator.
@@ -28,7 +31,13 @@ typedef struct _directmq_v1_DataFrame {
         struct _directmq_v1_Unsubscribe *unsubscribe;
         struct _directmq_v1_GracefullyClose *gracefully_close;
         struct _directmq_v1_TerminateNetwork *terminate_network;
+        struct _directmq_v1_FlowCredit *flow_credit;
+        struct _directmq_v1_TopologyProbe *topology_probe;
+        struct _directmq_v1_TopologyReport *topology_report;
+        struct _directmq_v1_EchoRequest *echo_request;
+        struct _directmq_v1_EchoReply *echo_reply;
     } message;
+    char *traceparent;
 } directmq_v1_DataFrame;
 
 
@@ -37,8 +46,8 @@ extern "C" {
 #endif
 
 /* Initializer values for message structs */
-#define directmq_v1_DataFrame_init_default       {NULL, 0, NULL, 0, {NULL}}
-#define directmq_v1_DataFrame_init_zero          {NULL, 0, NULL, 0, {NULL}}
+#define directmq_v1_DataFrame_init_default       {NULL, 0, NULL, 0, {NULL}, NULL}
+#define directmq_v1_DataFrame_init_zero          {NULL, 0, NULL, 0, {NULL}, NULL}
 
 /* Field tags (for use in manual encoding/decoding) */
 #define directmq_v1_DataFrame_ttl_tag            1
@@ -51,6 +60,12 @@ extern "C" {
 #define directmq_v1_DataFrame_unsubscribe_tag    8
 #define directmq_v1_DataFrame_gracefully_close_tag 9
 #define directmq_v1_DataFrame_terminate_network_tag 10
+#define directmq_v1_DataFrame_flow_credit_tag    11
+#define directmq_v1_DataFrame_topology_probe_tag 13
+#define directmq_v1_DataFrame_topology_report_tag 14
+#define directmq_v1_DataFrame_echo_request_tag   15
+#define directmq_v1_DataFrame_echo_reply_tag     16
+#define directmq_v1_DataFrame_traceparent_tag    12
 
 /* Struct field encoding specification for nanopb */
 #define directmq_v1_DataFrame_FIELDLIST(X, a) \
@@ -63,7 +78,13 @@ X(a, POINTER,  ONEOF,    MESSAGE,  (message,publish,message.publish),   6) \
 X(a, POINTER,  ONEOF,    MESSAGE,  (message,subscribe,message.subscribe),   7) \
 X(a, POINTER,  ONEOF,    MESSAGE,  (message,unsubscribe,message.unsubscribe),   8) \
 X(a, POINTER,  ONEOF,    MESSAGE,  (message,gracefully_close,message.gracefully_close),   9) \
-X(a, POINTER,  ONEOF,    MESSAGE,  (message,terminate_network,message.terminate_network),  10)
+X(a, POINTER,  ONEOF,    MESSAGE,  (message,terminate_network,message.terminate_network),  10) \
+X(a, POINTER,  ONEOF,    MESSAGE,  (message,flow_credit,message.flow_credit),  11) \
+X(a, POINTER,  ONEOF,    MESSAGE,  (message,topology_probe,message.topology_probe),  13) \
+X(a, POINTER,  ONEOF,    MESSAGE,  (message,topology_report,message.topology_report),  14) \
+X(a, POINTER,  ONEOF,    MESSAGE,  (message,echo_request,message.echo_request),  15) \
+X(a, POINTER,  ONEOF,    MESSAGE,  (message,echo_reply,message.echo_reply),  16) \
+X(a, POINTER,  SINGULAR, STRING,   traceparent,      12)
 #define directmq_v1_DataFrame_CALLBACK NULL
 #define directmq_v1_DataFrame_DEFAULT NULL
 #define directmq_v1_DataFrame_message_supported_protocol_versions_MSGTYPE directmq_v1_SupportedProtocolVersions
@@ -74,6 +95,11 @@ X(a, POINTER,  ONEOF,    MESSAGE,  (message,terminate_network,message.terminate_
 #define directmq_v1_DataFrame_message_unsubscribe_MSGTYPE directmq_v1_Unsubscribe
 #define directmq_v1_DataFrame_message_gracefully_close_MSGTYPE directmq_v1_GracefullyClose
 #define directmq_v1_DataFrame_message_terminate_network_MSGTYPE directmq_v1_TerminateNetwork
+#define directmq_v1_DataFrame_message_flow_credit_MSGTYPE directmq_v1_FlowCredit
+#define directmq_v1_DataFrame_message_topology_probe_MSGTYPE directmq_v1_TopologyProbe
+#define directmq_v1_DataFrame_message_topology_report_MSGTYPE directmq_v1_TopologyReport
+#define directmq_v1_DataFrame_message_echo_request_MSGTYPE directmq_v1_EchoRequest
+#define directmq_v1_DataFrame_message_echo_reply_MSGTYPE directmq_v1_EchoReply
 
 extern const pb_msgdesc_t directmq_v1_DataFrame_msg;
 
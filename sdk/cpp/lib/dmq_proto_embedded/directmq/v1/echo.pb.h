@@ -0,0 +1,76 @@
+/* Automatically generated nanopb header */
+/* Generated by nanopb-0.4.8 */
+
+#ifndef PB_DIRECTMQ_V1_DIRECTMQ_V1_ECHO_PB_H_INCLUDED
+#define PB_DIRECTMQ_V1_DIRECTMQ_V1_ECHO_PB_H_INCLUDED
+#include <pb.h>
+
+#if PB_PROTO_HEADER_VERSION != 40
+#error Regenerate this file with the current version of nanopb generator.
+#endif
+
+/* Struct definitions */
+typedef struct _directmq_v1_EchoRequest {
+    uint64_t *echo_id;
+    char *target_node_id;
+    pb_size_t route_count;
+    char **route;
+} directmq_v1_EchoRequest;
+
+typedef struct _directmq_v1_EchoReply {
+    uint64_t *echo_id;
+    char *node_id;
+    pb_size_t route_count;
+    char **route;
+} directmq_v1_EchoReply;
+
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+/* Initializer values for message structs */
+#define directmq_v1_EchoRequest_init_default     {NULL, NULL, 0, NULL}
+#define directmq_v1_EchoReply_init_default       {NULL, NULL, 0, NULL}
+#define directmq_v1_EchoRequest_init_zero        {NULL, NULL, 0, NULL}
+#define directmq_v1_EchoReply_init_zero          {NULL, NULL, 0, NULL}
+
+/* Field tags (for use in manual encoding/decoding) */
+#define directmq_v1_EchoRequest_echo_id_tag      1
+#define directmq_v1_EchoRequest_target_node_id_tag 2
+#define directmq_v1_EchoRequest_route_tag        3
+#define directmq_v1_EchoReply_echo_id_tag        1
+#define directmq_v1_EchoReply_node_id_tag        2
+#define directmq_v1_EchoReply_route_tag          3
+
+/* Struct field encoding specification for nanopb */
+#define directmq_v1_EchoRequest_FIELDLIST(X, a) \
+X(a, POINTER,  SINGULAR, UINT64,   echo_id,           1) \
+X(a, POINTER,  SINGULAR, STRING,   target_node_id,    2) \
+X(a, POINTER,  REPEATED, STRING,   route,             3)
+#define directmq_v1_EchoRequest_CALLBACK NULL
+#define directmq_v1_EchoRequest_DEFAULT NULL
+
+#define directmq_v1_EchoReply_FIELDLIST(X, a) \
+X(a, POINTER,  SINGULAR, UINT64,   echo_id,           1) \
+X(a, POINTER,  SINGULAR, STRING,   node_id,           2) \
+X(a, POINTER,  REPEATED, STRING,   route,             3)
+#define directmq_v1_EchoReply_CALLBACK NULL
+#define directmq_v1_EchoReply_DEFAULT NULL
+
+extern const pb_msgdesc_t directmq_v1_EchoRequest_msg;
+extern const pb_msgdesc_t directmq_v1_EchoReply_msg;
+
+/* Defines for backwards compatibility with code written before nanopb-0.4.0 */
+#define directmq_v1_EchoRequest_fields &directmq_v1_EchoRequest_msg
+#define directmq_v1_EchoReply_fields &directmq_v1_EchoReply_msg
+
+/* Maximum encoded size of messages (where known) */
+/* directmq_v1_EchoRequest_size depends on runtime parameters */
+/* directmq_v1_EchoReply_size depends on runtime parameters */
+
+#ifdef __cplusplus
+} /* extern "C" */
+#endif
+
+#endif
@@ -0,0 +1,50 @@
+/* Automatically generated nanopb header */
+/* Generated by nanopb-0.4.8 */
+
+#ifndef PB_DIRECTMQ_V1_DIRECTMQ_V1_FLOW_CONTROL_PB_H_INCLUDED
+#define PB_DIRECTMQ_V1_DIRECTMQ_V1_FLOW_CONTROL_PB_H_INCLUDED
+#include <pb.h>
+
+#if PB_PROTO_HEADER_VERSION != 40
+#error Regenerate this file with the current version of nanopb generator.
+#endif
+
+/* Struct definitions */
+typedef struct _directmq_v1_FlowCredit {
+    uint32_t *frames;
+    uint64_t *bytes;
+} directmq_v1_FlowCredit;
+
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+/* Initializer values for message structs */
+#define directmq_v1_FlowCredit_init_default      {NULL, NULL}
+#define directmq_v1_FlowCredit_init_zero         {NULL, NULL}
+
+/* Field tags (for use in manual encoding/decoding) */
+#define directmq_v1_FlowCredit_frames_tag        1
+#define directmq_v1_FlowCredit_bytes_tag         2
+
+/* Struct field encoding specification for nanopb */
+#define directmq_v1_FlowCredit_FIELDLIST(X, a) \
+X(a, POINTER,  SINGULAR, UINT32,   frames,            1) \
+X(a, POINTER,  SINGULAR, UINT64,   bytes,             2)
+#define directmq_v1_FlowCredit_CALLBACK NULL
+#define directmq_v1_FlowCredit_DEFAULT NULL
+
+extern const pb_msgdesc_t directmq_v1_FlowCredit_msg;
+
+/* Defines for backwards compatibility with code written before nanopb-0.4.0 */
+#define directmq_v1_FlowCredit_fields &directmq_v1_FlowCredit_msg
+
+/* Maximum encoded size of messages (where known) */
+/* directmq_v1_FlowCredit_size depends on runtime parameters */
+
+#ifdef __cplusplus
+} /* extern "C" */
+#endif
+
+#endif
@@ -21,6 +21,7 @@ typedef struct _directmq_v1_Publish {
     directmq_v1_DeliveryStrategy *delivery_strategy;
     uint64_t *size;
     pb_bytes_array_t *payload;
+    uint32_t *topic_alias;
 } directmq_v1_Publish;
 
 
@@ -37,21 +38,23 @@ extern "C" {
 
 
 /* Initializer values for message structs */
-#define directmq_v1_Publish_init_default         {NULL, NULL, NULL, NULL}
-#define directmq_v1_Publish_init_zero            {NULL, NULL, NULL, NULL}
+#define directmq_v1_Publish_init_default         {NULL, NULL, NULL, NULL, NULL}
+#define directmq_v1_Publish_init_zero            {NULL, NULL, NULL, NULL, NULL}
 
 /* Field tags (for use in manual encoding/decoding) */
 #define directmq_v1_Publish_topic_tag            1
 #define directmq_v1_Publish_delivery_strategy_tag 2
 #define directmq_v1_Publish_size_tag             3
 #define directmq_v1_Publish_payload_tag          4
+#define directmq_v1_Publish_topic_alias_tag      5
 
 /* Struct field encoding specification for nanopb */
 #define directmq_v1_Publish_FIELDLIST(X, a) \
 X(a, POINTER,  SINGULAR, STRING,   topic,             1) \
 X(a, POINTER,  SINGULAR, UENUM,    delivery_strategy,   2) \
 X(a, POINTER,  SINGULAR, UINT64,   size,              3) \
-X(a, POINTER,  SINGULAR, BYTES,    payload,           4)
+X(a, POINTER,  SINGULAR, BYTES,    payload,           4) \
+X(a, POINTER,  SINGULAR, UINT32,   topic_alias,       5)
 #define directmq_v1_Publish_CALLBACK NULL
 #define directmq_v1_Publish_DEFAULT NULL
 
@@ -0,0 +1,15 @@
+/* Automatically generated nanopb constant definitions */
+/* Generated by nanopb-0.4.8 */
+
+#include "directmq/v1/topology.pb.h"
+#if PB_PROTO_HEADER_VERSION != 40
+#error Regenerate this file with the current version of nanopb generator.
+#endif
+
+PB_BIND(directmq_v1_TopologyProbe, directmq_v1_TopologyProbe, AUTO)
+
+
+PB_BIND(directmq_v1_TopologyReport, directmq_v1_TopologyReport, AUTO)
+
+
+
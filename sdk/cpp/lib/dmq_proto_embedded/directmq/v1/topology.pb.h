@@ -0,0 +1,73 @@
+/* Automatically generated nanopb header */
+/* Generated by nanopb-0.4.8 */
+
+#ifndef PB_DIRECTMQ_V1_DIRECTMQ_V1_TOPOLOGY_PB_H_INCLUDED
+#define PB_DIRECTMQ_V1_DIRECTMQ_V1_TOPOLOGY_PB_H_INCLUDED
+#include <pb.h>
+
+#if PB_PROTO_HEADER_VERSION != 40
+#error Regenerate this file with the current version of nanopb generator.
+#endif
+
+/* Struct definitions */
+typedef struct _directmq_v1_TopologyProbe {
+    uint64_t *probe_id;
+} directmq_v1_TopologyProbe;
+
+typedef struct _directmq_v1_TopologyReport {
+    uint64_t *probe_id;
+    char *node_id;
+    pb_size_t bridged_node_ids_count;
+    char **bridged_node_ids;
+    pb_size_t route_count;
+    char **route;
+} directmq_v1_TopologyReport;
+
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+/* Initializer values for message structs */
+#define directmq_v1_TopologyProbe_init_default   {NULL}
+#define directmq_v1_TopologyReport_init_default  {NULL, NULL, 0, NULL, 0, NULL}
+#define directmq_v1_TopologyProbe_init_zero      {NULL}
+#define directmq_v1_TopologyReport_init_zero     {NULL, NULL, 0, NULL, 0, NULL}
+
+/* Field tags (for use in manual encoding/decoding) */
+#define directmq_v1_TopologyProbe_probe_id_tag   1
+#define directmq_v1_TopologyReport_probe_id_tag  1
+#define directmq_v1_TopologyReport_node_id_tag   2
+#define directmq_v1_TopologyReport_bridged_node_ids_tag 3
+#define directmq_v1_TopologyReport_route_tag     4
+
+/* Struct field encoding specification for nanopb */
+#define directmq_v1_TopologyProbe_FIELDLIST(X, a) \
+X(a, POINTER,  SINGULAR, UINT64,   probe_id,          1)
+#define directmq_v1_TopologyProbe_CALLBACK NULL
+#define directmq_v1_TopologyProbe_DEFAULT NULL
+
+#define directmq_v1_TopologyReport_FIELDLIST(X, a) \
+X(a, POINTER,  SINGULAR, UINT64,   probe_id,          1) \
+X(a, POINTER,  SINGULAR, STRING,   node_id,           2) \
+X(a, POINTER,  REPEATED, STRING,   bridged_node_ids,   3) \
+X(a, POINTER,  REPEATED, STRING,   route,             4)
+#define directmq_v1_TopologyReport_CALLBACK NULL
+#define directmq_v1_TopologyReport_DEFAULT NULL
+
+extern const pb_msgdesc_t directmq_v1_TopologyProbe_msg;
+extern const pb_msgdesc_t directmq_v1_TopologyReport_msg;
+
+/* Defines for backwards compatibility with code written before nanopb-0.4.0 */
+#define directmq_v1_TopologyProbe_fields &directmq_v1_TopologyProbe_msg
+#define directmq_v1_TopologyReport_fields &directmq_v1_TopologyReport_msg
+
+/* Maximum encoded size of messages (where known) */
+/* directmq_v1_TopologyProbe_size depends on runtime parameters */
+/* directmq_v1_TopologyReport_size depends on runtime parameters */
+
+#ifdef __cplusplus
+} /* extern "C" */
+#endif
+
+#endif
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func runPing(ctx context.Context, options *options, args []string) error {
+	if len(args) != 2 {
+		return errors.New("ping requires the URL and node ID")
+	}
+
+	options.address = args[0]
+	nodeID := args[1]
+
+	node, err := connect(ctx, options)
+	if err != nil {
+		return err
+	}
+	defer node.CloseNode("ping finished")
+
+	for i := 0; i < options.count; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(time.Second):
+			}
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, options.timeout)
+		result, err := node.Ping(pingCtx, nodeID)
+		cancel()
+
+		if err != nil {
+			fmt.Printf("echo %d from %s: %s\n", i+1, nodeID, err)
+			continue
+		}
+
+		fmt.Printf("echo %d from %s: hops=%d time=%s path=%v\n", i+1, result.NodeID, len(result.Traversed), result.RTT, result.Traversed)
+	}
+
+	return nil
+}
+
+func runTraceroute(ctx context.Context, options *options, args []string) error {
+	if len(args) != 2 {
+		return errors.New("traceroute requires the URL and node ID")
+	}
+
+	options.address = args[0]
+	nodeID := args[1]
+
+	node, err := connect(ctx, options)
+	if err != nil {
+		return err
+	}
+	defer node.CloseNode("traceroute finished")
+
+	tracerouteCtx, cancel := context.WithTimeout(ctx, options.timeout)
+	defer cancel()
+
+	hops, err := node.Traceroute(tracerouteCtx, nodeID)
+	for _, hop := range hops {
+		if hop.TimedOut {
+			fmt.Printf("%2d  %s  *\n", hop.Hop, hop.NodeID)
+			continue
+		}
+
+		fmt.Printf("%2d  %s  %s\n", hop.Hop, hop.NodeID, hop.RTT)
+	}
+
+	return err
+}
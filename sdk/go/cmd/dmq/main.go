@@ -0,0 +1,217 @@
+// Command dmq connects to the DirectMQ network and runs a single command.
+//
+// Usage:
+//
+//	dmq <command> [flags] <arguments>
+//
+// Commands:
+//
+//	pub <url> <topic> <payload|@file>  publishes the message
+//	sub <url> <pattern>                prints the received messages as JSON lines
+//	peers <url>                        lists the nodes of the network
+//	ping <url> <node-id>               sends echo requests to the node
+//	traceroute <url> <node-id>         lists the nodes on the path to the node
+//
+// The scheme of the URL selects the portal: ws, wss, http, https,
+// tcp, tls, udp or unix, e.g. unix:///run/dmq.sock.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"time"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+	dmqportals "github.com/sync-toys/DirectMQ/sdk/go/portals"
+)
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, options *options, args []string) error
+}
+
+var commands = map[string]command{
+	"pub":        {"pub [flags] <url> <topic> <payload|@file>", runPub},
+	"sub":        {"sub [flags] <url> <pattern>", runSub},
+	"peers":      {"peers [flags] <url>", runPeers},
+	"ping":       {"ping [flags] <url> <node-id>", runPing},
+	"traceroute": {"traceroute [flags] <url> <node-id>", runTraceroute},
+}
+
+type options struct {
+	address  string
+	nodeID   string
+	ttl      int
+	format   string
+	delivery string
+	timeout  time.Duration
+	count    int
+}
+
+func (o *options) register(flags *flag.FlagSet) {
+	flags.StringVar(&o.nodeID, "node-id", "dmq-cli-"+strconv.Itoa(os.Getpid()), "ID of the CLI node")
+	flags.IntVar(&o.ttl, "ttl", directmq.DEFAULT_TTL, "TTL of the sent frames")
+	flags.StringVar(&o.format, "format", "binary", "protocol format, binary or json")
+	flags.StringVar(&o.delivery, "delivery", "at-least-once", "delivery strategy of the published message, at-least-once or at-most-once")
+	flags.DurationVar(&o.timeout, "timeout", 5*time.Second, "timeout of the single request")
+	flags.IntVar(&o.count, "count", 4, "number of echo requests sent by ping")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	cmd, exists := commands[os.Args[1]]
+	if !exists {
+		printUsage()
+		os.Exit(2)
+	}
+
+	options := &options{}
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: dmq "+cmd.usage)
+		flags.PrintDefaults()
+	}
+
+	options.register(flags)
+	flags.Parse(os.Args[2:])
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := cmd.run(ctx, options, flags.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "dmq: "+err.Error())
+		os.Exit(1)
+	}
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: dmq <command> [flags] <arguments>")
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, name := range []string{"pub", "sub", "peers", "ping", "traceroute"} {
+		fmt.Fprintln(os.Stderr, "  "+commands[name].usage)
+	}
+}
+
+// connect creates the CLI node and bridges it with the remote node,
+// it returns when the connection is established.
+func connect(ctx context.Context, options *options) (directmq.NetworkNode, error) {
+	node, err := newNode(options)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bridge(ctx, node, options); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// newNode creates the CLI node, so the commands can register
+// the subscriptions and callbacks before the connection.
+func newNode(options *options) (directmq.NetworkNode, error) {
+	protocol, _, err := parseFormat(options.format)
+	if err != nil {
+		return nil, err
+	}
+
+	return directmq.NewNetworkNode(directmq.NetworkNodeConfig{
+		HostID:       options.nodeID,
+		HostTTL:      directmq.TTL(options.ttl),
+		HostFeatures: directmq.FEATURE_ECHO | directmq.FEATURE_TOPOLOGY_DISCOVERY,
+	}, protocol), nil
+}
+
+// bridge connects the node with the remote node,
+// it returns when the connection is established.
+func bridge(ctx context.Context, node directmq.NetworkNode, options *options) error {
+	_, messagesType, err := parseFormat(options.format)
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(options.address)
+	if err != nil {
+		return err
+	}
+
+	var once sync.Once
+	connected := make(chan struct{})
+	node.OnConnectionEstablished(func(bridgedNodeID string, portal directmq.Portal) {
+		once.Do(func() { close(connected) })
+	})
+
+	portal, err := dial(u, messagesType)
+	if err != nil {
+		return err
+	}
+
+	failed := make(chan error, 1)
+	go func() {
+		failed <- node.AddConnectingEdge(portal)
+	}()
+
+	select {
+	case <-connected:
+		return nil
+	case err := <-failed:
+		return errors.New("connection failed: " + fmt.Sprint(err))
+	case <-ctx.Done():
+		portal.Close()
+		return ctx.Err()
+	}
+}
+
+// dial opens the portal selected by the scheme of the URL.
+func dial(u *url.URL, messagesType dmqportals.MessagesType) (directmq.Portal, error) {
+	switch u.Scheme {
+	case "ws", "wss":
+		return dmqportals.WebsocketConnect(u, messagesType)
+	case "http", "https":
+		return dmqportals.HTTPConnect(u, dmqportals.HTTPClientConfig{})
+	case "tcp":
+		return dmqportals.TCPConnect(u.Host)
+	case "tls":
+		return dmqportals.TCPConnectTLS(u.Host, &tls.Config{ServerName: u.Hostname()})
+	case "udp":
+		return dmqportals.UDPConnect(u.Host, dmqportals.UDPConfig{})
+	case "unix":
+		return dmqportals.UnixConnect(u.Path)
+	default:
+		return nil, errors.New("unsupported URL scheme: " + u.Scheme)
+	}
+}
+
+func parseFormat(format string) (directmq.ProtocolFactory, dmqportals.MessagesType, error) {
+	switch format {
+	case "binary":
+		return directmq.NewProtobufBinaryProtocol(), dmqportals.BinaryMessages, nil
+	case "json":
+		return directmq.NewProtobufJSONProtocol(), dmqportals.TextMessages, nil
+	default:
+		return nil, 0, errors.New("unknown protocol format: " + format)
+	}
+}
+
+func parseDeliveryStrategy(delivery string) (directmq.DeliveryStrategy, error) {
+	switch delivery {
+	case "at-least-once":
+		return directmq.AT_LEAST_ONCE, nil
+	case "at-most-once":
+		return directmq.AT_MOST_ONCE, nil
+	default:
+		return 0, errors.New("unknown delivery strategy: " + delivery)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+func runPeers(ctx context.Context, options *options, args []string) error {
+	if len(args) != 1 {
+		return errors.New("peers requires exactly one URL")
+	}
+
+	options.address = args[0]
+
+	node, err := connect(ctx, options)
+	if err != nil {
+		return err
+	}
+	defer node.CloseNode("peers finished")
+
+	discoveryCtx, cancel := context.WithTimeout(ctx, options.timeout)
+	defer cancel()
+
+	graph := node.DiscoverTopology(discoveryCtx)
+
+	peers := graph.Nodes[:0]
+	for _, peer := range graph.Nodes {
+		// the CLI node is not the part of the network
+		if peer.NodeID != options.nodeID {
+			peers = append(peers, peer)
+		}
+	}
+
+	sort.Slice(peers, func(i, j int) bool {
+		if peers[i].Hops != peers[j].Hops {
+			return peers[i].Hops < peers[j].Hops
+		}
+
+		return peers[i].NodeID < peers[j].NodeID
+	})
+
+	for _, peer := range peers {
+		fmt.Printf("%2d  %s  %v\n", peer.Hops, peer.NodeID, peer.BridgedNodeIDs)
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+)
+
+// receivedMessage is printed by sub as a single JSON line, the payload
+// is printed as the string when it is valid UTF-8, otherwise as base64.
+type receivedMessage struct {
+	ReceivedAt       time.Time `json:"received_at"`
+	Topic            string    `json:"topic"`
+	Payload          *string   `json:"payload,omitempty"`
+	PayloadBase64    *string   `json:"payload_base64,omitempty"`
+	DeliveryStrategy string    `json:"delivery_strategy"`
+	Origin           string    `json:"origin"`
+	Traversed        []string  `json:"traversed"`
+	TTL              int32     `json:"ttl"`
+	TraceParent      string    `json:"trace_parent,omitempty"`
+}
+
+func runPub(ctx context.Context, options *options, args []string) error {
+	if len(args) != 3 {
+		return errors.New("pub requires the URL, topic and payload")
+	}
+
+	options.address, args = args[0], args[1:]
+	topic := args[0]
+
+	if !directmq.IsCorrectTopicPattern(topic) || strings.Contains(topic, "*") {
+		return errors.New("incorrect topic: " + topic)
+	}
+
+	deliveryStrategy, err := parseDeliveryStrategy(options.delivery)
+	if err != nil {
+		return err
+	}
+
+	payload, err := readPayload(args[1])
+	if err != nil {
+		return err
+	}
+
+	if len(payload) == 0 {
+		return errors.New("payload can not be empty")
+	}
+
+	node, err := newNode(options)
+	if err != nil {
+		return err
+	}
+
+	// publications are forwarded only to the nodes subscribed to the topic,
+	// so the subscriptions of the network have to be received first
+	var once sync.Once
+	subscribed := make(chan struct{})
+	node.OnSubscription(func(message directmq.SubscribeMessage) {
+		if directmq.MatchTopicPattern(message.Topic, topic) {
+			once.Do(func() { close(subscribed) })
+		}
+	})
+
+	if err := bridge(ctx, node, options); err != nil {
+		return err
+	}
+	defer node.CloseNode("pub finished")
+
+	select {
+	case <-subscribed:
+	case <-time.After(options.timeout):
+		return errors.New("no subscribers of the topic: " + topic)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	node.Publish(topic, payload, deliveryStrategy)
+	return nil
+}
+
+// readPayload returns the argument, the content of the file
+// when it starts with @, or the standard input for @-.
+func readPayload(argument string) ([]byte, error) {
+	if !strings.HasPrefix(argument, "@") {
+		return []byte(argument), nil
+	}
+
+	if argument == "@-" {
+		return io.ReadAll(os.Stdin)
+	}
+
+	return os.ReadFile(argument[1:])
+}
+
+func runSub(ctx context.Context, options *options, args []string) error {
+	if len(args) != 2 {
+		return errors.New("sub requires the URL and topic pattern")
+	}
+
+	options.address = args[0]
+	pattern := args[1]
+
+	if !directmq.IsCorrectTopicPattern(pattern) {
+		return errors.New("incorrect topic pattern: " + pattern)
+	}
+
+	node, err := newNode(options)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+
+	// the subscription handler receives only the payload,
+	// the metadata of the publication comes from the diagnostics
+	node.OnPublication(func(message directmq.PublishMessage) {
+		if directmq.MatchTopicPattern(pattern, message.Topic) {
+			encoder.Encode(newReceivedMessage(message))
+		}
+	})
+
+	node.Subscribe(pattern, func(payload []byte) {})
+
+	lost := make(chan string, 1)
+	node.OnConnectionLost(func(bridgedNodeID, reason string, portal directmq.Portal) {
+		select {
+		case lost <- reason:
+		default:
+		}
+	})
+
+	if err := bridge(ctx, node, options); err != nil {
+		return err
+	}
+	defer node.CloseNode("sub finished")
+
+	select {
+	case reason := <-lost:
+		return errors.New("connection lost: " + reason)
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+func newReceivedMessage(message directmq.PublishMessage) receivedMessage {
+	received := receivedMessage{
+		ReceivedAt:       time.Now().UTC(),
+		Topic:            message.Topic,
+		DeliveryStrategy: "at-least-once",
+		Traversed:        message.Traversed,
+		TTL:              message.TTL,
+		TraceParent:      message.TraceParent,
+	}
+
+	if message.DeliveryStrategy == directmq.AT_MOST_ONCE {
+		received.DeliveryStrategy = "at-most-once"
+	}
+
+	if len(message.Traversed) > 0 {
+		received.Origin = message.Traversed[0]
+	}
+
+	if utf8.Valid(message.Payload) {
+		payload := string(message.Payload)
+		received.Payload = &payload
+	} else {
+		payload := base64.StdEncoding.EncodeToString(message.Payload)
+		received.PayloadBase64 = &payload
+	}
+
+	return received
+}
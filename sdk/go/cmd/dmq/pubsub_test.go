@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+	dmqportals "github.com/sync-toys/DirectMQ/sdk/go/portals"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("pub", func() {
+	newOptions := func() *options {
+		return &options{
+			nodeID:   "dmq-cli-test",
+			ttl:      directmq.DEFAULT_TTL,
+			format:   "binary",
+			delivery: "at-least-once",
+			timeout:  time.Second,
+		}
+	}
+
+	// startServerNode returns the websocket URL of the node
+	// passing the payloads published on the topic to the channel
+	startServerNode := func(topic string) (string, chan []byte) {
+		node := directmq.NewNetworkNode(directmq.NetworkNodeConfig{
+			HostID:  "server",
+			HostTTL: directmq.DEFAULT_TTL,
+		}, directmq.NewProtobufBinaryProtocol())
+		DeferCleanup(node.CloseNode, "test finished")
+
+		received := make(chan []byte, 1)
+		node.Subscribe(topic, func(payload []byte) { received <- payload })
+
+		server := httptest.NewServer(dmqportals.NewWebsocketServer(node, dmqportals.WebsocketServerConfig{
+			MessagesType: dmqportals.BinaryMessages,
+		}))
+		DeferCleanup(server.Close)
+
+		return "ws" + strings.TrimPrefix(server.URL, "http"), received
+	}
+
+	It("should publish the payload to the subscribed node", func() {
+		address, received := startServerNode("cli/topic")
+
+		Expect(runPub(context.Background(), newOptions(), []string{address, "cli/topic", "payload"})).To(Succeed())
+		Eventually(received).Should(Receive(Equal([]byte("payload"))))
+	})
+
+	It("should publish the content of the file", func() {
+		address, received := startServerNode("cli/topic")
+
+		path := filepath.Join(GinkgoT().TempDir(), "payload")
+		Expect(os.WriteFile(path, []byte("from file"), 0o600)).To(Succeed())
+
+		Expect(runPub(context.Background(), newOptions(), []string{address, "cli/topic", "@" + path})).To(Succeed())
+		Eventually(received).Should(Receive(Equal([]byte("from file"))))
+	})
+
+	It("should reject the empty payload", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "empty")
+		Expect(os.WriteFile(path, nil, 0o600)).To(Succeed())
+
+		for _, payload := range []string{"", "@" + path} {
+			err := runPub(context.Background(), newOptions(), []string{"ws://127.0.0.1:1/", "cli/topic", payload})
+			Expect(err).To(MatchError("payload can not be empty"))
+		}
+	})
+
+	It("should fail without the subscribers of the topic", func() {
+		address, _ := startServerNode("other/topic")
+
+		err := runPub(context.Background(), newOptions(), []string{address, "cli/topic", "payload"})
+		Expect(err).To(MatchError("no subscribers of the topic: cli/topic"))
+	})
+})
@@ -0,0 +1,244 @@
+// Command dmqdump prints the packets of the capture written
+// by the CapturePortal, and can replay them into the node.
+//
+// Usage:
+//
+//	dmqdump [flags] <capture-file>
+//
+// Every printed line contains the timestamp, direction, edge ID,
+// message type, packet size and the decoded frame. With the -replay
+// flag the selected packets are written to the websocket node instead.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+	dmqportals "github.com/sync-toys/DirectMQ/sdk/go/portals"
+	"github.com/sync-toys/DirectMQ/sdk/go/protocol"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+type options struct {
+	format    string
+	types     string
+	topic     string
+	direction string
+	edgeID    string
+	replay    string
+	timing    bool
+}
+
+func (o *options) register(flags *flag.FlagSet) {
+	flags.StringVar(&o.format, "format", "binary", "protocol format of the captured packets, binary or json")
+	flags.StringVar(&o.types, "type", "", "comma separated message types to select, e.g. publish,subscribe")
+	flags.StringVar(&o.topic, "topic", "", "topic pattern the selected publish, subscribe and unsubscribe frames overlap")
+	flags.StringVar(&o.direction, "direction", "", "direction of the selected packets, in or out")
+	flags.StringVar(&o.edgeID, "edge", "", "edge ID of the selected packets")
+	flags.StringVar(&o.replay, "replay", "", "websocket address of the node the selected packets are replayed to")
+	flags.BoolVar(&o.timing, "timing", false, "keep the captured timing of the replayed packets")
+}
+
+func main() {
+	options := &options{}
+	flags := flag.NewFlagSet("dmqdump", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: dmqdump [flags] <capture-file>")
+		flags.PrintDefaults()
+	}
+
+	options.register(flags)
+	flags.Parse(os.Args[1:])
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(options, flags.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, "dmqdump: "+err.Error())
+		os.Exit(1)
+	}
+}
+
+func run(options *options, path string) error {
+	format, messagesType, err := parseFormat(options.format)
+	if err != nil {
+		return err
+	}
+
+	filter, err := newRecordFilter(options, format)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	capture, err := dmqportals.NewCaptureReader(file)
+	if err != nil {
+		return err
+	}
+
+	if options.replay != "" {
+		return replay(options, capture, filter, messagesType)
+	}
+
+	for {
+		record, err := capture.ReadRecord()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if filter.match(record) {
+			printRecord(record, format)
+		}
+	}
+}
+
+func replay(options *options, capture *dmqportals.CaptureReader, filter *recordFilter, messagesType dmqportals.MessagesType) error {
+	u, err := url.Parse(options.replay)
+	if err != nil {
+		return err
+	}
+
+	portal, err := dmqportals.WebsocketConnect(u, messagesType)
+	if err != nil {
+		return err
+	}
+	defer portal.Close()
+
+	replayed, err := dmqportals.ReplayCapture(capture, portal, dmqportals.ReplayConfig{
+		Filter:     filter.match,
+		KeepTiming: options.timing,
+	})
+
+	fmt.Fprintf(os.Stderr, "replayed %d packets\n", replayed)
+	return err
+}
+
+func printRecord(record dmqportals.CaptureRecord, format directmq.ProtobufProtocolFormat) {
+	frame, messageType, err := directmq.DecodeProtobufFrame(record.Packet, format)
+
+	var decoded string
+	if err != nil {
+		decoded = fmt.Sprintf("%x", record.Packet)
+	} else {
+		decoded = protojson.MarshalOptions{}.Format(frame)
+	}
+
+	fmt.Printf("%s %-3s %s %s %dB %s\n",
+		record.Timestamp.UTC().Format(time.RFC3339Nano),
+		record.Direction,
+		record.EdgeID,
+		messageType,
+		len(record.Packet),
+		decoded,
+	)
+}
+
+func parseFormat(format string) (directmq.ProtobufProtocolFormat, dmqportals.MessagesType, error) {
+	switch format {
+	case "binary":
+		return directmq.PROTOBUF_FORMAT_BINARY, dmqportals.BinaryMessages, nil
+	case "json":
+		return directmq.PROTOBUF_FORMAT_JSON, dmqportals.TextMessages, nil
+	default:
+		return 0, 0, errors.New("unknown protocol format: " + format)
+	}
+}
+
+type recordFilter struct {
+	format    directmq.ProtobufProtocolFormat
+	types     map[directmq.MessageType]struct{}
+	topic     string
+	direction dmqportals.CaptureDirection
+	edgeID    string
+}
+
+func newRecordFilter(options *options, format directmq.ProtobufProtocolFormat) (*recordFilter, error) {
+	filter := &recordFilter{
+		format: format,
+		types:  make(map[directmq.MessageType]struct{}),
+		topic:  options.topic,
+		edgeID: options.edgeID,
+	}
+
+	if options.types != "" {
+		for _, messageType := range strings.Split(options.types, ",") {
+			filter.types[directmq.MessageType(strings.TrimSpace(messageType))] = struct{}{}
+		}
+	}
+
+	if filter.topic != "" && !directmq.IsCorrectTopicPattern(filter.topic) {
+		return nil, errors.New("incorrect topic pattern: " + filter.topic)
+	}
+
+	switch options.direction {
+	case "":
+	case "in":
+		filter.direction = dmqportals.CAPTURE_DIRECTION_INCOMING
+	case "out":
+		filter.direction = dmqportals.CAPTURE_DIRECTION_OUTGOING
+	default:
+		return nil, errors.New("unknown direction: " + options.direction)
+	}
+
+	return filter, nil
+}
+
+func (f *recordFilter) match(record dmqportals.CaptureRecord) bool {
+	if f.direction != 0 && record.Direction != f.direction {
+		return false
+	}
+
+	if f.edgeID != "" && record.EdgeID != f.edgeID {
+		return false
+	}
+
+	if len(f.types) == 0 && f.topic == "" {
+		return true
+	}
+
+	frame, messageType, _ := directmq.DecodeProtobufFrame(record.Packet, f.format)
+
+	if _, selected := f.types[messageType]; len(f.types) > 0 && !selected {
+		return false
+	}
+
+	if f.topic == "" {
+		return true
+	}
+
+	// publications carrying only the topic alias have no topic to match
+	topic := getFrameTopic(frame)
+	return topic != "" && directmq.PatternsOverlap(f.topic, topic)
+}
+
+func getFrameTopic(frame *protocol.DataFrame) string {
+	switch {
+	case frame.GetPublish() != nil:
+		return frame.GetPublish().GetTopic()
+	case frame.GetSubscribe() != nil:
+		return frame.GetSubscribe().GetTopic()
+	case frame.GetUnsubscribe() != nil:
+		return frame.GetUnsubscribe().GetTopic()
+	default:
+		return ""
+	}
+}
@@ -7,10 +7,21 @@ type DiagnosticsAPI interface {
 	OnSubscription(callback func(subscription SubscribeMessage))
 	OnUnsubscribe(callback func(unsubscribe UnsubscribeMessage))
 	OnTerminateNetwork(callback func(terminate TerminateNetworkMessage))
+	OnFlowControlStall(callback func(bridgedNodeID string, queuedPublications int))
+
+	// OnEdgeStateChanged is called on every state change of every edge,
+	// previous state is EDGE_STATE_NONE for the newly added edges.
+	OnEdgeStateChanged(callback func(edge EdgeInfo, previous EdgeState))
+
+	// OnCorruptedPacket is called when the portal implementing
+	// CorruptedPacketsReporter drops the corrupted packet.
+	OnCorruptedPacket(callback func(bridgedNodeID, reason string, portal Portal))
 }
 
 // TODO: handle protocol writing errors
 type diagnosticsAPI struct {
+	network *globalNetwork
+
 	onConnectionEstablished func(bridgedNodeID string, portal Portal)
 	onConnectionLost        func(bridgedNodeID, reason string, portal Portal)
 
@@ -18,6 +29,10 @@ type diagnosticsAPI struct {
 	onSubscription     func(subscription SubscribeMessage)
 	onUnsubscribe      func(unsubscribe UnsubscribeMessage)
 	onTerminateNetwork func(terminate TerminateNetworkMessage)
+
+	onFlowControlStall func(bridgedNodeID string, queuedPublications int)
+	onEdgeStateChanged func(edge EdgeInfo, previous EdgeState)
+	onCorruptedPacket  func(bridgedNodeID, reason string, portal Portal)
 }
 
 var _ networkParticipant = (*diagnosticsAPI)(nil)
@@ -36,40 +51,76 @@ func (d *diagnosticsAPI) IsOriginOfFrame(message DataFrame) bool {
 }
 
 func (d *diagnosticsAPI) HandleConnectionEstablished(bridgedNodeID string, portal Portal) {
-	if d.onConnectionEstablished != nil {
-		d.onConnectionEstablished(bridgedNodeID, portal)
+	if callback := d.onConnectionEstablished; callback != nil {
+		d.network.schedule(func() { callback(bridgedNodeID, portal) })
 	}
 }
 
+// HandleConnectionLost calls the callback of the node immediately,
+// the node removes the edge and schedules the callback of the user.
 func (d *diagnosticsAPI) HandleConnectionLost(bridgedNodeID, reason string, portal Portal) {
 	if d.onConnectionLost != nil {
 		d.onConnectionLost(bridgedNodeID, reason, portal)
 	}
 }
 
-func (d *diagnosticsAPI) HandlePublish(publication PublishMessage) (handled bool) {
-	if d.onPublication != nil {
-		d.onPublication(publication)
+func (d *diagnosticsAPI) HandlePublish(publication PublishMessage) (handled bool, dropReason DropReason) {
+	if callback := d.onPublication; callback != nil {
+		d.network.schedule(func() { callback(publication) })
 	}
 
-	return false
+	return false, ""
 }
 
 func (d *diagnosticsAPI) HandleSubscribe(subscription SubscribeMessage) {
-	if d.onSubscription != nil {
-		d.onSubscription(subscription)
+	if callback := d.onSubscription; callback != nil {
+		d.network.schedule(func() { callback(subscription) })
 	}
 }
 
 func (d *diagnosticsAPI) HandleUnsubscribe(unsubscribe UnsubscribeMessage) {
-	if d.onUnsubscribe != nil {
-		d.onUnsubscribe(unsubscribe)
+	if callback := d.onUnsubscribe; callback != nil {
+		d.network.schedule(func() { callback(unsubscribe) })
 	}
 }
 
 func (d *diagnosticsAPI) HandleTerminateNetwork(terminate TerminateNetworkMessage) {
-	if d.onTerminateNetwork != nil {
-		d.onTerminateNetwork(terminate)
+	if callback := d.onTerminateNetwork; callback != nil {
+		d.network.schedule(func() { callback(terminate) })
+	}
+}
+
+func (d *diagnosticsAPI) HandleTopologyProbe(probe TopologyProbeMessage) {
+	/* No-op */
+}
+
+func (d *diagnosticsAPI) HandleTopologyReport(report TopologyReportMessage) (handled bool) {
+	return false
+}
+
+func (d *diagnosticsAPI) HandleEchoRequest(request EchoRequestMessage) (handled bool) {
+	return false
+}
+
+func (d *diagnosticsAPI) HandleEchoReply(reply EchoReplyMessage) (handled bool) {
+	return false
+}
+
+func (d *diagnosticsAPI) HandleFlowControlStall(bridgedNodeID string, queuedPublications int) {
+	if callback := d.onFlowControlStall; callback != nil {
+		d.network.schedule(func() { callback(bridgedNodeID, queuedPublications) })
+	}
+}
+
+func (d *diagnosticsAPI) HandleEdgeStateChanged(edge EdgeInfo, previous EdgeState) {
+	if callback := d.onEdgeStateChanged; callback != nil {
+		d.network.schedule(func() { callback(edge, previous) })
+	}
+}
+
+func (d *diagnosticsAPI) HandleCorruptedPacket(bridgedNodeID, reason string, portal Portal) {
+	if callback := d.onCorruptedPacket; callback != nil {
+		d.network.schedule(func() { callback(bridgedNodeID, reason, portal) })
 	}
 }
 
@@ -96,3 +147,15 @@ func (d *diagnosticsAPI) OnUnsubscribe(callback func(unsubscribe UnsubscribeMess
 func (d *diagnosticsAPI) OnTerminateNetwork(callback func(terminate TerminateNetworkMessage)) {
 	d.onTerminateNetwork = callback
 }
+
+func (d *diagnosticsAPI) OnFlowControlStall(callback func(bridgedNodeID string, queuedPublications int)) {
+	d.onFlowControlStall = callback
+}
+
+func (d *diagnosticsAPI) OnEdgeStateChanged(callback func(edge EdgeInfo, previous EdgeState)) {
+	d.onEdgeStateChanged = callback
+}
+
+func (d *diagnosticsAPI) OnCorruptedPacket(callback func(bridgedNodeID, reason string, portal Portal)) {
+	d.onCorruptedPacket = callback
+}
@@ -0,0 +1,232 @@
+package directmq
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type PingResult struct {
+	NodeID string
+	RTT    time.Duration
+
+	// Nodes traversed by the echo request before it reached
+	// the pinged node, this node is the first one.
+	Traversed []string
+}
+
+type TracerouteHop struct {
+	Hop    int
+	NodeID string
+	RTT    time.Duration
+
+	// TimedOut is set when the node on the route
+	// did not reply before the context was done.
+	TimedOut bool
+}
+
+/* globalNetwork echo handling */
+
+func (d *globalNetwork) EchoRequested(request EchoRequestMessage) {
+	originID := d.config.HostID
+	if len(request.Traversed) > 0 {
+		originID = request.Traversed[0]
+	}
+
+	if !d.echoes.MarkSeen(originID, request.EchoID) {
+		return
+	}
+
+	if len(request.Traversed) > 0 && request.TargetNodeID == d.config.HostID {
+		d.EchoReplied(EchoReplyMessage{
+			DataFrame: DataFrame{
+				TTL:       int32(d.config.HostTTL),
+				Traversed: []string{},
+			},
+			EchoID: request.EchoID,
+			NodeID: d.config.HostID,
+			Route:  append([]string{}, request.Traversed...),
+		})
+
+		return
+	}
+
+	if len(request.Route) == 0 {
+		for _, participant := range d.participants {
+			participant.HandleEchoRequest(request)
+		}
+
+		return
+	}
+
+	for _, participant := range d.participants {
+		if participant.HandleEchoRequest(request) {
+			return
+		}
+	}
+
+	d.logger.Debug("echo request dropped", slog.String("target_node_id", request.TargetNodeID), slog.Any("route", request.Route))
+}
+
+// learnEchoRoutes stores the route traversed by the echo reply,
+// the route leads to the replying node and every node on the way.
+func (d *globalNetwork) learnEchoRoutes(traversed []string) {
+	route := append([]string{}, traversed...)
+	for i, nodeID := range route {
+		d.echoRoutes[nodeID] = route[i:]
+	}
+}
+
+func (d *globalNetwork) EchoReplied(reply EchoReplyMessage) {
+	if len(reply.Route) == 0 {
+		d.pings.Deliver(reply)
+		return
+	}
+
+	for _, participant := range d.participants {
+		if participant.HandleEchoReply(reply) {
+			return
+		}
+	}
+
+	d.logger.Debug("echo reply dropped", slog.String("replying_node_id", reply.NodeID), slog.Any("route", reply.Route))
+}
+
+/* networkNode echo */
+
+// Ping sends the echo request to the node and waits for the reply
+// until the context is done. Only nodes with FEATURE_ECHO negotiated
+// on the whole path forward the request and the reply. The request
+// follows the route learned from the previous replies, it is flooded
+// only when the route to the node is not known yet or stopped working.
+func (n *networkNode) Ping(ctx context.Context, nodeID string) (PingResult, error) {
+	if nodeID == n.network.config.HostID {
+		return PingResult{NodeID: nodeID, Traversed: []string{}}, nil
+	}
+
+	echoID := newProbeID()
+
+	replies := n.network.pings.Start(echoID)
+	defer n.network.pings.Finish(echoID)
+
+	sentAt := time.Now()
+	n.network.lock()
+	route := n.network.echoRoutes[nodeID]
+	n.network.EchoRequested(EchoRequestMessage{
+		DataFrame: DataFrame{
+			TTL:       int32(n.network.config.HostTTL),
+			Traversed: []string{},
+		},
+		EchoID:       echoID,
+		TargetNodeID: nodeID,
+		Route:        route,
+	})
+	n.network.unlock()
+
+	select {
+	case <-ctx.Done():
+		if len(route) > 0 {
+			// the route could be outdated,
+			// so the next request floods the network again
+			n.network.lock()
+			delete(n.network.echoRoutes, nodeID)
+			n.network.unlock()
+		}
+
+		return PingResult{}, ctx.Err()
+	case reply := <-replies:
+		n.network.lock()
+		n.network.learnEchoRoutes(reply.Traversed)
+		n.network.unlock()
+
+		// reply traversed the path in the reverse order
+		// starting with the pinged node
+		traversed := []string{n.network.config.HostID}
+		for i := len(reply.Traversed) - 1; i > 0; i-- {
+			traversed = append(traversed, reply.Traversed[i])
+		}
+
+		return PingResult{
+			NodeID:    reply.NodeID,
+			RTT:       time.Since(sentAt),
+			Traversed: traversed,
+		}, nil
+	}
+}
+
+// Traceroute pings the node to learn the path leading to it and then
+// pings every intermediate node to measure its latency, the intermediate
+// nodes are pinged along the learned path. The hops are pinged at once,
+// each waiting for its reply until the context is done, so the hop
+// which does not reply is reported as timed out without delaying
+// the others.
+func (n *networkNode) Traceroute(ctx context.Context, nodeID string) ([]TracerouteHop, error) {
+	target, err := n.Ping(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	hops := make([]TracerouteHop, len(target.Traversed))
+
+	var wg sync.WaitGroup
+	for i, hopNodeID := range target.Traversed[1:] {
+		wg.Add(1)
+		go func(i int, hopNodeID string) {
+			defer wg.Done()
+
+			hop, err := n.Ping(ctx, hopNodeID)
+			hops[i] = TracerouteHop{Hop: i + 1, NodeID: hopNodeID, RTT: hop.RTT, TimedOut: err != nil}
+		}(i, hopNodeID)
+	}
+
+	wg.Wait()
+
+	hops[len(hops)-1] = TracerouteHop{Hop: len(target.Traversed), NodeID: target.NodeID, RTT: target.RTT}
+	return hops, nil
+}
+
+/* echo state */
+
+type pendingPings struct {
+	mutex   sync.Mutex
+	pending map[uint64]chan EchoReplyMessage
+}
+
+func newPendingPings() *pendingPings {
+	return &pendingPings{
+		pending: make(map[uint64]chan EchoReplyMessage),
+	}
+}
+
+func (p *pendingPings) Start(echoID uint64) <-chan EchoReplyMessage {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	replies := make(chan EchoReplyMessage, 1)
+	p.pending[echoID] = replies
+	return replies
+}
+
+func (p *pendingPings) Finish(echoID uint64) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	delete(p.pending, echoID)
+}
+
+func (p *pendingPings) Deliver(reply EchoReplyMessage) {
+	p.mutex.Lock()
+	replies, exists := p.pending[reply.EchoID]
+	p.mutex.Unlock()
+
+	if !exists {
+		// reply arrived after the ping has finished
+		return
+	}
+
+	select {
+	case replies <- reply:
+	default:
+	}
+}
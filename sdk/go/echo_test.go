@@ -0,0 +1,223 @@
+package directmq
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// silentEchoTestPortal drops the echo replies of the node writing to it.
+type silentEchoTestPortal struct {
+	*testPortal
+	nodeID string
+}
+
+func (p *silentEchoTestPortal) WritePacket(packet []byte) error {
+	frame, _, err := DecodeProtobufFrame(packet, PROTOBUF_FORMAT_BINARY)
+	if err == nil && frame.GetEchoReply().GetNodeId() == p.nodeID {
+		return nil
+	}
+
+	return p.testPortal.WritePacket(packet)
+}
+
+var _ = Describe("echo", func() {
+	newNode := func(id string) *networkNode {
+		return newNetworkNode(NetworkNodeConfig{
+			HostID:       id,
+			HostTTL:      DEFAULT_TTL,
+			HostFeatures: FEATURE_ECHO,
+		}, NewProtobufBinaryProtocol())
+	}
+
+	Context("when nodes are connected in a chain", func() {
+		var a, b, c *networkNode
+
+		BeforeEach(func() {
+			a, b, c = newNode("a"), newNode("b"), newNode("c")
+
+			connectTestNodes(a, b)
+			Eventually(b.GetBridgedNodeIDs).Should(HaveLen(1))
+			connectTestNodes(c, b)
+			Eventually(b.GetBridgedNodeIDs).Should(HaveLen(2))
+			Eventually(c.GetBridgedNodeIDs).Should(HaveLen(1))
+			Eventually(a.GetBridgedNodeIDs).Should(HaveLen(1))
+		})
+
+		AfterEach(func() {
+			a.CloseNode("test finished")
+			c.CloseNode("test finished")
+		})
+
+		It("should ping the distant node", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			result, err := a.Ping(ctx, "c")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.NodeID).To(Equal("c"))
+			Expect(result.Traversed).To(Equal([]string{"a", "b"}))
+			Expect(result.RTT).To(BeNumerically(">", 0))
+		})
+
+		It("should answer the ping of itself without sending anything", func() {
+			result, err := a.Ping(context.Background(), "a")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Traversed).To(BeEmpty())
+		})
+
+		It("should list every hop of the route", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			hops, err := a.Traceroute(ctx, "c")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hops).To(HaveLen(2))
+			Expect(hops[0].Hop).To(Equal(1))
+			Expect(hops[0].NodeID).To(Equal("b"))
+			Expect(hops[1].Hop).To(Equal(2))
+			Expect(hops[1].NodeID).To(Equal("c"))
+		})
+
+		It("should time out when the node does not exist", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			_, err := a.Ping(ctx, "unknown")
+
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+		})
+
+		It("should not reach the node when TTL is limited", func() {
+			// TTL is decremented before the request leaves the node
+			a.network.config.HostTTL = 2
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			_, err := a.Ping(ctx, "b")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = a.Ping(ctx, "c")
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+		})
+	})
+
+	Context("when the intermediate node does not reply", func() {
+		var a, b, c *networkNode
+
+		BeforeEach(func() {
+			a, b, c = newNode("a"), newNode("b"), newNode("c")
+
+			// b forwards the echo frames, but its own replies are lost
+			connectingPortal, listeningPortal := newTestPortalPair()
+			go a.AddConnectingEdge(connectingPortal)
+			go b.AddListeningEdge(&silentEchoTestPortal{listeningPortal, "b"})
+			Eventually(b.GetBridgedNodeIDs).Should(HaveLen(1))
+
+			connectTestNodes(c, b)
+			Eventually(b.GetBridgedNodeIDs).Should(HaveLen(2))
+			Eventually(a.GetBridgedNodeIDs).Should(HaveLen(1))
+			Eventually(c.GetBridgedNodeIDs).Should(HaveLen(1))
+		})
+
+		AfterEach(func() {
+			a.CloseNode("test finished")
+			c.CloseNode("test finished")
+		})
+
+		It("should report the hop as timed out and still list the following hops", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+			defer cancel()
+
+			hops, err := a.Traceroute(ctx, "c")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hops).To(Equal([]TracerouteHop{
+				{Hop: 1, NodeID: "b", TimedOut: true},
+				{Hop: 2, NodeID: "c", RTT: hops[1].RTT},
+			}))
+			Expect(hops[1].RTT).To(BeNumerically(">", 0))
+		})
+	})
+
+	Context("when the route to the node is learned", func() {
+		var a, b, c, d *networkNode
+
+		seenEchoRequests := func(node *networkNode) func() int {
+			return func() int {
+				node.network.echoes.mutex.Lock()
+				defer node.network.echoes.mutex.Unlock()
+
+				return len(node.network.echoes.order)
+			}
+		}
+
+		BeforeEach(func() {
+			a, b, c, d = newNode("a"), newNode("b"), newNode("c"), newNode("d")
+
+			connectTestNodes(a, b)
+			Eventually(b.GetBridgedNodeIDs).Should(HaveLen(1))
+			connectTestNodes(c, b)
+			Eventually(b.GetBridgedNodeIDs).Should(HaveLen(2))
+			connectTestNodes(d, b)
+			Eventually(b.GetBridgedNodeIDs).Should(HaveLen(3))
+			Eventually(a.GetBridgedNodeIDs).Should(HaveLen(1))
+			Eventually(c.GetBridgedNodeIDs).Should(HaveLen(1))
+			Eventually(d.GetBridgedNodeIDs).Should(HaveLen(1))
+		})
+
+		AfterEach(func() {
+			a.CloseNode("test finished")
+			c.CloseNode("test finished")
+			d.CloseNode("test finished")
+		})
+
+		It("should send the echo requests only along the route", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			// the first request floods the network to find the node
+			_, err := a.Ping(ctx, "c")
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(seenEchoRequests(d)).Should(Equal(1))
+
+			result, err := a.Ping(ctx, "c")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Traversed).To(Equal([]string{"a", "b"}))
+
+			hops, err := a.Traceroute(ctx, "c")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hops).To(HaveLen(2))
+
+			Consistently(seenEchoRequests(d), 100*time.Millisecond).Should(Equal(1))
+		})
+
+		It("should flood the request again when the route stopped working", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			_, err := a.Ping(ctx, "c")
+			Expect(err).ToNot(HaveOccurred())
+
+			a.network.lock()
+			a.network.echoRoutes["c"] = []string{"c", "missing"}
+			a.network.unlock()
+
+			timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer timeoutCancel()
+
+			_, err = a.Ping(timeoutCtx, "c")
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+
+			result, err := a.Ping(ctx, "c")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Traversed).To(Equal([]string{"a", "b"}))
+		})
+	})
+})
@@ -0,0 +1,145 @@
+package directmq
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// edgeWriteQueueSize is the number of frames waiting for a slow
+	// portal before the edge gives up on the bridged node.
+	edgeWriteQueueSize = 4096
+
+	// edgeCloseTimeout is the time given to the queued frames, e.g. the
+	// graceful close, to be written before the portal is closed anyway.
+	edgeCloseTimeout = 5 * time.Second
+)
+
+var errEdgeWriteQueueFull = errors.New("edge write queue is full, the bridged node does not keep up")
+var errEdgeWriterClosed = errors.New("edge writer closed")
+
+// edgeWriter is the PacketWriter of the edge protocol. Frames are written
+// with the node locked, so they are only queued here and written to the
+// portal by the writer goroutine. A slow or blocked portal stalls only its
+// own edge, never the node lock shared by all the edges.
+//
+// The first write failure is reported to onFailure and returned by all
+// the following writes. The portal is closed by the writer goroutine after
+// the queue is drained, or after edgeCloseTimeout when the portal blocks.
+type edgeWriter struct {
+	portal    Portal
+	onFailure func(err error)
+
+	mutex   sync.Mutex
+	ready   *sync.Cond
+	queue   [][]byte
+	err     error
+	closing bool
+	logger  *slog.Logger
+
+	closeOnce sync.Once
+}
+
+var _ PacketWriter = (*edgeWriter)(nil)
+
+func newEdgeWriter(portal Portal, onFailure func(err error)) *edgeWriter {
+	w := &edgeWriter{
+		portal:    portal,
+		onFailure: onFailure,
+		queue:     make([][]byte, 0),
+	}
+
+	w.ready = sync.NewCond(&w.mutex)
+	go w.run()
+
+	return w
+}
+
+func (w *edgeWriter) WritePacket(packet []byte) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+
+	if w.closing {
+		return errEdgeWriterClosed
+	}
+
+	if len(w.queue) >= edgeWriteQueueSize {
+		return errEdgeWriteQueueFull
+	}
+
+	w.queue = append(w.queue, packet)
+	w.ready.Signal()
+	return nil
+}
+
+// Close stops accepting frames and lets the writer goroutine close the
+// portal after the queued frames are written, the close failure is logged.
+func (w *edgeWriter) Close(logger *slog.Logger) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closing {
+		return
+	}
+
+	w.closing = true
+	w.logger = logger
+	w.ready.Signal()
+
+	time.AfterFunc(edgeCloseTimeout, w.closePortal)
+}
+
+func (w *edgeWriter) run() {
+	for {
+		w.mutex.Lock()
+		for len(w.queue) == 0 && !w.closing {
+			w.ready.Wait()
+		}
+
+		if len(w.queue) == 0 {
+			w.mutex.Unlock()
+			w.closePortal()
+			return
+		}
+
+		packet := w.queue[0]
+		w.queue[0] = nil
+		w.queue = w.queue[1:]
+		w.mutex.Unlock()
+
+		if err := w.portal.WritePacket(packet); err != nil {
+			w.fail(err)
+		}
+	}
+}
+
+func (w *edgeWriter) fail(err error) {
+	w.mutex.Lock()
+	w.err = err
+	w.queue = make([][]byte, 0)
+	closing := w.closing
+	w.mutex.Unlock()
+
+	// the edge closing the writer already gave up on the bridged node
+	if !closing {
+		w.onFailure(err)
+	}
+}
+
+func (w *edgeWriter) closePortal() {
+	w.closeOnce.Do(func() {
+		if err := w.portal.Close(); err != nil {
+			w.mutex.Lock()
+			logger := w.logger
+			w.mutex.Unlock()
+
+			logger.Warn("failed to close portal", slog.String("error", err.Error()))
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package directmq
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("edges", func() {
+	var a, b *networkNode
+
+	type stateChange struct {
+		bridgedNodeID string
+		previous      EdgeState
+		current       EdgeState
+	}
+
+	// every test records the changes separately, as the nodes
+	// of the previous test could still report their changes
+	type stateChanges struct {
+		mutex   sync.Mutex
+		changes []stateChange
+	}
+
+	var changes *stateChanges
+
+	getChanges := func() []stateChange {
+		changes.mutex.Lock()
+		defer changes.mutex.Unlock()
+		return append([]stateChange{}, changes.changes...)
+	}
+
+	BeforeEach(func() {
+		recorded := &stateChanges{}
+		changes = recorded
+
+		a = newNetworkNode(NetworkNodeConfig{HostID: "a", HostTTL: DEFAULT_TTL}, NewProtobufBinaryProtocol())
+		b = newNetworkNode(NetworkNodeConfig{HostID: "b", HostTTL: DEFAULT_TTL, HostMaxIncomingMessageSize: 1024}, NewProtobufBinaryProtocol())
+
+		a.OnEdgeStateChanged(func(edge EdgeInfo, previous EdgeState) {
+			recorded.mutex.Lock()
+			defer recorded.mutex.Unlock()
+			recorded.changes = append(recorded.changes, stateChange{edge.BridgedNodeID, previous, edge.State})
+		})
+
+		b.Subscribe("sensors/**", func([]byte) {})
+
+		connectTestNodes(a, b)
+		Eventually(a.GetBridgedNodeIDs).Should(HaveLen(1))
+	})
+
+	It("should describe the connected edge", func() {
+		Eventually(func() []string { return a.GetEdges()[0].BridgedNodeSubscriptions }).Should(ConsistOf("sensors/**"))
+
+		edges := a.GetEdges()
+		Expect(edges).To(HaveLen(1))
+		Expect(edges[0].BridgedNodeID).To(Equal("b"))
+		Expect(edges[0].State).To(Equal(EDGE_STATE_CONNECTED))
+		Expect(edges[0].NegotiatedProtocolVersion).To(Equal(uint32(PROTOCOL_VERSION)))
+		Expect(edges[0].BridgedNodeMaxMessageSize).To(Equal(uint64(1024)))
+		Expect(edges[0].ConnectionStartedAt).To(BeTemporally("~", time.Now(), time.Second))
+	})
+
+	It("should report every state change", func() {
+		a.CloseNode("test finished")
+
+		Eventually(getChanges).Should(Equal([]stateChange{
+			{"", EDGE_STATE_NONE, EDGE_STATE_CONNECTING},
+			{"b", EDGE_STATE_CONNECTING, EDGE_STATE_CONNECTED},
+			{"b", EDGE_STATE_CONNECTED, EDGE_STATE_DISCONNECTING},
+			{"b", EDGE_STATE_DISCONNECTING, EDGE_STATE_DISCONNECTED},
+		}))
+	})
+
+	It("should gracefully close the removed edge", func() {
+		reasons := make(chan string, 1)
+		b.OnConnectionLost(func(bridgedNodeID, reason string, portal Portal) {
+			reasons <- reason
+		})
+
+		a.RemoveEdge(a.edges[0].portal, "edge removed")
+
+		Expect(a.GetEdges()).To(BeEmpty())
+		Eventually(reasons).Should(Receive(Equal("edge removed")))
+	})
+
+	It("should keep serving the other edges while the portal of one of them is stalled", func() {
+		c := newNetworkNode(NetworkNodeConfig{HostID: "c", HostTTL: DEFAULT_TTL}, NewProtobufBinaryProtocol())
+		defer c.CloseNode("test finished")
+
+		received := make(chan []byte, 10)
+		b.Subscribe("alerts/**", func(payload []byte) { received <- payload })
+		c.Subscribe("alerts/**", func([]byte) {})
+
+		toC, fromA := newTestPortalPair()
+		stalled := &stalledTestPortal{testPortal: toC, release: make(chan struct{})}
+		defer close(stalled.release)
+
+		go c.AddListeningEdge(fromA)
+		go a.AddConnectingEdge(stalled)
+
+		Eventually(a.GetEdges).Should(ConsistOf(
+			HaveField("BridgedNodeSubscriptions", ContainElement("alerts/**")),
+			HaveField("BridgedNodeSubscriptions", ContainElement("alerts/**")),
+		))
+
+		stalled.stalled.Store(true)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := byte(1); i <= 3; i++ {
+				a.Publish("alerts/fire", []byte{i}, AT_LEAST_ONCE)
+			}
+			a.Subscribe("other", func([]byte) {})
+		}()
+
+		Eventually(done).Should(BeClosed())
+		for i := byte(1); i <= 3; i++ {
+			Eventually(received).Should(Receive(Equal([]byte{i})))
+		}
+	})
+
+	DescribeTable("should disconnect the bridged node sending the incorrect topic",
+		func(send func(edge *networkEdge) error, reason string) {
+			reasons := make(chan string, 1)
+			b.OnConnectionLost(func(bridgedNodeID, reason string, portal Portal) {
+				reasons <- reason
+			})
+
+			a.network.lock()
+			Expect(send(a.edges[0])).To(Succeed())
+			a.network.unlock()
+
+			Eventually(reasons).Should(Receive(Equal(reason)))
+		},
+
+		Entry("in the publication", func(edge *networkEdge) error {
+			return edge.protocol.Publish(PublishMessage{
+				DataFrame: DataFrame{TTL: DEFAULT_TTL, Traversed: []string{"a"}},
+				Topic:     "sensors//temperature",
+				Payload:   []byte("payload"),
+			})
+		}, "Received publication with incorrect topic"),
+
+		Entry("in the subscription", func(edge *networkEdge) error {
+			return edge.protocol.Subscribe(SubscribeMessage{
+				DataFrame: DataFrame{TTL: DEFAULT_TTL, Traversed: []string{"a"}},
+				Topic:     "sensors/",
+			})
+		}, "Received subscription with incorrect topic pattern"),
+	)
+})
+
+// stalledTestPortal blocks the writes once stalled, until released,
+// like a portal of the bridged node not reading anymore.
+type stalledTestPortal struct {
+	*testPortal
+
+	stalled atomic.Bool
+	release chan struct{}
+}
+
+func (p *stalledTestPortal) WritePacket(packet []byte) error {
+	if p.stalled.Load() {
+		<-p.release
+	}
+
+	return p.testPortal.WritePacket(packet)
+}
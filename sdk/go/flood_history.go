@@ -0,0 +1,49 @@
+package directmq
+
+import "sync"
+
+const (
+	TOPOLOGY_PROBE_HISTORY_SIZE = 128
+	ECHO_REQUEST_HISTORY_SIZE   = 128
+)
+
+type floodKey struct {
+	originID string
+	id       uint64
+}
+
+// floodHistory remembers recently seen flooded frames, so every
+// frame is answered and forwarded only once by the node.
+type floodHistory struct {
+	mutex sync.Mutex
+	size  int
+	seen  map[floodKey]struct{}
+	order []floodKey
+}
+
+func newFloodHistory(size int) *floodHistory {
+	return &floodHistory{
+		size:  size,
+		seen:  make(map[floodKey]struct{}),
+		order: make([]floodKey, 0, size),
+	}
+}
+
+func (h *floodHistory) MarkSeen(originID string, id uint64) (firstTime bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	key := floodKey{originID, id}
+	if _, exists := h.seen[key]; exists {
+		return false
+	}
+
+	if len(h.order) >= h.size {
+		delete(h.seen, h.order[0])
+		h.order = h.order[1:]
+	}
+
+	h.seen[key] = struct{}{}
+	h.order = append(h.order, key)
+	return true
+}
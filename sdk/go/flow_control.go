@@ -0,0 +1,229 @@
+package directmq
+
+import "sync"
+
+type flowControlResult int
+
+const (
+	publicationSent flowControlResult = iota
+	publicationQueued
+	publicationDropped
+)
+
+// flowControl tracks the credit granted to the host by the bridged node
+// (outgoing direction) and the credit used up by the bridged node
+// on the host (incoming direction).
+//
+// Byte credit is counted in the sizes of the encoded frames, so it matches
+// the bytes read by the bridged node. It is allowed to be overdrawn by the last
+// sent publication, as the size is known only after the frame is written,
+// and publications larger than the granted window are not stalled forever.
+//
+// The mutex is never held while the publication is written to the portal.
+type flowControl struct {
+	mutex sync.Mutex
+
+	frameLimited bool
+	byteLimited  bool
+	frameCredit  int64
+	byteCredit   int64
+
+	queue        []PublishMessage
+	maxQueueSize int
+
+	granting       bool
+	grantFrames    uint32
+	grantBytes     uint64
+	consumedFrames uint32
+	consumedBytes  uint64
+}
+
+func newFlowControl() *flowControl {
+	return &flowControl{
+		queue: make([]PublishMessage, 0),
+	}
+}
+
+func (f *flowControl) Reset(config NetworkNodeConfig, bridgedNode edgeInfo) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	flowControlNegotiated := bridgedNode.NegotiatedFeatures.Has(FEATURE_FLOW_CONTROL)
+
+	f.frameLimited = flowControlNegotiated && bridgedNode.BridgedNodeFrameCredit != NO_FLOW_CONTROL
+	f.byteLimited = flowControlNegotiated && bridgedNode.BridgedNodeByteCredit != NO_FLOW_CONTROL
+	f.frameCredit = int64(bridgedNode.BridgedNodeFrameCredit)
+	f.byteCredit = int64(bridgedNode.BridgedNodeByteCredit)
+
+	f.queue = make([]PublishMessage, 0)
+	f.maxQueueSize = config.HostOutgoingQueueSize
+	if f.maxQueueSize <= 0 {
+		f.maxQueueSize = DEFAULT_OUTGOING_QUEUE_SIZE
+	}
+
+	f.grantFrames, f.grantBytes = getHostIncomingCredit(config)
+	f.granting = flowControlNegotiated && (f.grantFrames != NO_FLOW_CONTROL || f.grantBytes != NO_FLOW_CONTROL)
+	f.consumedFrames = 0
+	f.consumedBytes = 0
+}
+
+func (f *flowControl) Clear() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.frameLimited = false
+	f.byteLimited = false
+	f.frameCredit = 0
+	f.byteCredit = 0
+	f.queue = make([]PublishMessage, 0)
+	f.granting = false
+	f.consumedFrames = 0
+	f.consumedBytes = 0
+}
+
+func (f *flowControl) QueueLength() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return len(f.queue)
+}
+
+// Send writes the publication immediately when there is enough credit
+// and nothing is waiting in the queue, otherwise the publication is queued
+// until the bridged node grants more credit. The write returns the size
+// of the written frame, which is used up from the byte credit.
+func (f *flowControl) Send(publication PublishMessage, write func(PublishMessage) (size int, err error)) (result flowControlResult, queueLength int, err error) {
+	f.mutex.Lock()
+
+	if len(f.queue) == 0 && f.hasCredit() {
+		f.takeFrameCredit()
+		f.mutex.Unlock()
+
+		return publicationSent, 0, f.writeWithByteCredit(publication, write)
+	}
+
+	defer f.mutex.Unlock()
+
+	if len(f.queue) >= f.maxQueueSize {
+		return publicationDropped, len(f.queue), nil
+	}
+
+	f.queue = append(f.queue, publication)
+	return publicationQueued, len(f.queue), nil
+}
+
+// Grant adds the credit received from the bridged node
+// and writes all queued publications that fit into it.
+func (f *flowControl) Grant(credit FlowCreditMessage, write func(PublishMessage) (size int, err error)) error {
+	f.mutex.Lock()
+	f.frameCredit += int64(credit.Frames)
+	f.byteCredit += int64(credit.Bytes)
+	f.mutex.Unlock()
+
+	for {
+		publication, ok := f.dequeue()
+		if !ok {
+			return nil
+		}
+
+		if err := f.writeWithByteCredit(publication, write); err != nil {
+			return err
+		}
+	}
+}
+
+// dequeue returns the first queued publication when there is
+// the credit to send it and uses up the frame credit.
+func (f *flowControl) dequeue() (publication PublishMessage, ok bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if len(f.queue) == 0 || !f.hasCredit() {
+		return PublishMessage{}, false
+	}
+
+	publication = f.queue[0]
+	f.queue = f.queue[1:]
+
+	f.takeFrameCredit()
+	return publication, true
+}
+
+// writeWithByteCredit writes the publication without holding the mutex
+// and then uses up the byte credit by the size of the written frame.
+func (f *flowControl) writeWithByteCredit(publication PublishMessage, write func(PublishMessage) (size int, err error)) error {
+	size, err := write(publication)
+	if err != nil {
+		return err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.byteLimited {
+		f.byteCredit -= int64(size)
+	}
+
+	return nil
+}
+
+// Consume records the frame of the given size received from the bridged
+// node, when half of the granted window is used up it returns the credit
+// that should be given back to the bridged node.
+func (f *flowControl) Consume(size int) (frames uint32, bytes uint64, shouldGrant bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if !f.granting {
+		return 0, 0, false
+	}
+
+	f.consumedFrames++
+	f.consumedBytes += uint64(size)
+
+	framesThresholdReached := f.grantFrames != NO_FLOW_CONTROL && f.consumedFrames >= halfOfWindow(f.grantFrames)
+	bytesThresholdReached := f.grantBytes != NO_FLOW_CONTROL && f.consumedBytes >= halfOfWindow(f.grantBytes)
+
+	if !framesThresholdReached && !bytesThresholdReached {
+		return 0, 0, false
+	}
+
+	frames, bytes = f.consumedFrames, f.consumedBytes
+	f.consumedFrames, f.consumedBytes = 0, 0
+
+	return frames, bytes, true
+}
+
+func (f *flowControl) hasCredit() bool {
+	if f.frameLimited && f.frameCredit <= 0 {
+		return false
+	}
+
+	if f.byteLimited && f.byteCredit <= 0 {
+		return false
+	}
+
+	return true
+}
+
+func (f *flowControl) takeFrameCredit() {
+	if f.frameLimited {
+		f.frameCredit--
+	}
+}
+
+func getHostIncomingCredit(config NetworkNodeConfig) (frames uint32, bytes uint64) {
+	if !getHostFeatures(config).Has(FEATURE_FLOW_CONTROL) {
+		return NO_FLOW_CONTROL, NO_FLOW_CONTROL
+	}
+
+	return config.HostIncomingFrameCredit, config.HostIncomingByteCredit
+}
+
+func halfOfWindow[T uint32 | uint64](window T) T {
+	if window < 2 {
+		return 1
+	}
+
+	return window / 2
+}
@@ -0,0 +1,275 @@
+package directmq
+
+import (
+	"sync"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("flowControl", func() {
+	var flow *flowControl
+	var written []PublishMessage
+
+	// the frame is written with the header of the fixed size
+	const frameHeaderSize = 10
+
+	write := func(publication PublishMessage) (int, error) {
+		written = append(written, publication)
+		return frameHeaderSize + len(publication.Payload), nil
+	}
+
+	publication := func(topic string, size int) PublishMessage {
+		return PublishMessage{
+			Topic:   topic,
+			Payload: make([]byte, size),
+		}
+	}
+
+	config := NetworkNodeConfig{
+		HostFeatures:            FEATURE_FLOW_CONTROL,
+		HostIncomingFrameCredit: 4,
+		HostIncomingByteCredit:  NO_FLOW_CONTROL,
+		HostOutgoingQueueSize:   2,
+	}
+
+	bridgedNode := func(frameCredit uint32, byteCredit uint64) edgeInfo {
+		return edgeInfo{
+			BridgedNodeFrameCredit: frameCredit,
+			BridgedNodeByteCredit:  byteCredit,
+			NegotiatedFeatures:     FEATURE_FLOW_CONTROL,
+		}
+	}
+
+	BeforeEach(func() {
+		flow = newFlowControl()
+		written = make([]PublishMessage, 0)
+	})
+
+	Context("when flow control is not negotiated", func() {
+		It("should send every publication immediately", func() {
+			flow.Reset(config, edgeInfo{BridgedNodeFrameCredit: 1})
+
+			for i := 0; i < 10; i++ {
+				result, _, err := flow.Send(publication("topic", 1), write)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(publicationSent))
+			}
+
+			Expect(written).To(HaveLen(10))
+		})
+
+		It("should not grant any credit", func() {
+			flow.Reset(config, edgeInfo{})
+
+			_, _, shouldGrant := flow.Consume(1)
+			Expect(shouldGrant).To(BeFalse())
+		})
+	})
+
+	Context("when bridged node grants frame credit", func() {
+		It("should queue publications after the credit is used up", func() {
+			flow.Reset(config, bridgedNode(1, NO_FLOW_CONTROL))
+
+			result, _, _ := flow.Send(publication("first", 1), write)
+			Expect(result).To(Equal(publicationSent))
+
+			result, queueLength, _ := flow.Send(publication("second", 1), write)
+			Expect(result).To(Equal(publicationQueued))
+			Expect(queueLength).To(Equal(1))
+			Expect(written).To(HaveLen(1))
+		})
+
+		It("should drop publications when the queue is full", func() {
+			flow.Reset(config, bridgedNode(1, NO_FLOW_CONTROL))
+
+			flow.Send(publication("sent", 1), write)
+			flow.Send(publication("queued", 1), write)
+			flow.Send(publication("queued", 1), write)
+
+			result, queueLength, _ := flow.Send(publication("dropped", 1), write)
+			Expect(result).To(Equal(publicationDropped))
+			Expect(queueLength).To(Equal(2))
+		})
+
+		It("should send queued publications in order after receiving credit", func() {
+			flow.Reset(config, bridgedNode(1, NO_FLOW_CONTROL))
+
+			flow.Send(publication("first", 1), write)
+			flow.Send(publication("second", 1), write)
+			flow.Send(publication("third", 1), write)
+
+			Expect(flow.Grant(FlowCreditMessage{Frames: 1}, write)).To(Succeed())
+			Expect(written).To(HaveLen(2))
+			Expect(flow.QueueLength()).To(Equal(1))
+
+			Expect(flow.Grant(FlowCreditMessage{Frames: 5}, write)).To(Succeed())
+			Expect(written).To(HaveLen(3))
+			Expect(written[1].Topic).To(Equal("second"))
+			Expect(written[2].Topic).To(Equal("third"))
+		})
+	})
+
+	Context("when bridged node grants byte credit", func() {
+		It("should allow overdrawing the credit by a single publication", func() {
+			flow.Reset(config, bridgedNode(NO_FLOW_CONTROL, 10))
+
+			result, _, _ := flow.Send(publication("large", 100), write)
+			Expect(result).To(Equal(publicationSent))
+
+			result, _, _ = flow.Send(publication("small", 1), write)
+			Expect(result).To(Equal(publicationQueued))
+
+			Expect(flow.Grant(FlowCreditMessage{Bytes: 100}, write)).To(Succeed())
+			Expect(flow.QueueLength()).To(Equal(1))
+
+			Expect(flow.Grant(FlowCreditMessage{Bytes: 1}, write)).To(Succeed())
+			Expect(flow.QueueLength()).To(Equal(0))
+		})
+
+		It("should use up the credit by the size of the written frame", func() {
+			flow.Reset(config, bridgedNode(NO_FLOW_CONTROL, 20))
+
+			result, _, _ := flow.Send(publication("first", 5), write)
+			Expect(result).To(Equal(publicationSent))
+
+			result, _, _ = flow.Send(publication("second", 1), write)
+			Expect(result).To(Equal(publicationSent))
+
+			// the payloads fit into the credit, but the frames do not
+			result, _, _ = flow.Send(publication("third", 1), write)
+			Expect(result).To(Equal(publicationQueued))
+		})
+	})
+
+	Context("when host grants credit", func() {
+		It("should return the credit after half of the window is consumed", func() {
+			flow.Reset(config, bridgedNode(NO_FLOW_CONTROL, NO_FLOW_CONTROL))
+
+			_, _, shouldGrant := flow.Consume(3)
+			Expect(shouldGrant).To(BeFalse())
+
+			frames, bytes, shouldGrant := flow.Consume(5)
+			Expect(shouldGrant).To(BeTrue())
+			Expect(frames).To(Equal(uint32(2)))
+			Expect(bytes).To(Equal(uint64(8)))
+		})
+
+		It("should not grant credit when flow control is not enabled on host", func() {
+			flow.Reset(NetworkNodeConfig{HostIncomingFrameCredit: 1}, bridgedNode(NO_FLOW_CONTROL, NO_FLOW_CONTROL))
+
+			_, _, shouldGrant := flow.Consume(1)
+			Expect(shouldGrant).To(BeFalse())
+		})
+	})
+
+	Context("when writing the publication", func() {
+		It("should not hold the mutex", func() {
+			flow.Reset(config, bridgedNode(1, NO_FLOW_CONTROL))
+
+			queueLengths := []int{}
+			writeReadingQueue := func(publication PublishMessage) (int, error) {
+				queueLengths = append(queueLengths, flow.QueueLength())
+				return write(publication)
+			}
+
+			flow.Send(publication("first", 1), writeReadingQueue)
+			flow.Send(publication("second", 1), writeReadingQueue)
+			Expect(flow.Grant(FlowCreditMessage{Frames: 1}, writeReadingQueue)).To(Succeed())
+
+			Expect(queueLengths).To(Equal([]int{0, 0}))
+		})
+	})
+
+	Context("when cleared", func() {
+		It("should discard all queued publications", func() {
+			flow.Reset(config, bridgedNode(1, NO_FLOW_CONTROL))
+
+			flow.Send(publication("sent", 1), write)
+			flow.Send(publication("queued", 1), write)
+			flow.Clear()
+
+			Expect(flow.QueueLength()).To(Equal(0))
+		})
+	})
+})
+
+// pausablePortal stops reading the packets while it is paused,
+// so the bridged node can not return the consumed credit.
+type pausablePortal struct {
+	*testPortal
+	reading sync.Mutex
+}
+
+func (p *pausablePortal) ReadPacket() ([]byte, error) {
+	p.reading.Lock()
+	p.reading.Unlock()
+
+	return p.testPortal.ReadPacket()
+}
+
+var _ = Describe("flow control between nodes", func() {
+	var sender, receiver *networkNode
+	var receiverPortal *pausablePortal
+	var received atomic.Int32
+
+	BeforeEach(func() {
+		sender = newNetworkNode(NetworkNodeConfig{
+			HostID:       "sender",
+			HostTTL:      DEFAULT_TTL,
+			HostFeatures: FEATURE_FLOW_CONTROL,
+		}, NewProtobufBinaryProtocol())
+
+		receiver = newNetworkNode(NetworkNodeConfig{
+			HostID:                  "receiver",
+			HostTTL:                 DEFAULT_TTL,
+			HostFeatures:            FEATURE_FLOW_CONTROL,
+			HostIncomingFrameCredit: 4,
+			HostIncomingByteCredit:  NO_FLOW_CONTROL,
+		}, NewProtobufBinaryProtocol())
+
+		received.Store(0)
+		receiver.Subscribe("topic", func([]byte) { received.Add(1) })
+
+		senderPortal, listeningPortal := newTestPortalPair()
+		receiverPortal = &pausablePortal{testPortal: listeningPortal}
+
+		go receiver.AddListeningEdge(receiverPortal)
+		go sender.AddConnectingEdge(senderPortal)
+
+		Eventually(func() []string {
+			edges := sender.GetEdges()
+			if len(edges) == 0 {
+				return nil
+			}
+
+			return edges[0].BridgedNodeSubscriptions
+		}).Should(ContainElement("topic"))
+	})
+
+	AfterEach(func() {
+		sender.CloseNode("test finished")
+	})
+
+	It("should stop sending without the credit and resume after it is returned", func() {
+		var stalled atomic.Int32
+		sender.OnFlowControlStall(func(bridgedNodeID string, queuedPublications int) {
+			stalled.Store(int32(queuedPublications))
+		})
+
+		receiverPortal.reading.Lock()
+
+		for i := 0; i < 10; i++ {
+			sender.Publish("topic", []byte("payload"), AT_LEAST_ONCE)
+		}
+
+		// the receiver could read only the packet it was already waiting for
+		Expect(stalled.Load()).To(BeNumerically(">=", 6))
+		Consistently(received.Load).Should(BeNumerically("<=", 1))
+
+		receiverPortal.reading.Unlock()
+
+		Eventually(received.Load).Should(Equal(int32(10)))
+	})
+})
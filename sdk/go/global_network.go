@@ -1,20 +1,61 @@
 package directmq
 
+import (
+	"log/slog"
+	"sync"
+)
+
 type networkParticipant interface {
 	GetSubscribedTopics() []string
 	WillHandleTopic(topic string) bool
 	IsOriginOfFrame(message DataFrame) bool
 
-	HandlePublish(publication PublishMessage) (handled bool)
+	// HandlePublish returns the reason when the participant
+	// could handle the publication, but had to drop it.
+	HandlePublish(publication PublishMessage) (handled bool, dropReason DropReason)
 	HandleSubscribe(subscription SubscribeMessage)
 	HandleUnsubscribe(unsubscribe UnsubscribeMessage)
 	HandleTerminateNetwork(terminate TerminateNetworkMessage)
+	HandleTopologyProbe(probe TopologyProbeMessage)
+	HandleTopologyReport(report TopologyReportMessage) (handled bool)
+	HandleEchoRequest(request EchoRequestMessage) (handled bool)
+	HandleEchoReply(reply EchoReplyMessage) (handled bool)
 }
 
 type globalNetwork struct {
+	// mutex serializes the frames read by the edges, the API calls
+	// and the timers, so the state of the node is never accessed
+	// concurrently. Callbacks of the user are called after the mutex
+	// is released, so they can use the node again.
+	mutex     sync.Mutex
+	callbacks []func()
+
 	config       NetworkNodeConfig
 	participants []networkParticipant
 	diag         *diagnosticsAPI
+	metrics      *nodeMetrics
+	tracer       *tracer
+	logger       *slog.Logger
+
+	// top level topics of every participant, reference counted,
+	// every change of them passes through Subscribed and Unsubscribed
+	subscribedTopics *topicCoverSet
+
+	probes      *floodHistory
+	discoveries *topologyDiscoveries
+
+	echoes *floodHistory
+	pings  *pendingPings
+
+	// routes to the nodes learned from the echo replies,
+	// the target is the first node and the next hop is the last one
+	echoRoutes map[string][]string
+}
+
+type publicationResult struct {
+	delivered  bool       // handled by the native API
+	forwarded  bool       // handled by any of the edges
+	dropReason DropReason // set when handled by none of the participants
 }
 
 func newGlobalNetwork(config NetworkNodeConfig, nativeAPI *nativeAPI, diag *diagnosticsAPI) *globalNetwork {
@@ -22,47 +63,114 @@ func newGlobalNetwork(config NetworkNodeConfig, nativeAPI *nativeAPI, diag *diag
 		config:       config,
 		participants: []networkParticipant{nativeAPI},
 		diag:         diag,
+		metrics:      newNodeMetrics(),
+		tracer:       newTracer(config),
+		logger:       getLogger(config),
+
+		subscribedTopics: newTopicCoverSet(),
+
+		probes:      newFloodHistory(TOPOLOGY_PROBE_HISTORY_SIZE),
+		discoveries: newTopologyDiscoveries(),
+
+		echoes: newFloodHistory(ECHO_REQUEST_HISTORY_SIZE),
+		pings:  newPendingPings(),
+
+		echoRoutes: make(map[string][]string),
 	}
 }
 
-func (d *globalNetwork) GetAllSubscribedTopics() []string {
-	topics := make([]string, 0)
-	for _, participant := range d.participants {
-		topics = append(topics, participant.GetSubscribedTopics()...)
+func (d *globalNetwork) lock() {
+	d.mutex.Lock()
+}
+
+// unlock releases the node and calls the callbacks
+// scheduled while the node was locked.
+func (d *globalNetwork) unlock() {
+	callbacks := d.callbacks
+	d.callbacks = nil
+	d.mutex.Unlock()
+
+	for _, callback := range callbacks {
+		callback()
 	}
+}
 
-	return unique(topics)
+// locker returns the node lock for the helpers calling
+// back into the node from their own goroutines.
+func (d *globalNetwork) locker() sync.Locker {
+	return globalNetworkLocker{network: d}
+}
+
+type globalNetworkLocker struct {
+	network *globalNetwork
+}
+
+func (l globalNetworkLocker) Lock() {
+	l.network.lock()
 }
 
-func (d *globalNetwork) getSubscribedTopicsExcludingOriginOfMessage(frame DataFrame) []string {
+func (l globalNetworkLocker) Unlock() {
+	l.network.unlock()
+}
+
+// schedule calls the callback of the user after the node is unlocked,
+// it has to be called with the node locked.
+func (d *globalNetwork) schedule(callback func()) {
+	d.callbacks = append(d.callbacks, callback)
+}
+
+func (d *globalNetwork) GetAllSubscribedTopics() []string {
 	topics := make([]string, 0)
 	for _, participant := range d.participants {
-		if participant.IsOriginOfFrame(frame) {
-			continue
-		}
-
 		topics = append(topics, participant.GetSubscribedTopics()...)
 	}
 
 	return unique(topics)
 }
 
-func (d *globalNetwork) Published(message PublishMessage) {
+func (d *globalNetwork) Published(message PublishMessage) (result publicationResult) {
 	d.diag.HandlePublish(message)
 
+	fanOut := 0
+	dropReason := DROP_REASON_NO_SUBSCRIBERS
 	for _, participant := range randomOrder(d.participants) {
-		handled := participant.HandlePublish(message)
+		handled, reason := participant.HandlePublish(message)
+		if !handled && reason != "" && dropReason == DROP_REASON_NO_SUBSCRIBERS {
+			// the first reason is reported, so the publication is counted once
+			dropReason = reason
+		}
+
+		if handled {
+			fanOut++
+
+			if _, isNativeAPI := participant.(*nativeAPI); isNativeAPI {
+				result.delivered = true
+			} else {
+				result.forwarded = true
+			}
+		}
+
 		if message.DeliveryStrategy == AT_MOST_ONCE && handled {
-			return
+			break
 		}
 	}
+
+	d.metrics.RecordPublication(fanOut)
+	if fanOut == 0 {
+		result.dropReason = dropReason
+		d.metrics.RecordDroppedPublication(dropReason)
+		d.logger.Debug("publication dropped", slog.String("topic", message.Topic), slog.String("reason", string(dropReason)))
+	}
+
+	return result
 }
 
 func (d *globalNetwork) Subscribed(message SubscribeMessage) {
 	d.diag.HandleSubscribe(message)
 
-	// todo: we need to check if every edge has given topic subscribed
-	topLevelSubscriptions := d.getSubscribedTopicsExcludingOriginOfMessage(message.DataFrame)
+	// the cover set returns the topics covered by the new one
+	// without comparing all subscribed topics with each other
+	topicsToUnsubscribe, _ := d.subscribedTopics.Add(message.Topic)
 
 	for _, participant := range d.participants {
 		participant.HandleSubscribe(message)
@@ -75,9 +183,6 @@ func (d *globalNetwork) Subscribed(message SubscribeMessage) {
 		return
 	}
 
-	updatedTopLevelSubscriptions := d.GetAllSubscribedTopics()
-	topicsToUnsubscribe, _ := GetDeduplicatedOverlappingTopicsDiff(topLevelSubscriptions, updatedTopLevelSubscriptions)
-
 	for _, topic := range topicsToUnsubscribe {
 		message := UnsubscribeMessage{
 			DataFrame: message.DataFrame,
@@ -85,7 +190,9 @@ func (d *globalNetwork) Subscribed(message SubscribeMessage) {
 		}
 
 		for _, participant := range d.participants {
-			if participant.WillHandleTopic(message.Topic) {
+			// participant could still exchange publications
+			// matching the topic, so it has to stay subscribed
+			if overlapsSubscribedTopics(participant, message.Topic) {
 				continue
 			}
 
@@ -96,12 +203,12 @@ func (d *globalNetwork) Subscribed(message SubscribeMessage) {
 
 func (d *globalNetwork) Unsubscribed(message UnsubscribeMessage) {
 	d.diag.HandleUnsubscribe(message)
+	d.subscribedTopics.Remove(message.Topic)
 
-	// todo: we need to check if every edge has given topic unsubscribed
-	for _, participant := range d.participants {
-		if participant.WillHandleTopic(message.Topic) {
-			return
-		}
+	// the topic is still needed when any participant
+	// keeps the subscription which covers it
+	if d.subscribedTopics.Covers(message.Topic) {
+		return
 	}
 
 	for _, participant := range d.participants {
@@ -109,6 +216,18 @@ func (d *globalNetwork) Unsubscribed(message UnsubscribeMessage) {
 	}
 }
 
+// overlapsSubscribedTopics checks if any of the participant subscriptions
+// could match the same topic as the pattern.
+func overlapsSubscribedTopics(participant networkParticipant, pattern string) bool {
+	for _, subscribed := range participant.GetSubscribedTopics() {
+		if PatternsOverlap(subscribed, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (d *globalNetwork) Terminated(message TerminateNetworkMessage) {
 	d.diag.HandleTerminateNetwork(message)
 
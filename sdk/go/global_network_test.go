@@ -0,0 +1,77 @@
+package directmq
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("subscription propagation", func() {
+	var a, b, c *networkNode
+
+	bridgedNodeSubscriptions := func(node *networkNode) func() []string {
+		return func() []string {
+			return node.GetEdges()[0].BridgedNodeSubscriptions
+		}
+	}
+
+	BeforeEach(func() {
+		newNode := func(id string) *networkNode {
+			return newNetworkNode(NetworkNodeConfig{HostID: id, HostTTL: DEFAULT_TTL}, NewProtobufBinaryProtocol())
+		}
+
+		a, b, c = newNode("a"), newNode("b"), newNode("c")
+
+		connectTestNodes(a, b)
+		Eventually(b.GetBridgedNodeIDs).Should(HaveLen(1))
+		connectTestNodes(c, b)
+		Eventually(b.GetBridgedNodeIDs).Should(HaveLen(2))
+		Eventually(c.GetBridgedNodeIDs).Should(HaveLen(1))
+	})
+
+	AfterEach(func() {
+		a.CloseNode("test finished")
+		c.CloseNode("test finished")
+	})
+
+	It("should revoke the subscription not covered by the remaining ones", func() {
+		id := a.Subscribe("**", func([]byte) {})
+		c.Subscribe("*", func([]byte) {})
+		Eventually(bridgedNodeSubscriptions(c)).Should(ConsistOf("**"))
+
+		// * matches the ** string, but not the topics of the ** pattern
+		a.Unsubscribe(id)
+		Eventually(bridgedNodeSubscriptions(c)).Should(BeEmpty())
+	})
+
+	It("should keep the subscription covered by the remaining ones", func() {
+		id := a.Subscribe("sensors/*", func([]byte) {})
+		c.Subscribe("sensors/**", func([]byte) {})
+		Eventually(bridgedNodeSubscriptions(a)).Should(ConsistOf("sensors/**"))
+
+		a.Unsubscribe(id)
+		Consistently(bridgedNodeSubscriptions(a)).Should(ConsistOf("sensors/**"))
+	})
+})
+
+func BenchmarkGlobalNetworkRemoteSubscribe(b *testing.B) {
+	for _, size := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("subscriptions=%d", size), func(b *testing.B) {
+			node := newNetworkNode(NetworkNodeConfig{HostID: "host", HostTTL: DEFAULT_TTL}, NewProtobufBinaryProtocol())
+			for i := 0; i < size; i++ {
+				node.Subscribe(fmt.Sprintf("devices/%d/telemetry", i), func([]byte) {})
+			}
+
+			frame := DataFrame{TTL: DEFAULT_TTL, Traversed: []string{"remote"}}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				topic := fmt.Sprintf("remote/%d/*", i)
+				node.network.Subscribed(SubscribeMessage{DataFrame: frame, Topic: topic})
+				node.network.Unsubscribed(UnsubscribeMessage{DataFrame: frame, Topic: topic})
+			}
+		})
+	}
+}
@@ -1,6 +1,6 @@
 module github.com/sync-toys/DirectMQ/sdk/go
 
-go 1.20
+go 1.21
 
 require (
 	github.com/gobwas/glob v0.2.3
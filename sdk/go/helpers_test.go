@@ -0,0 +1,66 @@
+package directmq
+
+import (
+	"errors"
+	"sync"
+)
+
+var errTestPortalClosed = errors.New("test portal closed")
+
+// testPortal is one end of the in-memory connection between two nodes.
+type testPortal struct {
+	incoming <-chan []byte
+	outgoing chan<- []byte
+
+	closed    chan struct{}
+	closeOnce *sync.Once
+}
+
+var _ Portal = (*testPortal)(nil)
+
+func newTestPortalPair() (*testPortal, *testPortal) {
+	aToB := make(chan []byte, 64)
+	bToA := make(chan []byte, 64)
+	closed := make(chan struct{})
+	closeOnce := &sync.Once{}
+
+	return &testPortal{bToA, aToB, closed, closeOnce}, &testPortal{aToB, bToA, closed, closeOnce}
+}
+
+func (p *testPortal) ReadPacket() ([]byte, error) {
+	select {
+	case packet := <-p.incoming:
+		return packet, nil
+	case <-p.closed:
+		return nil, errTestPortalClosed
+	}
+}
+
+func (p *testPortal) WritePacket(packet []byte) error {
+	select {
+	case <-p.closed:
+		return errTestPortalClosed
+	default:
+	}
+
+	select {
+	case p.outgoing <- packet:
+		return nil
+	case <-p.closed:
+		return errTestPortalClosed
+	}
+}
+
+func (p *testPortal) Close() error {
+	p.closeOnce.Do(func() { close(p.closed) })
+	return nil
+}
+
+// connectTestNodes bridges the nodes using the in-memory portals,
+// edges are running in the background until the portals are closed.
+func connectTestNodes(connecting, listening *networkNode) {
+	connectingPortal, listeningPortal := newTestPortalPair()
+
+	go listening.AddListeningEdge(listeningPortal)
+	go connecting.AddConnectingEdge(connectingPortal)
+}
@@ -0,0 +1,26 @@
+package directmq
+
+import (
+	"context"
+	"log/slog"
+)
+
+// discardLogHandler drops all log records,
+// it is used when no logger is configured.
+type discardLogHandler struct{}
+
+var _ slog.Handler = discardLogHandler{}
+
+func (discardLogHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardLogHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardLogHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardLogHandler) WithGroup(string) slog.Handler           { return h }
+
+func getLogger(config NetworkNodeConfig) *slog.Logger {
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.New(discardLogHandler{})
+	}
+
+	return logger.With(slog.String("node_id", config.HostID))
+}
@@ -0,0 +1,70 @@
+package directmq
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type closedPortal struct{}
+
+func (closedPortal) ReadPacket() ([]byte, error) { return nil, io.EOF }
+func (closedPortal) WritePacket([]byte) error    { return nil }
+func (closedPortal) Close() error                { return nil }
+
+var _ = Describe("logging", func() {
+	var output *bytes.Buffer
+	var node *networkNode
+
+	records := func() []map[string]any {
+		result := make([]map[string]any, 0)
+		for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+			record := make(map[string]any)
+			Expect(json.Unmarshal([]byte(line), &record)).To(Succeed())
+			result = append(result, record)
+		}
+
+		return result
+	}
+
+	BeforeEach(func() {
+		output = &bytes.Buffer{}
+		logger := slog.New(slog.NewJSONHandler(output, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+		node = newNetworkNode(NetworkNodeConfig{
+			HostID:  "host",
+			HostTTL: DEFAULT_TTL,
+			Logger:  logger,
+		}, NewProtobufJSONProtocol())
+	})
+
+	It("should not log anything without logger", func() {
+		silent := newNetworkNode(NetworkNodeConfig{HostID: "host", HostTTL: DEFAULT_TTL}, NewProtobufJSONProtocol())
+		Expect(func() { silent.Publish("topic", []byte("payload"), AT_LEAST_ONCE) }).ToNot(Panic())
+	})
+
+	It("should log dropped publications with node ID, topic and reason", func() {
+		node.Publish("topic", []byte("payload"), AT_LEAST_ONCE)
+
+		Expect(records()).To(ContainElement(SatisfyAll(
+			HaveKeyWithValue("msg", "publication dropped"),
+			HaveKeyWithValue("node_id", "host"),
+			HaveKeyWithValue("topic", "topic"),
+			HaveKeyWithValue("reason", string(DROP_REASON_NO_SUBSCRIBERS)),
+		)))
+	})
+
+	It("should log edge state transitions and handshake steps", func() {
+		Expect(node.AddConnectingEdge(closedPortal{})).To(MatchError(io.EOF))
+
+		Expect(records()).To(ContainElements(
+			SatisfyAll(HaveKeyWithValue("msg", "edge state set"), HaveKeyWithValue("state", "connecting")),
+			SatisfyAll(HaveKeyWithValue("msg", "negotiating protocol version"), HaveKeyWithValue("bridged_node_id", "")),
+		))
+	})
+})
@@ -0,0 +1,274 @@
+package directmq
+
+import (
+	"sync"
+	"time"
+)
+
+type MessageType string
+
+const (
+	MESSAGE_TYPE_SUPPORTED_PROTOCOL_VERSIONS MessageType = "supported_protocol_versions"
+	MESSAGE_TYPE_INIT_CONNECTION             MessageType = "init_connection"
+	MESSAGE_TYPE_CONNECTION_ACCEPTED         MessageType = "connection_accepted"
+	MESSAGE_TYPE_GRACEFULLY_CLOSE            MessageType = "gracefully_close"
+	MESSAGE_TYPE_TERMINATE_NETWORK           MessageType = "terminate_network"
+	MESSAGE_TYPE_PUBLISH                     MessageType = "publish"
+	MESSAGE_TYPE_SUBSCRIBE                   MessageType = "subscribe"
+	MESSAGE_TYPE_UNSUBSCRIBE                 MessageType = "unsubscribe"
+	MESSAGE_TYPE_FLOW_CREDIT                 MessageType = "flow_credit"
+	MESSAGE_TYPE_TOPOLOGY_PROBE              MessageType = "topology_probe"
+	MESSAGE_TYPE_TOPOLOGY_REPORT             MessageType = "topology_report"
+	MESSAGE_TYPE_ECHO_REQUEST                MessageType = "echo_request"
+	MESSAGE_TYPE_ECHO_REPLY                  MessageType = "echo_reply"
+	MESSAGE_TYPE_MALFORMED                   MessageType = "malformed"
+)
+
+type DropReason string
+
+const (
+	DROP_REASON_TTL            DropReason = "ttl"
+	DROP_REASON_SIZE           DropReason = "size"
+	DROP_REASON_NO_SUBSCRIBERS DropReason = "no_subscribers"
+	DROP_REASON_LOOP           DropReason = "loop"
+	DROP_REASON_RATE_LIMIT     DropReason = "rate_limit"
+	DROP_REASON_QUEUE_FULL     DropReason = "queue_full"
+	DROP_REASON_WRITE_FAILED   DropReason = "write_failed"
+)
+
+// ProtocolTrafficObserver is implemented by the protocol decoder handler
+// that wants to be informed about every frame read or written by the protocol.
+type ProtocolTrafficObserver interface {
+	OnFrameRead(messageType MessageType, size int)
+	OnFrameWritten(messageType MessageType, size int)
+}
+
+var (
+	publicationFanOutBuckets = []float64{0, 1, 2, 4, 8, 16, 32}
+	handshakeDurationBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10}
+)
+
+type TrafficCounters struct {
+	Frames uint64
+	Bytes  uint64
+}
+
+type HistogramBucket struct {
+	UpperBound float64
+	Count      uint64 // cumulative
+}
+
+type HistogramSnapshot struct {
+	Buckets []HistogramBucket
+	Count   uint64
+	Sum     float64
+}
+
+type EdgeMetrics struct {
+	BridgedNodeID     string
+	Connected         bool
+	HandshakeDuration time.Duration
+
+	Received map[MessageType]TrafficCounters
+	Sent     map[MessageType]TrafficCounters
+
+	FlowControlQueueDepth int
+	RateLimitQueueDepth   int
+	SubscriptionCount     int
+}
+
+type NetworkNodeMetrics struct {
+	NodeID string
+	Edges  []EdgeMetrics
+
+	Publications        uint64
+	PublicationFanOut   HistogramSnapshot
+	DroppedPublications map[DropReason]uint64
+	HandshakeDurations  HistogramSnapshot // in seconds
+
+	SubscriptionCount int
+}
+
+type histogram struct {
+	upperBounds []float64
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+func newHistogram(upperBounds []float64) *histogram {
+	return &histogram{
+		upperBounds: upperBounds,
+		counts:      make([]uint64, len(upperBounds)),
+	}
+}
+
+func (h *histogram) Observe(value float64) {
+	for i, upperBound := range h.upperBounds {
+		if value <= upperBound {
+			h.counts[i]++
+		}
+	}
+
+	h.count++
+	h.sum += value
+}
+
+func (h *histogram) Snapshot() HistogramSnapshot {
+	buckets := make([]HistogramBucket, len(h.upperBounds))
+	for i, upperBound := range h.upperBounds {
+		buckets[i] = HistogramBucket{UpperBound: upperBound, Count: h.counts[i]}
+	}
+
+	return HistogramSnapshot{
+		Buckets: buckets,
+		Count:   h.count,
+		Sum:     h.sum,
+	}
+}
+
+// nodeMetrics collects metrics of the whole node,
+// which are not bound to any particular edge.
+type nodeMetrics struct {
+	mutex sync.Mutex
+
+	publications       uint64
+	fanOut             *histogram
+	dropped            map[DropReason]uint64
+	handshakeDurations *histogram
+}
+
+func newNodeMetrics() *nodeMetrics {
+	return &nodeMetrics{
+		fanOut:             newHistogram(publicationFanOutBuckets),
+		dropped:            make(map[DropReason]uint64),
+		handshakeDurations: newHistogram(handshakeDurationBuckets),
+	}
+}
+
+func (m *nodeMetrics) RecordPublication(fanOut int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.publications++
+	m.fanOut.Observe(float64(fanOut))
+}
+
+func (m *nodeMetrics) RecordDroppedPublication(reason DropReason) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.dropped[reason]++
+}
+
+func (m *nodeMetrics) RecordHandshake(duration time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.handshakeDurations.Observe(duration.Seconds())
+}
+
+func (m *nodeMetrics) Snapshot() NetworkNodeMetrics {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	dropped := make(map[DropReason]uint64, len(m.dropped))
+	for reason, count := range m.dropped {
+		dropped[reason] = count
+	}
+
+	return NetworkNodeMetrics{
+		Publications:        m.publications,
+		PublicationFanOut:   m.fanOut.Snapshot(),
+		DroppedPublications: dropped,
+		HandshakeDurations:  m.handshakeDurations.Snapshot(),
+	}
+}
+
+// edgeTraffic collects frames and bytes exchanged with the bridged node.
+type edgeTraffic struct {
+	mutex sync.Mutex
+
+	received map[MessageType]TrafficCounters
+	sent     map[MessageType]TrafficCounters
+
+	connectingSince   time.Time
+	handshakeDuration time.Duration
+}
+
+func newEdgeTraffic() *edgeTraffic {
+	return &edgeTraffic{
+		received: make(map[MessageType]TrafficCounters),
+		sent:     make(map[MessageType]TrafficCounters),
+	}
+}
+
+func (t *edgeTraffic) RecordReceived(messageType MessageType, size int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	counters := t.received[messageType]
+	counters.Frames++
+	counters.Bytes += uint64(size)
+	t.received[messageType] = counters
+}
+
+func (t *edgeTraffic) RecordSent(messageType MessageType, size int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	counters := t.sent[messageType]
+	counters.Frames++
+	counters.Bytes += uint64(size)
+	t.sent[messageType] = counters
+}
+
+func (t *edgeTraffic) StartHandshake() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.connectingSince = time.Now()
+	t.handshakeDuration = 0
+}
+
+func (t *edgeTraffic) FinishHandshake() time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.handshakeDuration = time.Since(t.connectingSince)
+	return t.handshakeDuration
+}
+
+func (t *edgeTraffic) Snapshot() (received, sent map[MessageType]TrafficCounters, handshakeDuration time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	received = make(map[MessageType]TrafficCounters, len(t.received))
+	for messageType, counters := range t.received {
+		received[messageType] = counters
+	}
+
+	sent = make(map[MessageType]TrafficCounters, len(t.sent))
+	for messageType, counters := range t.sent {
+		sent[messageType] = counters
+	}
+
+	return received, sent, t.handshakeDuration
+}
+
+func (n *networkEdge) GetMetrics() EdgeMetrics {
+	received, sent, handshakeDuration := n.traffic.Snapshot()
+
+	return EdgeMetrics{
+		BridgedNodeID:     n.info.BridgedNodeID,
+		Connected:         n.GetStateName() == stateConnected,
+		HandshakeDuration: handshakeDuration,
+
+		Received: received,
+		Sent:     sent,
+
+		FlowControlQueueDepth: n.flow.QueueLength(),
+		RateLimitQueueDepth:   n.inboundLimiter.QueueLength() + n.outboundLimiter.QueueLength(),
+		SubscriptionCount:     len(n.bridgedNodeSubscriptions.GetSubscriptions()),
+	}
+}
@@ -0,0 +1,247 @@
+package directmq
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const PROMETHEUS_TEXT_CONTENT_TYPE = "text/plain; version=0.0.4; charset=utf-8"
+
+// NewPrometheusMetricsHandler returns http.Handler exposing
+// the node metrics in the Prometheus text exposition format.
+func NewPrometheusMetricsHandler(node NetworkNode) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the metrics are buffered, so the failure can still be reported
+		body := &bytes.Buffer{}
+		if err := WritePrometheusMetrics(body, node.GetMetrics()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", PROMETHEUS_TEXT_CONTENT_TYPE)
+		w.Write(body.Bytes())
+	})
+}
+
+// WritePrometheusMetrics writes the metrics snapshot
+// in the Prometheus text exposition format. The per-edge series
+// are written only for the connected edges, labeled by the bridged
+// node ID, as the edges which are still connecting have no ID yet.
+func WritePrometheusMetrics(w io.Writer, metrics NetworkNodeMetrics) error {
+	out := &prometheusWriter{w: bufio.NewWriter(w), nodeID: metrics.NodeID}
+
+	edges := mergeConnectedEdgeMetrics(metrics.Edges)
+
+	out.Header("directmq_connected_edges", "gauge", "Number of edges in connected state.")
+	out.Sample("directmq_connected_edges", nil, float64(countConnectedEdges(metrics.Edges)))
+
+	out.Header("directmq_frames_received_total", "counter", "Frames received from the bridged node.")
+	for _, edge := range edges {
+		for _, messageType := range sortedMessageTypes(edge.Received) {
+			out.Sample("directmq_frames_received_total", edgeLabels(edge, messageType), float64(edge.Received[messageType].Frames))
+		}
+	}
+
+	out.Header("directmq_bytes_received_total", "counter", "Bytes received from the bridged node.")
+	for _, edge := range edges {
+		for _, messageType := range sortedMessageTypes(edge.Received) {
+			out.Sample("directmq_bytes_received_total", edgeLabels(edge, messageType), float64(edge.Received[messageType].Bytes))
+		}
+	}
+
+	out.Header("directmq_frames_sent_total", "counter", "Frames sent to the bridged node.")
+	for _, edge := range edges {
+		for _, messageType := range sortedMessageTypes(edge.Sent) {
+			out.Sample("directmq_frames_sent_total", edgeLabels(edge, messageType), float64(edge.Sent[messageType].Frames))
+		}
+	}
+
+	out.Header("directmq_bytes_sent_total", "counter", "Bytes sent to the bridged node.")
+	for _, edge := range edges {
+		for _, messageType := range sortedMessageTypes(edge.Sent) {
+			out.Sample("directmq_bytes_sent_total", edgeLabels(edge, messageType), float64(edge.Sent[messageType].Bytes))
+		}
+	}
+
+	out.Header("directmq_flow_control_queue_depth", "gauge", "Publications waiting for the flow control credit.")
+	for _, edge := range edges {
+		out.Sample("directmq_flow_control_queue_depth", edgeLabels(edge, ""), float64(edge.FlowControlQueueDepth))
+	}
+
+	out.Header("directmq_rate_limit_queue_depth", "gauge", "Publications delayed by the rate limits.")
+	for _, edge := range edges {
+		out.Sample("directmq_rate_limit_queue_depth", edgeLabels(edge, ""), float64(edge.RateLimitQueueDepth))
+	}
+
+	out.Header("directmq_bridged_node_subscriptions", "gauge", "Subscriptions registered by the bridged node.")
+	for _, edge := range edges {
+		out.Sample("directmq_bridged_node_subscriptions", edgeLabels(edge, ""), float64(edge.SubscriptionCount))
+	}
+
+	out.Header("directmq_local_subscriptions", "gauge", "Subscriptions registered with the native API.")
+	out.Sample("directmq_local_subscriptions", nil, float64(metrics.SubscriptionCount))
+
+	out.Header("directmq_publications_total", "counter", "Publications processed by the node.")
+	out.Sample("directmq_publications_total", nil, float64(metrics.Publications))
+
+	out.Header("directmq_dropped_publications_total", "counter", "Publications dropped by the node.")
+	for _, reason := range sortedDropReasons(metrics.DroppedPublications) {
+		out.Sample("directmq_dropped_publications_total", []string{"reason", string(reason)}, float64(metrics.DroppedPublications[reason]))
+	}
+
+	out.Header("directmq_publication_fan_out", "histogram", "Number of participants handling a single publication.")
+	out.Histogram("directmq_publication_fan_out", metrics.PublicationFanOut)
+
+	out.Header("directmq_handshake_duration_seconds", "histogram", "Duration of the connection handshake.")
+	out.Histogram("directmq_handshake_duration_seconds", metrics.HandshakeDurations)
+
+	if out.err != nil {
+		return out.err
+	}
+
+	return out.w.Flush()
+}
+
+type prometheusWriter struct {
+	w      *bufio.Writer
+	nodeID string
+	err    error
+}
+
+func (p *prometheusWriter) Header(name, metricType, help string) {
+	p.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// Sample writes a single sample, labels are given as name-value pairs.
+func (p *prometheusWriter) Sample(name string, labels []string, value float64) {
+	labels = append([]string{"node_id", p.nodeID}, labels...)
+
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, labels[i]+"=\""+escapePrometheusLabelValue(labels[i+1])+"\"")
+	}
+
+	p.printf("%s{%s} %s\n", name, strings.Join(pairs, ","), formatPrometheusValue(value))
+}
+
+func (p *prometheusWriter) Histogram(name string, histogram HistogramSnapshot) {
+	for _, bucket := range histogram.Buckets {
+		p.Sample(name+"_bucket", []string{"le", formatPrometheusValue(bucket.UpperBound)}, float64(bucket.Count))
+	}
+
+	p.Sample(name+"_bucket", []string{"le", "+Inf"}, float64(histogram.Count))
+	p.Sample(name+"_sum", nil, histogram.Sum)
+	p.Sample(name+"_count", nil, float64(histogram.Count))
+}
+
+func (p *prometheusWriter) printf(format string, args ...any) {
+	if p.err != nil {
+		return
+	}
+
+	_, p.err = fmt.Fprintf(p.w, format, args...)
+}
+
+func countConnectedEdges(edges []EdgeMetrics) int {
+	connected := 0
+	for _, edge := range edges {
+		if edge.Connected {
+			connected++
+		}
+	}
+
+	return connected
+}
+
+// mergeConnectedEdgeMetrics returns the metrics of the connected edges
+// sorted by the bridged node ID. Edges bridged with the same node are
+// merged into one, so every series is written only once.
+func mergeConnectedEdgeMetrics(edges []EdgeMetrics) []EdgeMetrics {
+	merged := make([]EdgeMetrics, 0, len(edges))
+	indexes := make(map[string]int, len(edges))
+
+	for _, edge := range edges {
+		if !edge.Connected {
+			continue
+		}
+
+		i, exists := indexes[edge.BridgedNodeID]
+		if !exists {
+			indexes[edge.BridgedNodeID] = len(merged)
+			merged = append(merged, EdgeMetrics{
+				BridgedNodeID: edge.BridgedNodeID,
+				Connected:     true,
+				Received:      make(map[MessageType]TrafficCounters),
+				Sent:          make(map[MessageType]TrafficCounters),
+			})
+
+			i = len(merged) - 1
+		}
+
+		target := &merged[i]
+		addTrafficCounters(target.Received, edge.Received)
+		addTrafficCounters(target.Sent, edge.Sent)
+		target.FlowControlQueueDepth += edge.FlowControlQueueDepth
+		target.RateLimitQueueDepth += edge.RateLimitQueueDepth
+		target.SubscriptionCount += edge.SubscriptionCount
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].BridgedNodeID < merged[j].BridgedNodeID
+	})
+
+	return merged
+}
+
+func addTrafficCounters(target, counters map[MessageType]TrafficCounters) {
+	for messageType, added := range counters {
+		sum := target[messageType]
+		sum.Frames += added.Frames
+		sum.Bytes += added.Bytes
+		target[messageType] = sum
+	}
+}
+
+func edgeLabels(edge EdgeMetrics, messageType MessageType) []string {
+	if messageType == "" {
+		return []string{"bridged_node_id", edge.BridgedNodeID}
+	}
+
+	return []string{"bridged_node_id", edge.BridgedNodeID, "message_type", string(messageType)}
+}
+
+func sortedMessageTypes(counters map[MessageType]TrafficCounters) []MessageType {
+	messageTypes := make([]MessageType, 0, len(counters))
+	for messageType := range counters {
+		messageTypes = append(messageTypes, messageType)
+	}
+
+	sort.Slice(messageTypes, func(i, j int) bool { return messageTypes[i] < messageTypes[j] })
+	return messageTypes
+}
+
+func sortedDropReasons(dropped map[DropReason]uint64) []DropReason {
+	reasons := make([]DropReason, 0, len(dropped))
+	for reason := range dropped {
+		reasons = append(reasons, reason)
+	}
+
+	sort.Slice(reasons, func(i, j int) bool { return reasons[i] < reasons[j] })
+	return reasons
+}
+
+func escapePrometheusLabelValue(value string) string {
+	value = strings.ReplaceAll(value, "\\", "\\\\")
+	value = strings.ReplaceAll(value, "\"", "\\\"")
+	return strings.ReplaceAll(value, "\n", "\\n")
+}
+
+func formatPrometheusValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,167 @@
+package directmq
+
+import (
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("metrics", func() {
+	Describe("histogram", func() {
+		It("should count observations cumulatively", func() {
+			h := newHistogram([]float64{1, 2, 4})
+
+			h.Observe(0)
+			h.Observe(2)
+			h.Observe(3)
+			h.Observe(10)
+
+			snapshot := h.Snapshot()
+			Expect(snapshot.Count).To(Equal(uint64(4)))
+			Expect(snapshot.Sum).To(Equal(15.0))
+			Expect(snapshot.Buckets).To(Equal([]HistogramBucket{
+				{UpperBound: 1, Count: 1},
+				{UpperBound: 2, Count: 2},
+				{UpperBound: 4, Count: 3},
+			}))
+		})
+	})
+
+	Describe("nodeMetrics", func() {
+		It("should record publications and drops", func() {
+			m := newNodeMetrics()
+
+			m.RecordPublication(2)
+			m.RecordPublication(0)
+			m.RecordDroppedPublication(DROP_REASON_NO_SUBSCRIBERS)
+			m.RecordDroppedPublication(DROP_REASON_TTL)
+			m.RecordDroppedPublication(DROP_REASON_TTL)
+			m.RecordHandshake(20 * time.Millisecond)
+
+			snapshot := m.Snapshot()
+			Expect(snapshot.Publications).To(Equal(uint64(2)))
+			Expect(snapshot.PublicationFanOut.Sum).To(Equal(2.0))
+			Expect(snapshot.DroppedPublications).To(Equal(map[DropReason]uint64{
+				DROP_REASON_NO_SUBSCRIBERS: 1,
+				DROP_REASON_TTL:            2,
+			}))
+			Expect(snapshot.HandshakeDurations.Count).To(Equal(uint64(1)))
+		})
+
+		It("should return independent snapshots", func() {
+			m := newNodeMetrics()
+			m.RecordDroppedPublication(DROP_REASON_LOOP)
+
+			snapshot := m.Snapshot()
+			m.RecordDroppedPublication(DROP_REASON_LOOP)
+
+			Expect(snapshot.DroppedPublications[DROP_REASON_LOOP]).To(Equal(uint64(1)))
+		})
+	})
+
+	Describe("edgeTraffic", func() {
+		It("should count frames and bytes per message type", func() {
+			t := newEdgeTraffic()
+
+			t.RecordReceived(MESSAGE_TYPE_PUBLISH, 10)
+			t.RecordReceived(MESSAGE_TYPE_PUBLISH, 5)
+			t.RecordSent(MESSAGE_TYPE_SUBSCRIBE, 7)
+
+			received, sent, _ := t.Snapshot()
+			Expect(received[MESSAGE_TYPE_PUBLISH]).To(Equal(TrafficCounters{Frames: 2, Bytes: 15}))
+			Expect(sent[MESSAGE_TYPE_SUBSCRIBE]).To(Equal(TrafficCounters{Frames: 1, Bytes: 7}))
+		})
+	})
+
+	Describe("WritePrometheusMetrics", func() {
+		metrics := NetworkNodeMetrics{
+			NodeID: "node-a",
+			Edges: []EdgeMetrics{
+				{
+					BridgedNodeID: "node-\"b\"",
+					Connected:     true,
+					Received:      map[MessageType]TrafficCounters{MESSAGE_TYPE_PUBLISH: {Frames: 3, Bytes: 30}},
+					Sent:          map[MessageType]TrafficCounters{},
+				},
+			},
+			Publications:        3,
+			PublicationFanOut:   HistogramSnapshot{Buckets: []HistogramBucket{{UpperBound: 1, Count: 3}}, Count: 3, Sum: 3},
+			DroppedPublications: map[DropReason]uint64{DROP_REASON_SIZE: 1},
+			SubscriptionCount:   2,
+		}
+
+		It("should write metrics in the text exposition format", func() {
+			out := &strings.Builder{}
+			Expect(WritePrometheusMetrics(out, metrics)).To(Succeed())
+
+			text := out.String()
+			Expect(text).To(ContainSubstring("# TYPE directmq_frames_received_total counter\n"))
+			Expect(text).To(ContainSubstring(`directmq_frames_received_total{node_id="node-a",bridged_node_id="node-\"b\"",message_type="publish"} 3` + "\n"))
+			Expect(text).To(ContainSubstring(`directmq_bytes_received_total{node_id="node-a",bridged_node_id="node-\"b\"",message_type="publish"} 30` + "\n"))
+			Expect(text).To(ContainSubstring(`directmq_connected_edges{node_id="node-a"} 1` + "\n"))
+			Expect(text).To(ContainSubstring(`directmq_dropped_publications_total{node_id="node-a",reason="size"} 1` + "\n"))
+			Expect(text).To(ContainSubstring(`directmq_publication_fan_out_bucket{node_id="node-a",le="1"} 3` + "\n"))
+			Expect(text).To(ContainSubstring(`directmq_publication_fan_out_bucket{node_id="node-a",le="+Inf"} 3` + "\n"))
+			Expect(text).To(ContainSubstring(`directmq_local_subscriptions{node_id="node-a"} 2` + "\n"))
+		})
+
+		It("should write per-edge series once for every connected bridged node", func() {
+			edges := NetworkNodeMetrics{
+				NodeID: "node-a",
+				Edges: []EdgeMetrics{
+					{Connected: false, FlowControlQueueDepth: 7},
+					{Connected: false, FlowControlQueueDepth: 7},
+					{BridgedNodeID: "node-b", Connected: true, FlowControlQueueDepth: 1, Received: map[MessageType]TrafficCounters{MESSAGE_TYPE_PUBLISH: {Frames: 1, Bytes: 10}}},
+					{BridgedNodeID: "node-b", Connected: true, FlowControlQueueDepth: 2, Received: map[MessageType]TrafficCounters{MESSAGE_TYPE_PUBLISH: {Frames: 2, Bytes: 20}}},
+				},
+			}
+
+			out := &strings.Builder{}
+			Expect(WritePrometheusMetrics(out, edges)).To(Succeed())
+
+			text := out.String()
+			Expect(text).ToNot(ContainSubstring(`bridged_node_id=""`))
+			Expect(strings.Count(text, "directmq_flow_control_queue_depth{")).To(Equal(1))
+			Expect(text).To(ContainSubstring(`directmq_flow_control_queue_depth{node_id="node-a",bridged_node_id="node-b"} 3` + "\n"))
+			Expect(text).To(ContainSubstring(`directmq_frames_received_total{node_id="node-a",bridged_node_id="node-b",message_type="publish"} 3` + "\n"))
+			Expect(text).To(ContainSubstring(`directmq_connected_edges{node_id="node-a"} 2` + "\n"))
+		})
+
+		It("should expose metrics of the node over http", func() {
+			node := NewNetworkNode(NetworkNodeConfig{HostID: "node-a"}, NewProtobufBinaryProtocol())
+
+			recorder := httptest.NewRecorder()
+			NewPrometheusMetricsHandler(node).ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+			Expect(recorder.Header().Get("Content-Type")).To(Equal(PROMETHEUS_TEXT_CONTENT_TYPE))
+			Expect(recorder.Body.String()).To(ContainSubstring(`directmq_publications_total{node_id="node-a"} 0`))
+		})
+	})
+
+	Describe("dropped publications", func() {
+		It("should count the publication dropped by many edges once", func() {
+			a := newNetworkNode(NetworkNodeConfig{HostID: "a", HostTTL: 1}, NewProtobufBinaryProtocol())
+			b := newNetworkNode(NetworkNodeConfig{HostID: "b", HostTTL: DEFAULT_TTL}, NewProtobufBinaryProtocol())
+			c := newNetworkNode(NetworkNodeConfig{HostID: "c", HostTTL: DEFAULT_TTL}, NewProtobufBinaryProtocol())
+			defer a.CloseNode("test finished")
+
+			connectTestNodes(b, a)
+			connectTestNodes(c, a)
+			Eventually(a.GetBridgedNodeIDs).Should(HaveLen(2))
+
+			b.Subscribe("sensors/temperature", func([]byte) {})
+			c.Subscribe("sensors/temperature", func([]byte) {})
+			Eventually(func() int {
+				return a.GetMetrics().Edges[0].SubscriptionCount + a.GetMetrics().Edges[1].SubscriptionCount
+			}).Should(Equal(2))
+
+			// the TTL of the node is exhausted by the first hop
+			a.Publish("sensors/temperature", []byte("21.5"), AT_LEAST_ONCE)
+
+			Expect(a.GetMetrics().DroppedPublications).To(Equal(map[DropReason]uint64{DROP_REASON_TTL: 1}))
+		})
+	})
+})
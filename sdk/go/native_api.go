@@ -9,6 +9,10 @@ type NativeAPI interface {
 type nativeAPI struct {
 	network       *globalNetwork
 	subscriptions *subscriptionList[func(payload []byte)]
+
+	// subscriptions created together with the returned one,
+	// when the topic pattern was converted into multiple patterns
+	linkedSubscriptions map[SubscriptionID][]SubscriptionID
 }
 
 var _ networkParticipant = (*nativeAPI)(nil)
@@ -17,6 +21,8 @@ var _ NativeAPI = (*nativeAPI)(nil)
 func newNativeAPI() *nativeAPI {
 	return &nativeAPI{
 		subscriptions: newSubscriptionList[func(payload []byte)](),
+
+		linkedSubscriptions: make(map[SubscriptionID][]SubscriptionID),
 	}
 }
 
@@ -31,6 +37,9 @@ func (n *nativeAPI) Publish(topic string, payload []byte, deliveryStrategy Deliv
 		panic("forbidden payload value")
 	}
 
+	n.network.lock()
+	defer n.network.unlock()
+
 	message := PublishMessage{
 		DataFrame:        n.getInitialDataFrame(),
 		Topic:            topic,
@@ -38,7 +47,9 @@ func (n *nativeAPI) Publish(topic string, payload []byte, deliveryStrategy Deliv
 		DeliveryStrategy: deliveryStrategy,
 	}
 
-	n.network.Published(message)
+	span := n.network.tracer.StartSpan(SPAN_PUBLISH, "", message)
+	message.DataFrame = span.Propagate(message.DataFrame)
+	span.EndPublication(n.network.Published(message))
 }
 
 func (n *nativeAPI) Subscribe(topic string, handler func(payload []byte)) SubscriptionID {
@@ -48,21 +59,40 @@ func (n *nativeAPI) Subscribe(topic string, handler func(payload []byte)) Subscr
 		panic("handler cannot be nil")
 	}
 
-	oldTopics := n.subscriptions.GetOnlyTopLevelSubscribedTopics()
-	subscriptionID := n.subscriptions.AddSubscription(topic, &handler)
-	n.updateSubscriptions(oldTopics)
+	patterns, err := getTopicPatterns(n.network.config.HostTopicGrammar, topic)
+	if err != nil {
+		panic("incorrect topic pattern")
+	}
+
+	n.network.lock()
+	defer n.network.unlock()
+
+	subscriptionID, topicsToUnsubscribe, topicsToSubscribe := n.subscriptions.AddSubscriptionWithDiff(patterns[0], &handler)
+	n.updateSubscriptions(topicsToUnsubscribe, topicsToSubscribe)
+
+	for _, pattern := range patterns[1:] {
+		linkedID, topicsToUnsubscribe, topicsToSubscribe := n.subscriptions.AddSubscriptionWithDiff(pattern, &handler)
+		n.updateSubscriptions(topicsToUnsubscribe, topicsToSubscribe)
+		n.linkedSubscriptions[subscriptionID] = append(n.linkedSubscriptions[subscriptionID], linkedID)
+	}
+
 	return subscriptionID
 }
 
 func (n *nativeAPI) Unsubscribe(id SubscriptionID) {
-	oldTopics := n.subscriptions.GetOnlyTopLevelSubscribedTopics()
-	n.subscriptions.RemoveSubscription(id)
-	n.updateSubscriptions(oldTopics)
-}
+	n.network.lock()
+	defer n.network.unlock()
+
+	n.updateSubscriptions(n.subscriptions.RemoveSubscriptionWithDiff(id))
 
-func (n *nativeAPI) updateSubscriptions(oldTopics []string) {
-	topicsToUnsubscribe, topicsToSubscribe := GetDeduplicatedOverlappingTopicsDiff(oldTopics, n.subscriptions.GetOnlyTopLevelSubscribedTopics())
+	for _, linkedID := range n.linkedSubscriptions[id] {
+		n.updateSubscriptions(n.subscriptions.RemoveSubscriptionWithDiff(linkedID))
+	}
+
+	delete(n.linkedSubscriptions, id)
+}
 
+func (n *nativeAPI) updateSubscriptions(topicsToUnsubscribe, topicsToSubscribe []string) {
 	for _, topic := range topicsToSubscribe {
 		n.network.Subscribed(SubscribeMessage{
 			DataFrame: n.getInitialDataFrame(),
@@ -99,24 +129,25 @@ func (n *nativeAPI) IsOriginOfFrame(frame DataFrame) bool {
 	return len(frame.Traversed) == 0
 }
 
-func (n *nativeAPI) HandlePublish(publication PublishMessage) (handled bool) {
+func (n *nativeAPI) HandlePublish(publication PublishMessage) (handled bool, dropReason DropReason) {
 	subscribers := randomOrder(n.subscriptions.GetTriggeredSubscriptions(publication.Topic))
 	if len(subscribers) == 0 {
-		return false
+		return false, ""
 	}
 
 	// Handle AT_MOST_ONCE delivery strategy
 	if publication.DeliveryStrategy == AT_MOST_ONCE {
-		subscribers[0].Handler(publication.Payload)
-		return true
+		subscribers = subscribers[:1]
 	}
 
-	// Handle AT_LEAST_ONCE delivery strategy
+	// handlers are called after the node is unlocked,
+	// so they can publish or subscribe
 	for _, subscriber := range subscribers {
-		subscriber.Handler(publication.Payload)
+		handler := subscriber.Handler
+		n.network.schedule(func() { handler(publication.Payload) })
 	}
 
-	return true
+	return true, ""
 }
 
 func (n *nativeAPI) HandleSubscribe(subscription SubscribeMessage) {
@@ -130,3 +161,19 @@ func (n *nativeAPI) HandleUnsubscribe(unsubscribe UnsubscribeMessage) {
 func (n *nativeAPI) HandleTerminateNetwork(terminate TerminateNetworkMessage) {
 	/* No-op */
 }
+
+func (n *nativeAPI) HandleTopologyProbe(probe TopologyProbeMessage) {
+	/* No-op */
+}
+
+func (n *nativeAPI) HandleTopologyReport(report TopologyReportMessage) (handled bool) {
+	return false
+}
+
+func (n *nativeAPI) HandleEchoRequest(request EchoRequestMessage) (handled bool) {
+	return false
+}
+
+func (n *nativeAPI) HandleEchoReply(reply EchoReplyMessage) (handled bool) {
+	return false
+}
@@ -1,10 +1,38 @@
 package directmq
 
 import (
-	"fmt"
+	"log/slog"
 	"strings"
+	"time"
 )
 
+// EdgeState is the name of the state of the connection
+// with the bridged node as reported by the diagnostics.
+type EdgeState string
+
+const (
+	EDGE_STATE_NONE          EdgeState = ""
+	EDGE_STATE_CONNECTING    EdgeState = "connecting"
+	EDGE_STATE_CONNECTED     EdgeState = "connected"
+	EDGE_STATE_DISCONNECTING EdgeState = "disconnecting"
+	EDGE_STATE_DISCONNECTED  EdgeState = "disconnected"
+)
+
+// EdgeInfo is the snapshot of the connection with the bridged node,
+// fields describing the bridged node are empty until the connection
+// process exchanges them.
+type EdgeInfo struct {
+	BridgedNodeID             string
+	State                     EdgeState
+	NegotiatedProtocolVersion uint32
+	NegotiatedFeatures        ProtocolFeatures
+	BridgedNodeMaxMessageSize uint64
+	ConnectionStartedAt       time.Time
+
+	// Topic patterns the bridged node is subscribed to.
+	BridgedNodeSubscriptions []string
+}
+
 type edgeStateName int
 
 const (
@@ -14,6 +42,21 @@ const (
 	stateDisconnected
 )
 
+func (s edgeStateName) String() string {
+	switch s {
+	case stateConnecting:
+		return "connecting"
+	case stateConnected:
+		return "connected"
+	case stateDisconnecting:
+		return "disconnecting"
+	case stateDisconnected:
+		return "disconnected"
+	default:
+		return "unknown"
+	}
+}
+
 type networkEdgeState interface {
 	ProtocolDecoderHandler
 	networkParticipant
@@ -26,23 +69,44 @@ type edgeInfo struct {
 	BridgedNodeID                        string
 	BridgedNodeMaxMessageSize            uint64
 	BridgedNodeSupportedProtocolVersions []uint32
+	BridgedNodeSupportedFeatures         ProtocolFeatures
+	BridgedNodeFrameCredit               uint32
+	BridgedNodeByteCredit                uint64
+	BridgedNodeMaxTopicAliases           uint32
 	NegotiatedProtocolVersion            uint32
+	NegotiatedFeatures                   ProtocolFeatures
 }
 
 type networkEdge struct {
 	network *globalNetwork
 
 	portal   Portal
+	writer   *edgeWriter
 	protocol Protocol
 
 	state networkEdgeState
 	info  edgeInfo
 
 	bridgedNodeSubscriptions *subscriptionList[struct{}]
+	flow                     *flowControl
+	aliases                  *topicAliases
+
+	inboundLimiter  *rateLimiter
+	outboundLimiter *rateLimiter
+
+	traffic *edgeTraffic
+
+	// sizes of the last publish frames read and written on the edge,
+	// the flow control counts the byte credit in the frame sizes
+	readPublicationSize    int
+	writtenPublicationSize int
+
+	startedAt time.Time
 }
 
 var _ networkParticipant = (*networkEdge)(nil)
 var _ ProtocolDecoderHandler = (*networkEdge)(nil)
+var _ ProtocolTrafficObserver = (*networkEdge)(nil)
 
 func newNetworkEdge(portal Portal, network *globalNetwork) *networkEdge {
 	edge := &networkEdge{
@@ -56,10 +120,29 @@ func newNetworkEdge(portal Portal, network *globalNetwork) *networkEdge {
 			BridgedNodeID:                        "",
 			BridgedNodeMaxMessageSize:            NO_MAX_MESSAGE_SIZE,
 			BridgedNodeSupportedProtocolVersions: []uint32{},
+			BridgedNodeSupportedFeatures:         NO_PROTOCOL_FEATURES,
+			BridgedNodeFrameCredit:               NO_FLOW_CONTROL,
+			BridgedNodeByteCredit:                NO_FLOW_CONTROL,
+			BridgedNodeMaxTopicAliases:           0,
 			NegotiatedProtocolVersion:            UNKNOWN_PROTOCOL_VERSION,
+			NegotiatedFeatures:                   NO_PROTOCOL_FEATURES,
 		},
 
 		bridgedNodeSubscriptions: newSubscriptionList[struct{}](),
+		flow:                     newFlowControl(),
+		aliases:                  newTopicAliases(),
+
+		traffic: newEdgeTraffic(),
+
+		startedAt: time.Now(),
+	}
+
+	edge.writer = newEdgeWriter(portal, edge.handleQueuedWriteFailure)
+	edge.inboundLimiter = newRateLimiter(INBOUND_TRAFFIC, network.locker(), edge.releaseInboundPublication)
+	edge.outboundLimiter = newRateLimiter(OUTBOUND_TRAFFIC, network.locker(), edge.releaseOutboundPublication)
+
+	if reporter, ok := portal.(CorruptedPacketsReporter); ok {
+		reporter.OnCorruptedPacket(edge.handleCorruptedPacket)
 	}
 
 	return edge
@@ -72,19 +155,58 @@ func (n *networkEdge) GetStateName() edgeStateName {
 }
 
 func (n *networkEdge) SetState(state networkEdgeState) {
-	fmt.Println("Setting edge state to: ", state.GetStateName())
+	previous := EDGE_STATE_NONE
+
+	if n.state == nil {
+		n.logger().Debug("edge state set", slog.String("state", state.GetStateName().String()))
+	} else {
+		previous = EdgeState(n.state.GetStateName().String())
+		n.logger().Debug("edge state changed", slog.String("from", n.state.GetStateName().String()), slog.String("to", state.GetStateName().String()))
+	}
+
 	n.state = state
+	n.network.diag.HandleEdgeStateChanged(n.GetInfo(), previous)
 	n.state.OnSet()
 }
 
+func (n *networkEdge) GetInfo() EdgeInfo {
+	return EdgeInfo{
+		BridgedNodeID:             n.info.BridgedNodeID,
+		State:                     EdgeState(n.state.GetStateName().String()),
+		NegotiatedProtocolVersion: n.info.NegotiatedProtocolVersion,
+		NegotiatedFeatures:        n.info.NegotiatedFeatures,
+		BridgedNodeMaxMessageSize: n.info.BridgedNodeMaxMessageSize,
+		ConnectionStartedAt:       n.startedAt,
+		BridgedNodeSubscriptions:  n.bridgedNodeSubscriptions.GetUniqueSubscribedTopics(),
+	}
+}
+
+// Run reads the packets until the portal fails, the packet
+// is read without the lock and handled with the node locked.
 func (n *networkEdge) Run() error {
 	for {
-		if err := n.protocol.ReadFrom(n.portal); err != nil {
+		packet, err := n.portal.ReadPacket()
+		if err != nil {
+			return err
+		}
+
+		n.network.lock()
+		err = n.protocol.ReadFrom(readPacket(packet))
+		n.network.unlock()
+
+		if err != nil {
 			return err
 		}
 	}
 }
 
+// readPacket is the PacketReader returning the already read packet.
+type readPacket []byte
+
+func (p readPacket) ReadPacket() ([]byte, error) {
+	return p, nil
+}
+
 /* networkParticipant interface implementation */
 
 func (n *networkEdge) GetSubscribedTopics() []string {
@@ -103,7 +225,7 @@ func (n *networkEdge) IsOriginOfFrame(frame DataFrame) bool {
 	return frame.Traversed[len(frame.Traversed)-1] == n.info.BridgedNodeID
 }
 
-func (n *networkEdge) HandlePublish(publication PublishMessage) (handled bool) {
+func (n *networkEdge) HandlePublish(publication PublishMessage) (handled bool, dropReason DropReason) {
 	return n.state.HandlePublish(publication)
 }
 
@@ -119,6 +241,22 @@ func (n *networkEdge) HandleTerminateNetwork(terminate TerminateNetworkMessage)
 	n.state.HandleTerminateNetwork(terminate)
 }
 
+func (n *networkEdge) HandleTopologyProbe(probe TopologyProbeMessage) {
+	n.state.HandleTopologyProbe(probe)
+}
+
+func (n *networkEdge) HandleTopologyReport(report TopologyReportMessage) (handled bool) {
+	return n.state.HandleTopologyReport(report)
+}
+
+func (n *networkEdge) HandleEchoRequest(request EchoRequestMessage) (handled bool) {
+	return n.state.HandleEchoRequest(request)
+}
+
+func (n *networkEdge) HandleEchoReply(reply EchoReplyMessage) (handled bool) {
+	return n.state.HandleEchoReply(reply)
+}
+
 /* ProtocolDecoderHandler interface implementation */
 
 func (n *networkEdge) OnSupportedProtocolVersions(message SupportedProtocolVersionsMessage) {
@@ -153,15 +291,130 @@ func (n *networkEdge) OnUnsubscribe(message UnsubscribeMessage) {
 	n.state.OnUnsubscribe(message)
 }
 
+func (n *networkEdge) OnFlowCredit(message FlowCreditMessage) {
+	n.state.OnFlowCredit(message)
+}
+
+func (n *networkEdge) OnTopologyProbe(message TopologyProbeMessage) {
+	n.state.OnTopologyProbe(message)
+}
+
+func (n *networkEdge) OnTopologyReport(message TopologyReportMessage) {
+	n.state.OnTopologyReport(message)
+}
+
+func (n *networkEdge) OnEchoRequest(message EchoRequestMessage) {
+	n.state.OnEchoRequest(message)
+}
+
+func (n *networkEdge) OnEchoReply(message EchoReplyMessage) {
+	n.state.OnEchoReply(message)
+}
+
 func (n *networkEdge) OnMalformedMessage(message MalformedMessage) {
 	n.state.OnMalformedMessage(message)
 }
 
+/* ProtocolTrafficObserver interface implementation */
+
+func (n *networkEdge) OnFrameRead(messageType MessageType, size int) {
+	n.traffic.RecordReceived(messageType, size)
+
+	if messageType == MESSAGE_TYPE_PUBLISH {
+		n.readPublicationSize = size
+	}
+}
+
+func (n *networkEdge) OnFrameWritten(messageType MessageType, size int) {
+	n.traffic.RecordSent(messageType, size)
+
+	if messageType == MESSAGE_TYPE_PUBLISH {
+		n.writtenPublicationSize = size
+	}
+}
+
 /* networkEdge utility methods */
 
+// getHostFeatures returns the features the host supports on this edge.
+func (n *networkEdge) getHostFeatures() ProtocolFeatures {
+	features := getHostFeatures(n.network.config)
+	if isUnreliablePortal(n.portal) {
+		features &^= FEATURE_TOPIC_ALIASES
+	}
+
+	return features
+}
+
+func (n *networkEdge) logger() *slog.Logger {
+	return n.network.logger.With(slog.String("bridged_node_id", n.info.BridgedNodeID))
+}
+
+// handleWriteFailure logs the failed write to the bridged node
+// and returns the reason of the edge disconnection.
+func (n *networkEdge) handleWriteFailure(reason string, err error) string {
+	n.logger().Error("write failed", slog.String("reason", reason), slog.String("error", err.Error()))
+	return reason + ": " + err.Error()
+}
+
+// handleQueuedWriteFailure is called by the writer goroutine when a queued
+// frame could not be written, the edge is disconnected like after
+// a write failed with the node locked.
+func (n *networkEdge) handleQueuedWriteFailure(err error) {
+	n.network.lock()
+	defer n.network.unlock()
+
+	if n.GetStateName() == stateDisconnecting || n.GetStateName() == stateDisconnected {
+		return
+	}
+
+	n.SetState(&networkEdgeStateDisconnecting{n, n.handleWriteFailure("Failed to write frame", err)})
+}
+
+func (n *networkEdge) handleCorruptedPacket(reason string) {
+	n.network.lock()
+	defer n.network.unlock()
+
+	n.logger().Warn("corrupted packet dropped by portal", slog.String("reason", reason))
+	n.network.diag.HandleCorruptedPacket(n.info.BridgedNodeID, reason, n.portal)
+}
+
+// releaseInboundPublication is called by the timer of the rate limiter
+// with the node locked, like the frames read by the edge.
+func (n *networkEdge) releaseInboundPublication(publication PublishMessage) {
+	if n.GetStateName() == stateConnected {
+		n.receivePublication(publication)
+	}
+}
+
+func (n *networkEdge) releaseOutboundPublication(publication PublishMessage) {
+	if connected, ok := n.state.(*networkEdgeStateConnected); ok {
+		if sent, reason := connected.sendPublication(publication); !sent {
+			n.network.metrics.RecordDroppedPublication(reason)
+			n.logger().Debug("publication dropped", slog.String("topic", publication.Topic), slog.String("reason", string(reason)))
+		}
+	}
+}
+
+// publish writes the publication to the bridged node, replacing its topic
+// with the alias when possible, and returns the size of the written frame
+// used up from the flow control credit.
+func (n *networkEdge) publish(publication PublishMessage) (size int, err error) {
+	n.writtenPublicationSize = 0
+	err = n.aliases.Publish(publication, n.protocol.Publish)
+	return n.writtenPublicationSize, err
+}
+
+func (n *networkEdge) receivePublication(publication PublishMessage) {
+	span := n.network.tracer.StartSpan(SPAN_RECEIVE, n.info.BridgedNodeID, publication)
+	publication.DataFrame = span.Propagate(publication.DataFrame)
+	span.EndPublication(n.network.Published(publication))
+}
+
 func (n *networkEdge) shouldForwardMessage(frame DataFrame) bool {
 	loopDetected := n.checkForNetworkLoops(frame)
 	if loopDetected {
+		n.logger().Warn("network loop detected", slog.Any("traversed", frame.Traversed))
+
 		// report loop, terminate whole network
 		n.network.Terminated(TerminateNetworkMessage{
 			DataFrame: DataFrame{
@@ -175,6 +428,20 @@ func (n *networkEdge) shouldForwardMessage(frame DataFrame) bool {
 	return !loopDetected && frame.TTL > 0
 }
 
+func (n *networkEdge) getDropReason(frame DataFrame) DropReason {
+	if n.checkForNetworkLoops(frame) {
+		return DROP_REASON_LOOP
+	}
+
+	return DROP_REASON_TTL
+}
+
+// isNextHopOf checks if the bridged node is the next hop of the route,
+// the last node of it.
+func (n *networkEdge) isNextHopOf(route []string) bool {
+	return len(route) > 0 && route[len(route)-1] == n.info.BridgedNodeID
+}
+
 func (n *networkEdge) checkForNetworkLoops(frame DataFrame) bool {
 	existingHosts := make(map[string]struct{})
 
@@ -194,7 +461,8 @@ func (n *networkEdge) updateFrame(frame DataFrame) DataFrame {
 	}
 
 	return DataFrame{
-		TTL:       frame.TTL - 1,
-		Traversed: append(frame.Traversed, n.network.config.HostID),
+		TTL:         frame.TTL - 1,
+		Traversed:   append(frame.Traversed, n.network.config.HostID),
+		TraceParent: frame.TraceParent,
 	}
 }
@@ -1,5 +1,7 @@
 package directmq
 
+import "log/slog"
+
 type networkEdgeStateConnected struct {
 	edge *networkEdge
 }
@@ -11,6 +13,19 @@ func (n *networkEdgeStateConnected) GetStateName() edgeStateName {
 }
 
 func (n *networkEdgeStateConnected) OnSet() {
+	handshakeDuration := n.edge.traffic.FinishHandshake()
+	n.edge.network.metrics.RecordHandshake(handshakeDuration)
+	n.edge.logger().Info(
+		"edge connected",
+		slog.Uint64("protocol_version", uint64(n.edge.info.NegotiatedProtocolVersion)),
+		slog.Uint64("features", uint64(n.edge.info.NegotiatedFeatures)),
+		slog.Duration("handshake_duration", handshakeDuration),
+	)
+
+	n.edge.flow.Reset(n.edge.network.config, n.edge.info)
+	n.edge.aliases.Reset(n.edge.network.config, n.edge.info)
+	n.edge.inboundLimiter.Reset(n.edge.network.config.HostRateLimits, n.edge.info.BridgedNodeID)
+	n.edge.outboundLimiter.Reset(n.edge.network.config.HostRateLimits, n.edge.info.BridgedNodeID)
 	n.edge.network.diag.HandleConnectionEstablished(n.edge.info.BridgedNodeID, n.edge.portal)
 	n.exchangeAllNodeSubscriptions()
 }
@@ -19,7 +34,7 @@ func (n *networkEdgeStateConnected) exchangeAllNodeSubscriptions() {
 	for _, topic := range n.edge.network.GetAllSubscribedTopics() {
 		err := n.edge.protocol.Subscribe(SubscribeMessage{Topic: topic}) // TODO: add DataFrame
 		if err != nil {
-			n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, "Failed to exchange subscriptions: " + err.Error()})
+			n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, n.edge.handleWriteFailure("Failed to exchange subscriptions", err)})
 			return
 		}
 	}
@@ -39,13 +54,13 @@ func (n *networkEdgeStateConnected) IsOriginOfFrame(frame DataFrame) bool {
 	panic("this method should not be used, use the networkEdge.IsOriginOfFrame method instead")
 }
 
-func (n *networkEdgeStateConnected) HandlePublish(publication PublishMessage) (handled bool) {
+func (n *networkEdgeStateConnected) HandlePublish(publication PublishMessage) (handled bool, dropReason DropReason) {
 	if n.edge.IsOriginOfFrame(publication.DataFrame) {
-		return false
+		return false, ""
 	}
 
 	if triggered := n.edge.bridgedNodeSubscriptions.GetTriggeredSubscriptions(publication.Topic); len(triggered) == 0 {
-		return false
+		return false, ""
 	}
 
 	publicationToForward := PublishMessage{
@@ -55,21 +70,57 @@ func (n *networkEdgeStateConnected) HandlePublish(publication PublishMessage) (h
 		DeliveryStrategy: publication.DeliveryStrategy,
 	}
 
+	span := n.edge.network.tracer.StartSpan(SPAN_FORWARD, n.edge.info.BridgedNodeID, publicationToForward)
+	publicationToForward.DataFrame = span.Propagate(publicationToForward.DataFrame)
+
 	if !n.edge.shouldForwardMessage(publicationToForward.DataFrame) {
-		return false
+		return n.dropPublication(span, publicationToForward, n.edge.getDropReason(publicationToForward.DataFrame))
 	}
 
 	if n.edge.info.BridgedNodeMaxMessageSize != NO_MAX_MESSAGE_SIZE && uint64(len(publicationToForward.Payload)) > n.edge.info.BridgedNodeMaxMessageSize {
-		return false
+		return n.dropPublication(span, publicationToForward, DROP_REASON_SIZE)
+	}
+
+	switch n.edge.outboundLimiter.Limit(publicationToForward) {
+	case publicationLimited:
+		return n.dropPublication(span, publicationToForward, DROP_REASON_RATE_LIMIT)
+	case publicationDeferred:
+		span.End(SPAN_OUTCOME_FORWARDED, "")
+		return true, ""
 	}
 
-	err := n.edge.protocol.Publish(publicationToForward)
+	if sent, reason := n.sendPublication(publicationToForward); !sent {
+		return n.dropPublication(span, publicationToForward, reason)
+	}
+
+	span.End(SPAN_OUTCOME_FORWARDED, "")
+	return true, ""
+}
+
+// dropPublication returns the reason to the global network instead
+// of recording it, so the publication dropped by many edges is counted once.
+func (n *networkEdgeStateConnected) dropPublication(span *activeSpan, publication PublishMessage, reason DropReason) (handled bool, dropReason DropReason) {
+	n.edge.logger().Debug("publication dropped", slog.String("topic", publication.Topic), slog.String("reason", string(reason)))
+	span.End(SPAN_OUTCOME_DROPPED, reason)
+	return false, reason
+}
+
+func (n *networkEdgeStateConnected) sendPublication(publicationToForward PublishMessage) (sent bool, reason DropReason) {
+	result, queueLength, err := n.edge.flow.Send(publicationToForward, n.edge.publish)
 	if err != nil {
-		n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, "Failed to publish message: " + err.Error()})
-		return false
+		n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, n.edge.handleWriteFailure("Failed to publish message", err)})
+		return false, DROP_REASON_WRITE_FAILED
 	}
 
-	return true
+	if result == publicationQueued {
+		n.edge.network.diag.HandleFlowControlStall(n.edge.info.BridgedNodeID, queueLength)
+	}
+
+	if result == publicationDropped {
+		return false, DROP_REASON_QUEUE_FULL
+	}
+
+	return true, ""
 }
 
 func (n *networkEdgeStateConnected) HandleSubscribe(subscription SubscribeMessage) {
@@ -88,7 +139,7 @@ func (n *networkEdgeStateConnected) HandleSubscribe(subscription SubscribeMessag
 
 	err := n.edge.protocol.Subscribe(subscriptionToForward)
 	if err != nil {
-		n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, "Failed to subscribe: " + err.Error()})
+		n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, n.edge.handleWriteFailure("Failed to subscribe", err)})
 	}
 }
 
@@ -108,7 +159,7 @@ func (n *networkEdgeStateConnected) HandleUnsubscribe(unsubscribe UnsubscribeMes
 
 	err := n.edge.protocol.Unsubscribe(unsubscriptionToForward)
 	if err != nil {
-		n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, "Failed to unsubscribe: " + err.Error()})
+		n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, n.edge.handleWriteFailure("Failed to unsubscribe", err)})
 	}
 }
 
@@ -122,11 +173,121 @@ func (n *networkEdgeStateConnected) HandleTerminateNetwork(terminate TerminateNe
 	})
 
 	if err != nil {
-		n.edge.SetState(&networkEdgeStateDisconnected{n.edge, "Failed to terminate network edge: " + err.Error(), nil})
+		n.edge.SetState(&networkEdgeStateDisconnected{n.edge, n.edge.handleWriteFailure("Failed to terminate network edge", err)})
+		return
+	}
+
+	n.edge.SetState(&networkEdgeStateDisconnected{n.edge, terminate.Reason})
+}
+
+func (n *networkEdgeStateConnected) HandleTopologyProbe(probe TopologyProbeMessage) {
+	if !n.edge.info.NegotiatedFeatures.Has(FEATURE_TOPOLOGY_DISCOVERY) || n.edge.IsOriginOfFrame(probe.DataFrame) {
 		return
 	}
 
-	n.edge.SetState(&networkEdgeStateDisconnected{n.edge, terminate.Reason, nil})
+	probeToForward := TopologyProbeMessage{
+		DataFrame: n.edge.updateFrame(probe.DataFrame),
+		ProbeID:   probe.ProbeID,
+	}
+
+	// probes are deduplicated by the nodes, so it is safe
+	// to skip the loop detection and check only the TTL
+	if probeToForward.TTL <= 0 {
+		return
+	}
+
+	if err := n.edge.protocol.TopologyProbe(probeToForward); err != nil {
+		n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, n.edge.handleWriteFailure("Failed to forward topology probe", err)})
+	}
+}
+
+func (n *networkEdgeStateConnected) HandleTopologyReport(report TopologyReportMessage) (handled bool) {
+	if !n.edge.info.NegotiatedFeatures.Has(FEATURE_TOPOLOGY_DISCOVERY) {
+		return false
+	}
+
+	if !n.edge.isNextHopOf(report.Route) {
+		return false
+	}
+
+	reportToForward := TopologyReportMessage{
+		DataFrame:      n.edge.updateFrame(report.DataFrame),
+		ProbeID:        report.ProbeID,
+		NodeID:         report.NodeID,
+		BridgedNodeIDs: report.BridgedNodeIDs,
+		Route:          report.Route[:len(report.Route)-1],
+	}
+
+	if reportToForward.TTL <= 0 {
+		return false
+	}
+
+	if err := n.edge.protocol.TopologyReport(reportToForward); err != nil {
+		n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, n.edge.handleWriteFailure("Failed to forward topology report", err)})
+		return false
+	}
+
+	return true
+}
+
+func (n *networkEdgeStateConnected) HandleEchoRequest(request EchoRequestMessage) (handled bool) {
+	if !n.edge.info.NegotiatedFeatures.Has(FEATURE_ECHO) || n.edge.IsOriginOfFrame(request.DataFrame) {
+		return false
+	}
+
+	// routed request is forwarded only towards its next hop
+	route := request.Route
+	if len(route) > 0 {
+		if !n.edge.isNextHopOf(route) {
+			return false
+		}
+
+		route = route[:len(route)-1]
+	}
+
+	requestToForward := EchoRequestMessage{
+		DataFrame:    n.edge.updateFrame(request.DataFrame),
+		EchoID:       request.EchoID,
+		TargetNodeID: request.TargetNodeID,
+		Route:        route,
+	}
+
+	// echo requests are deduplicated by the nodes, so it is safe
+	// to skip the loop detection and check only the TTL
+	if requestToForward.TTL <= 0 {
+		return false
+	}
+
+	if err := n.edge.protocol.EchoRequest(requestToForward); err != nil {
+		n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, n.edge.handleWriteFailure("Failed to forward echo request", err)})
+		return false
+	}
+
+	return true
+}
+
+func (n *networkEdgeStateConnected) HandleEchoReply(reply EchoReplyMessage) (handled bool) {
+	if !n.edge.info.NegotiatedFeatures.Has(FEATURE_ECHO) || !n.edge.isNextHopOf(reply.Route) {
+		return false
+	}
+
+	replyToForward := EchoReplyMessage{
+		DataFrame: n.edge.updateFrame(reply.DataFrame),
+		EchoID:    reply.EchoID,
+		NodeID:    reply.NodeID,
+		Route:     reply.Route[:len(reply.Route)-1],
+	}
+
+	if replyToForward.TTL <= 0 {
+		return false
+	}
+
+	if err := n.edge.protocol.EchoReply(replyToForward); err != nil {
+		n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, n.edge.handleWriteFailure("Failed to forward echo reply", err)})
+		return false
+	}
+
+	return true
 }
 
 /* ProtocolDecoderHandler interface implementation */
@@ -144,50 +305,126 @@ func (n *networkEdgeStateConnected) OnConnectionAccepted(message ConnectionAccep
 }
 
 func (n *networkEdgeStateConnected) OnGracefullyClose(message GracefullyCloseMessage) {
-	n.edge.SetState(&networkEdgeStateDisconnected{n.edge, message.Reason, nil})
+	n.edge.SetState(&networkEdgeStateDisconnected{n.edge, message.Reason})
 }
 
 func (n *networkEdgeStateConnected) OnTerminateNetwork(message TerminateNetworkMessage) {
-	n.edge.SetState(&networkEdgeStateDisconnected{n.edge, message.Reason, nil})
+	n.edge.SetState(&networkEdgeStateDisconnected{n.edge, message.Reason})
 	n.edge.network.Terminated(message)
 }
 
 func (n *networkEdgeStateConnected) OnPublish(message PublishMessage) {
-	n.edge.network.Published(message)
+	// the credit is counted in the size of the read frame
+	frameSize := n.edge.readPublicationSize
+
+	message, err := n.edge.aliases.Resolve(message)
+	if err != nil {
+		n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, err.Error()})
+		return
+	}
+
+	// the topic is validated once here, the subscriptions
+	// matching it do not validate it again
+	if !IsCorrectTopicPattern(message.Topic) {
+		n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, "Received publication with incorrect topic"})
+		return
+	}
+
+	switch n.edge.inboundLimiter.Limit(message) {
+	case publicationPassed:
+		n.edge.receivePublication(message)
+	case publicationLimited:
+		n.edge.network.metrics.RecordDroppedPublication(DROP_REASON_RATE_LIMIT)
+		n.edge.logger().Debug("publication dropped", slog.String("topic", message.Topic), slog.String("reason", string(DROP_REASON_RATE_LIMIT)))
+		n.edge.network.tracer.StartSpan(SPAN_RECEIVE, n.edge.info.BridgedNodeID, message).End(SPAN_OUTCOME_DROPPED, DROP_REASON_RATE_LIMIT)
+	}
+
+	n.returnConsumedCredit(frameSize)
 }
 
-func (n *networkEdgeStateConnected) OnSubscribe(message SubscribeMessage) {
-	oldTopics := n.edge.bridgedNodeSubscriptions.GetOnlyTopLevelSubscribedTopics()
-	n.edge.bridgedNodeSubscriptions.AddSubscription(message.Topic, &struct{}{})
-	n.updateSubscriptions(oldTopics, message.DataFrame)
+func (n *networkEdgeStateConnected) returnConsumedCredit(frameSize int) {
+	frames, bytes, shouldGrant := n.edge.flow.Consume(frameSize)
+	if !shouldGrant {
+		return
+	}
+
+	err := n.edge.protocol.FlowCredit(FlowCreditMessage{
+		DataFrame: DataFrame{
+			TTL:       ONLY_DIRECT_CONNECTION_TTL,
+			Traversed: []string{n.edge.network.config.HostID},
+		},
+		Frames: frames,
+		Bytes:  bytes,
+	})
+
+	if err != nil {
+		n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, n.edge.handleWriteFailure("Failed to grant flow credit", err)})
+	}
 }
 
-func (n *networkEdgeStateConnected) OnUnsubscribe(message UnsubscribeMessage) {
-	subscriptionID, subscriptionFound := n.findSubscriptionUsingTopicPattern(message.Topic)
-	if !subscriptionFound {
+func (n *networkEdgeStateConnected) OnFlowCredit(message FlowCreditMessage) {
+	if err := n.edge.flow.Grant(message, n.edge.publish); err != nil {
+		n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, n.edge.handleWriteFailure("Failed to publish queued message", err)})
+	}
+}
+
+func (n *networkEdgeStateConnected) OnTopologyProbe(message TopologyProbeMessage) {
+	if !n.edge.info.NegotiatedFeatures.Has(FEATURE_TOPOLOGY_DISCOVERY) {
+		n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, "Unexpected topology probe message, topology discovery was not negotiated"})
 		return
 	}
 
-	oldTopics := n.edge.bridgedNodeSubscriptions.GetOnlyTopLevelSubscribedTopics()
-	n.edge.bridgedNodeSubscriptions.RemoveSubscription(subscriptionID)
-	n.updateSubscriptions(oldTopics, message.DataFrame)
+	n.edge.network.TopologyProbed(message)
 }
 
-func (n *networkEdgeStateConnected) findSubscriptionUsingTopicPattern(topic string) (SubscriptionID, bool) {
-	subscriptions := n.edge.bridgedNodeSubscriptions.GetSubscriptions()
+func (n *networkEdgeStateConnected) OnTopologyReport(message TopologyReportMessage) {
+	if !n.edge.info.NegotiatedFeatures.Has(FEATURE_TOPOLOGY_DISCOVERY) {
+		n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, "Unexpected topology report message, topology discovery was not negotiated"})
+		return
+	}
 
-	for _, subscription := range subscriptions {
-		if subscription.TopicPattern == topic {
-			return subscription.ID, true
-		}
+	n.edge.network.TopologyReported(message)
+}
+
+func (n *networkEdgeStateConnected) OnEchoRequest(message EchoRequestMessage) {
+	if !n.edge.info.NegotiatedFeatures.Has(FEATURE_ECHO) {
+		n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, "Unexpected echo request message, echo was not negotiated"})
+		return
+	}
+
+	n.edge.network.EchoRequested(message)
+}
+
+func (n *networkEdgeStateConnected) OnEchoReply(message EchoReplyMessage) {
+	if !n.edge.info.NegotiatedFeatures.Has(FEATURE_ECHO) {
+		n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, "Unexpected echo reply message, echo was not negotiated"})
+		return
+	}
+
+	n.edge.network.EchoReplied(message)
+}
+
+func (n *networkEdgeStateConnected) OnSubscribe(message SubscribeMessage) {
+	if !IsCorrectTopicPattern(message.Topic) {
+		n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, "Received subscription with incorrect topic pattern"})
+		return
 	}
 
-	return 0, false
+	_, topicsToUnsubscribe, topicsToSubscribe := n.edge.bridgedNodeSubscriptions.AddSubscriptionWithDiff(message.Topic, &struct{}{})
+	n.updateSubscriptions(topicsToUnsubscribe, topicsToSubscribe, message.DataFrame)
 }
 
-func (n *networkEdgeStateConnected) updateSubscriptions(oldTopics []string, frame DataFrame) {
-	topicsToUnsubscribe, topicsToSubscribe := GetDeduplicatedOverlappingTopicsDiff(oldTopics, n.edge.bridgedNodeSubscriptions.GetOnlyTopLevelSubscribedTopics())
+func (n *networkEdgeStateConnected) OnUnsubscribe(message UnsubscribeMessage) {
+	subscriptionID, subscriptionFound := n.edge.bridgedNodeSubscriptions.FindSubscriptionByTopicPattern(message.Topic)
+	if !subscriptionFound {
+		return
+	}
+
+	topicsToUnsubscribe, topicsToSubscribe := n.edge.bridgedNodeSubscriptions.RemoveSubscriptionWithDiff(subscriptionID)
+	n.updateSubscriptions(topicsToUnsubscribe, topicsToSubscribe, message.DataFrame)
+}
 
+func (n *networkEdgeStateConnected) updateSubscriptions(topicsToUnsubscribe, topicsToSubscribe []string, frame DataFrame) {
 	for _, topic := range topicsToSubscribe {
 		n.edge.network.Subscribed(SubscribeMessage{
 			DataFrame: frame,
@@ -204,5 +441,6 @@ func (n *networkEdgeStateConnected) updateSubscriptions(oldTopics []string, fram
 }
 
 func (n *networkEdgeStateConnected) OnMalformedMessage(message MalformedMessage) {
+	n.edge.logger().Warn("malformed message received", slog.Int("size", len(message.Message)))
 	n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, "Malformed message received"})
 }
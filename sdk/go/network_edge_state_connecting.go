@@ -1,5 +1,7 @@
 package directmq
 
+import "log/slog"
+
 const PROTOCOL_VERSION = 1
 const UNKNOWN_PROTOCOL_VERSION = 0
 
@@ -15,10 +17,14 @@ func (n *networkEdgeStateConnecting) GetStateName() edgeStateName {
 }
 
 func (n *networkEdgeStateConnecting) OnSet() {
+	n.edge.traffic.StartHandshake()
+
 	if !n.initializeConnection {
+		n.edge.logger().Debug("waiting for protocol negotiation")
 		return
 	}
 
+	n.edge.logger().Debug("negotiating protocol version", slog.Any("supported_versions", []uint32{PROTOCOL_VERSION}))
 	err := n.edge.protocol.SupportedProtocolVersions(SupportedProtocolVersionsMessage{
 		DataFrame: DataFrame{
 			TTL:       ONLY_DIRECT_CONNECTION_WITH_RESPONSE_TTL,
@@ -28,7 +34,7 @@ func (n *networkEdgeStateConnecting) OnSet() {
 	})
 
 	if err != nil {
-		n.edge.SetState(&networkEdgeStateDisconnected{n.edge, "Supported protocol version negotiation failed: " + err.Error(), nil})
+		n.edge.SetState(&networkEdgeStateDisconnected{n.edge, n.edge.handleWriteFailure("Supported protocol version negotiation failed", err)})
 	}
 }
 
@@ -46,9 +52,9 @@ func (n *networkEdgeStateConnecting) IsOriginOfFrame(frame DataFrame) bool {
 	panic("this method should not be used, use the networkEdge.IsOriginOfFrame method instead")
 }
 
-func (n *networkEdgeStateConnecting) HandlePublish(publication PublishMessage) (handled bool) {
+func (n *networkEdgeStateConnecting) HandlePublish(publication PublishMessage) (handled bool, dropReason DropReason) {
 	// we are connecting, we cannot handle any publications
-	return false
+	return false, ""
 }
 
 func (n *networkEdgeStateConnecting) HandleSubscribe(subscription SubscribeMessage) {
@@ -63,12 +69,37 @@ func (n *networkEdgeStateConnecting) HandleTerminateNetwork(terminate TerminateN
 	n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, "Network terminated: " + terminate.Reason})
 }
 
+func (n *networkEdgeStateConnecting) HandleTopologyProbe(probe TopologyProbeMessage) {
+	// we are connecting, we cannot handle any topology probes
+}
+
+func (n *networkEdgeStateConnecting) HandleTopologyReport(report TopologyReportMessage) (handled bool) {
+	// we are connecting, we cannot handle any topology reports
+	return false
+}
+
+func (n *networkEdgeStateConnecting) HandleEchoRequest(request EchoRequestMessage) (handled bool) {
+	// we are connecting, we cannot handle any echo requests
+	return false
+}
+
+func (n *networkEdgeStateConnecting) HandleEchoReply(reply EchoReplyMessage) (handled bool) {
+	// we are connecting, we cannot handle any echo replies
+	return false
+}
+
 /* ProtocolDecoderHandler interface implementation */
 
 func (n *networkEdgeStateConnecting) OnSupportedProtocolVersions(message SupportedProtocolVersionsMessage) {
 	n.edge.info.BridgedNodeSupportedProtocolVersions = message.SupportedVersions
 	n.edge.info.NegotiatedProtocolVersion = PROTOCOL_VERSION // TODO: check if supports v1
 
+	n.edge.logger().Debug(
+		"received supported protocol versions",
+		slog.Any("supported_versions", message.SupportedVersions),
+		slog.Uint64("negotiated_version", uint64(n.edge.info.NegotiatedProtocolVersion)),
+	)
+
 	if message.TTL == ONLY_DIRECT_CONNECTION_WITH_RESPONSE_TTL {
 		n.respondWithSupportedProtocolVersions(message)
 		return
@@ -87,21 +118,28 @@ func (n *networkEdgeStateConnecting) respondWithSupportedProtocolVersions(messag
 	})
 
 	if err != nil {
-		n.edge.SetState(&networkEdgeStateDisconnected{n.edge, "Respond to supported protocol versions failed: " + err.Error(), nil})
+		n.edge.SetState(&networkEdgeStateDisconnected{n.edge, n.edge.handleWriteFailure("Respond to supported protocol versions failed", err)})
 	}
 }
 
 func (n *networkEdgeStateConnecting) initializeEdgeConnection() {
+	frameCredit, byteCredit := getHostIncomingCredit(n.edge.network.config)
+
+	n.edge.logger().Debug("initializing connection")
 	err := n.edge.protocol.InitConnection(InitConnectionMessage{
 		DataFrame: DataFrame{
 			TTL:       ONLY_DIRECT_CONNECTION_TTL,
 			Traversed: []string{n.edge.network.config.HostID},
 		},
-		MaxMessageSize: n.edge.network.config.HostMaxIncomingMessageSize,
+		MaxMessageSize:    n.edge.network.config.HostMaxIncomingMessageSize,
+		SupportedFeatures: n.edge.getHostFeatures(),
+		FrameCredit:       frameCredit,
+		ByteCredit:        byteCredit,
+		MaxTopicAliases:   getHostAnnouncedTopicAliases(n.edge.network.config),
 	})
 
 	if err != nil {
-		n.edge.SetState(&networkEdgeStateDisconnected{n.edge, "Connection initialization failed: " + err.Error(), nil})
+		n.edge.SetState(&networkEdgeStateDisconnected{n.edge, n.edge.handleWriteFailure("Connection initialization failed", err)})
 	}
 }
 
@@ -118,21 +156,38 @@ func (n *networkEdgeStateConnecting) OnInitConnection(message InitConnectionMess
 
 	n.edge.info.BridgedNodeID = message.Traversed[0]
 	n.edge.info.BridgedNodeMaxMessageSize = message.MaxMessageSize
+	n.edge.info.BridgedNodeSupportedFeatures = message.SupportedFeatures
+	n.edge.info.BridgedNodeFrameCredit = message.FrameCredit
+	n.edge.info.BridgedNodeByteCredit = message.ByteCredit
+	n.edge.info.BridgedNodeMaxTopicAliases = message.MaxTopicAliases
+	n.edge.info.NegotiatedFeatures = n.edge.getHostFeatures() & message.SupportedFeatures
+
+	n.edge.logger().Debug(
+		"received connection initialization",
+		slog.Uint64("max_message_size", message.MaxMessageSize),
+		slog.Uint64("supported_features", uint64(message.SupportedFeatures)),
+	)
 
 	n.acceptEdgeConnection()
 }
 
 func (n *networkEdgeStateConnecting) acceptEdgeConnection() {
+	frameCredit, byteCredit := getHostIncomingCredit(n.edge.network.config)
+
 	err := n.edge.protocol.ConnectionAccepted(ConnectionAcceptedMessage{
 		DataFrame: DataFrame{
 			TTL:       ONLY_DIRECT_CONNECTION_TTL,
 			Traversed: []string{n.edge.network.config.HostID},
 		},
-		MaxMessageSize: n.edge.network.config.HostMaxIncomingMessageSize,
+		MaxMessageSize:    n.edge.network.config.HostMaxIncomingMessageSize,
+		SupportedFeatures: n.edge.getHostFeatures(),
+		FrameCredit:       frameCredit,
+		ByteCredit:        byteCredit,
+		MaxTopicAliases:   getHostAnnouncedTopicAliases(n.edge.network.config),
 	})
 
 	if err != nil {
-		n.edge.SetState(&networkEdgeStateDisconnected{n.edge, "Connection acceptance failed: " + err.Error(), nil})
+		n.edge.SetState(&networkEdgeStateDisconnected{n.edge, n.edge.handleWriteFailure("Connection acceptance failed", err)})
 		return
 	}
 
@@ -152,12 +207,23 @@ func (n *networkEdgeStateConnecting) OnConnectionAccepted(message ConnectionAcce
 
 	n.edge.info.BridgedNodeID = message.Traversed[0]
 	n.edge.info.BridgedNodeMaxMessageSize = message.MaxMessageSize
+	n.edge.info.BridgedNodeSupportedFeatures = message.SupportedFeatures
+	n.edge.info.BridgedNodeFrameCredit = message.FrameCredit
+	n.edge.info.BridgedNodeByteCredit = message.ByteCredit
+	n.edge.info.BridgedNodeMaxTopicAliases = message.MaxTopicAliases
+	n.edge.info.NegotiatedFeatures = n.edge.getHostFeatures() & message.SupportedFeatures
+
+	n.edge.logger().Debug(
+		"received connection acceptance",
+		slog.Uint64("max_message_size", message.MaxMessageSize),
+		slog.Uint64("supported_features", uint64(message.SupportedFeatures)),
+	)
 
 	n.edge.SetState(&networkEdgeStateConnected{n.edge})
 }
 
 func (n *networkEdgeStateConnecting) OnGracefullyClose(message GracefullyCloseMessage) {
-	n.edge.SetState(&networkEdgeStateDisconnected{n.edge, message.Reason, nil})
+	n.edge.SetState(&networkEdgeStateDisconnected{n.edge, message.Reason})
 }
 
 func (n *networkEdgeStateConnecting) OnTerminateNetwork(message TerminateNetworkMessage) {
@@ -176,6 +242,27 @@ func (n *networkEdgeStateConnecting) OnUnsubscribe(message UnsubscribeMessage) {
 	n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, "Unexpected unsubscribe message in connection process"})
 }
 
+func (n *networkEdgeStateConnecting) OnFlowCredit(message FlowCreditMessage) {
+	n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, "Unexpected flow credit message in connection process"})
+}
+
+func (n *networkEdgeStateConnecting) OnTopologyProbe(message TopologyProbeMessage) {
+	n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, "Unexpected topology probe message in connection process"})
+}
+
+func (n *networkEdgeStateConnecting) OnTopologyReport(message TopologyReportMessage) {
+	n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, "Unexpected topology report message in connection process"})
+}
+
+func (n *networkEdgeStateConnecting) OnEchoRequest(message EchoRequestMessage) {
+	n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, "Unexpected echo request message in connection process"})
+}
+
+func (n *networkEdgeStateConnecting) OnEchoReply(message EchoReplyMessage) {
+	n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, "Unexpected echo reply message in connection process"})
+}
+
 func (n *networkEdgeStateConnecting) OnMalformedMessage(message MalformedMessage) {
+	n.edge.logger().Warn("malformed message received", slog.Int("size", len(message.Message)))
 	n.edge.SetState(&networkEdgeStateDisconnecting{n.edge, "Malformed message in connection process"})
 }
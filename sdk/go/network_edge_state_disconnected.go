@@ -1,10 +1,11 @@
 package directmq
 
+import "log/slog"
+
 type networkEdgeStateDisconnected struct {
 	edge *networkEdge
 
-	reason     string
-	closeError error
+	reason string
 }
 
 var _ networkEdgeState = (*networkEdgeStateDisconnected)(nil)
@@ -14,7 +15,9 @@ func (n *networkEdgeStateDisconnected) GetStateName() edgeStateName {
 }
 
 func (n *networkEdgeStateDisconnected) OnSet() {
-	n.closeError = n.edge.portal.Close()
+	n.edge.writer.Close(n.edge.logger())
+	n.edge.logger().Info("edge disconnected", slog.String("reason", n.reason))
+
 	n.edge.network.diag.HandleConnectionLost(n.edge.info.BridgedNodeID, n.reason, n.edge.portal)
 
 	n.revokeAllBridgedNodeSubscriptionsFromNetwork()
@@ -22,7 +25,17 @@ func (n *networkEdgeStateDisconnected) OnSet() {
 	n.edge.info.BridgedNodeID = ""
 	n.edge.info.BridgedNodeMaxMessageSize = NO_MAX_MESSAGE_SIZE
 	n.edge.info.BridgedNodeSupportedProtocolVersions = []uint32{}
+	n.edge.info.BridgedNodeSupportedFeatures = NO_PROTOCOL_FEATURES
+	n.edge.info.BridgedNodeFrameCredit = NO_FLOW_CONTROL
+	n.edge.info.BridgedNodeByteCredit = NO_FLOW_CONTROL
+	n.edge.info.BridgedNodeMaxTopicAliases = 0
 	n.edge.info.NegotiatedProtocolVersion = UNKNOWN_PROTOCOL_VERSION
+	n.edge.info.NegotiatedFeatures = NO_PROTOCOL_FEATURES
+
+	n.edge.flow.Clear()
+	n.edge.aliases.Clear()
+	n.edge.inboundLimiter.Clear()
+	n.edge.outboundLimiter.Clear()
 }
 
 func (n *networkEdgeStateDisconnected) revokeAllBridgedNodeSubscriptionsFromNetwork() {
@@ -55,9 +68,9 @@ func (n *networkEdgeStateDisconnected) IsOriginOfFrame(frame DataFrame) bool {
 	panic("this method should not be used, use the networkEdge.IsOriginOfFrame method instead")
 }
 
-func (n *networkEdgeStateDisconnected) HandlePublish(publication PublishMessage) (handled bool) {
+func (n *networkEdgeStateDisconnected) HandlePublish(publication PublishMessage) (handled bool, dropReason DropReason) {
 	// we are disconnected, we cannot handle any publications
-	return false
+	return false, ""
 }
 
 func (n *networkEdgeStateDisconnected) HandleSubscribe(subscription SubscribeMessage) {
@@ -72,6 +85,25 @@ func (n *networkEdgeStateDisconnected) HandleTerminateNetwork(terminate Terminat
 	// we are disconnected, we cannot handle any termination messages
 }
 
+func (n *networkEdgeStateDisconnected) HandleTopologyProbe(probe TopologyProbeMessage) {
+	// we are disconnected, we cannot handle any topology probes
+}
+
+func (n *networkEdgeStateDisconnected) HandleTopologyReport(report TopologyReportMessage) (handled bool) {
+	// we are disconnected, we cannot handle any topology reports
+	return false
+}
+
+func (n *networkEdgeStateDisconnected) HandleEchoRequest(request EchoRequestMessage) (handled bool) {
+	// we are disconnected, we cannot handle any echo requests
+	return false
+}
+
+func (n *networkEdgeStateDisconnected) HandleEchoReply(reply EchoReplyMessage) (handled bool) {
+	// we are disconnected, we cannot handle any echo replies
+	return false
+}
+
 /* ProtocolDecoderHandler interface implementation */
 
 func (n *networkEdgeStateDisconnected) OnSupportedProtocolVersions(message SupportedProtocolVersionsMessage) {
@@ -106,6 +138,26 @@ func (n *networkEdgeStateDisconnected) OnUnsubscribe(message UnsubscribeMessage)
 	// we are disconnected, we cannot handle any unsubscribe messages
 }
 
+func (n *networkEdgeStateDisconnected) OnFlowCredit(message FlowCreditMessage) {
+	// we are disconnected, we cannot handle any flow credit messages
+}
+
+func (n *networkEdgeStateDisconnected) OnTopologyProbe(message TopologyProbeMessage) {
+	// we are disconnected, we cannot handle any topology probe messages
+}
+
+func (n *networkEdgeStateDisconnected) OnTopologyReport(message TopologyReportMessage) {
+	// we are disconnected, we cannot handle any topology report messages
+}
+
+func (n *networkEdgeStateDisconnected) OnEchoRequest(message EchoRequestMessage) {
+	// we are disconnected, we cannot handle any echo request messages
+}
+
+func (n *networkEdgeStateDisconnected) OnEchoReply(message EchoReplyMessage) {
+	// we are disconnected, we cannot handle any echo reply messages
+}
+
 func (n *networkEdgeStateDisconnected) OnMalformedMessage(message MalformedMessage) {
 	// we are disconnected, we cannot handle any malformed messages
 }
@@ -12,7 +12,7 @@ func (n *networkEdgeStateDisconnecting) GetStateName() edgeStateName {
 }
 
 func (n *networkEdgeStateDisconnecting) OnSet() {
-	n.edge.protocol.GracefullyClose(GracefullyCloseMessage{
+	err := n.edge.protocol.GracefullyClose(GracefullyCloseMessage{
 		DataFrame: DataFrame{
 			TTL:       ONLY_DIRECT_CONNECTION_TTL,
 			Traversed: []string{n.edge.network.config.HostID},
@@ -20,7 +20,12 @@ func (n *networkEdgeStateDisconnecting) OnSet() {
 		Reason: n.reason,
 	})
 
-	n.edge.SetState(&networkEdgeStateDisconnected{n.edge, n.reason, nil})
+	if err != nil {
+		// we are closing the edge anyway, failure is only reported
+		n.edge.handleWriteFailure("Failed to gracefully close edge", err)
+	}
+
+	n.edge.SetState(&networkEdgeStateDisconnected{n.edge, n.reason})
 }
 
 /* networkParticipant interface implementation */
@@ -37,9 +42,9 @@ func (n *networkEdgeStateDisconnecting) IsOriginOfFrame(frame DataFrame) bool {
 	panic("this method should not be used, use the networkEdge.IsOriginOfFrame method instead")
 }
 
-func (n *networkEdgeStateDisconnecting) HandlePublish(publication PublishMessage) (handled bool) {
+func (n *networkEdgeStateDisconnecting) HandlePublish(publication PublishMessage) (handled bool, dropReason DropReason) {
 	// we are disconnecting, we cannot handle any publications
-	return false
+	return false, ""
 }
 
 func (n *networkEdgeStateDisconnecting) HandleSubscribe(subscription SubscribeMessage) {
@@ -54,6 +59,25 @@ func (n *networkEdgeStateDisconnecting) HandleTerminateNetwork(terminate Termina
 	// we are disconnecting, we cannot handle any termination messages
 }
 
+func (n *networkEdgeStateDisconnecting) HandleTopologyProbe(probe TopologyProbeMessage) {
+	// we are disconnecting, we cannot handle any topology probes
+}
+
+func (n *networkEdgeStateDisconnecting) HandleTopologyReport(report TopologyReportMessage) (handled bool) {
+	// we are disconnecting, we cannot handle any topology reports
+	return false
+}
+
+func (n *networkEdgeStateDisconnecting) HandleEchoRequest(request EchoRequestMessage) (handled bool) {
+	// we are disconnecting, we cannot handle any echo requests
+	return false
+}
+
+func (n *networkEdgeStateDisconnecting) HandleEchoReply(reply EchoReplyMessage) (handled bool) {
+	// we are disconnecting, we cannot handle any echo replies
+	return false
+}
+
 /* ProtocolDecoderHandler interface implementation */
 
 func (n *networkEdgeStateDisconnecting) OnSupportedProtocolVersions(message SupportedProtocolVersionsMessage) {
@@ -88,6 +112,26 @@ func (n *networkEdgeStateDisconnecting) OnUnsubscribe(message UnsubscribeMessage
 	// we are disconnecting, we cannot handle any unsubscribe messages
 }
 
+func (n *networkEdgeStateDisconnecting) OnFlowCredit(message FlowCreditMessage) {
+	// we are disconnecting, we cannot handle any flow credit messages
+}
+
+func (n *networkEdgeStateDisconnecting) OnTopologyProbe(message TopologyProbeMessage) {
+	// we are disconnecting, we cannot handle any topology probe messages
+}
+
+func (n *networkEdgeStateDisconnecting) OnTopologyReport(message TopologyReportMessage) {
+	// we are disconnecting, we cannot handle any topology report messages
+}
+
+func (n *networkEdgeStateDisconnecting) OnEchoRequest(message EchoRequestMessage) {
+	// we are disconnecting, we cannot handle any echo request messages
+}
+
+func (n *networkEdgeStateDisconnecting) OnEchoReply(message EchoReplyMessage) {
+	// we are disconnecting, we cannot handle any echo reply messages
+}
+
 func (n *networkEdgeStateDisconnecting) OnMalformedMessage(message MalformedMessage) {
 	// we are disconnecting, we cannot handle any malformed messages
 }
@@ -1,5 +1,7 @@
 package directmq
 
+import "context"
+
 type EdgeManager interface {
 	AddListeningEdge(portal Portal) error
 	AddConnectingEdge(portal Portal) error
@@ -12,6 +14,11 @@ type NetworkNode interface {
 	EdgeManager
 
 	GetBridgedNodeIDs() []string
+	GetEdges() []EdgeInfo
+	GetMetrics() NetworkNodeMetrics
+	DiscoverTopology(ctx context.Context) TopologyGraph
+	Ping(ctx context.Context, nodeID string) (PingResult, error)
+	Traceroute(ctx context.Context, nodeID string) ([]TracerouteHop, error)
 	CloseNode(reason string)
 }
 
@@ -30,11 +37,18 @@ type networkNode struct {
 var _ NetworkNode = (*networkNode)(nil)
 
 func newNetworkNode(networkConfig NetworkNodeConfig, protocol ProtocolFactory) *networkNode {
+	for _, rule := range networkConfig.HostRateLimits {
+		if !IsCorrectRateLimitRule(rule) {
+			panic("incorrect rate limit rule")
+		}
+	}
+
 	diagnosticsAPI := &diagnosticsAPI{}
 	nativeAPI := newNativeAPI()
 	globalNetwork := newGlobalNetwork(networkConfig, nativeAPI, diagnosticsAPI)
 
 	nativeAPI.network = globalNetwork
+	diagnosticsAPI.network = globalNetwork
 
 	node := &networkNode{
 		network: globalNetwork,
@@ -58,27 +72,39 @@ func NewNetworkNode(networkConfig NetworkNodeConfig, protocol ProtocolFactory) N
 /* EdgeManager interface implementation */
 
 func (n *networkNode) AddListeningEdge(portal Portal) error {
-	edge := newNetworkEdge(portal, n.network)
-	edge.protocol = n.createProtocolInstance(edge, portal)
-	edge.SetState(&networkEdgeStateConnecting{edge, false})
-	n.edges = append(n.edges, edge)
-	n.network.participants = append(n.network.participants, edge)
-	return edge.Run()
+	return n.addEdge(portal, false).Run()
 }
 
 func (n *networkNode) AddConnectingEdge(portal Portal) error {
+	return n.addEdge(portal, true).Run()
+}
+
+func (n *networkNode) addEdge(portal Portal, initializeConnection bool) *networkEdge {
+	n.network.lock()
+	defer n.network.unlock()
+
 	edge := newNetworkEdge(portal, n.network)
-	edge.protocol = n.createProtocolInstance(edge, portal)
-	edge.SetState(&networkEdgeStateConnecting{edge, true})
+	edge.protocol = n.createProtocolInstance(edge, edge.writer)
+	edge.SetState(&networkEdgeStateConnecting{edge, initializeConnection})
 	n.edges = append(n.edges, edge)
 	n.network.participants = append(n.network.participants, edge)
-	return edge.Run()
+	return edge
 }
 
 func (n *networkNode) RemoveEdge(portal Portal, reason string) {
-	edge, index := n.findEdgeByPortal(portal)
-	if edge != nil {
-		n.disconnectEdgeFromNetwork(edge, reason)
+	n.network.lock()
+	defer n.network.unlock()
+
+	edge, _ := n.findEdgeByPortal(portal)
+	if edge == nil {
+		return
+	}
+
+	n.disconnectEdgeFromNetwork(edge, reason)
+
+	// the disconnected edge is usually already removed
+	// by the connection lost handler, so it is looked up again
+	if edge, index := n.findEdgeByPortal(portal); edge != nil {
 		n.edges = append(n.edges[:index], n.edges[index+1:]...)
 	}
 }
@@ -113,8 +139,8 @@ func (n *networkNode) handleEdgeConnectionLost(bridgedNodeID, reason string, por
 		n.edges = append(n.edges[:index], n.edges[index+1:]...)
 	}
 
-	if n.onConnectionLostCallback != nil {
-		n.onConnectionLostCallback(bridgedNodeID, reason, portal)
+	if callback := n.onConnectionLostCallback; callback != nil {
+		n.network.schedule(func() { callback(bridgedNodeID, reason, portal) })
 	}
 }
 
@@ -129,6 +155,9 @@ func (n *networkNode) findEdgeByPortal(portal Portal) (edge *networkEdge, index
 }
 
 func (n *networkNode) GetBridgedNodeIDs() []string {
+	n.network.lock()
+	defer n.network.unlock()
+
 	ids := make([]string, 0, len(n.edges))
 	for _, edge := range n.edges {
 		if edge.GetStateName() == stateConnected {
@@ -139,7 +168,38 @@ func (n *networkNode) GetBridgedNodeIDs() []string {
 	return ids
 }
 
+func (n *networkNode) GetEdges() []EdgeInfo {
+	n.network.lock()
+	defer n.network.unlock()
+
+	edges := make([]EdgeInfo, 0, len(n.edges))
+	for _, edge := range n.edges {
+		edges = append(edges, edge.GetInfo())
+	}
+
+	return edges
+}
+
+func (n *networkNode) GetMetrics() NetworkNodeMetrics {
+	n.network.lock()
+	defer n.network.unlock()
+
+	metrics := n.network.metrics.Snapshot()
+	metrics.NodeID = n.network.config.HostID
+	metrics.SubscriptionCount = len(n.api.subscriptions.GetSubscriptions())
+
+	metrics.Edges = make([]EdgeMetrics, 0, len(n.edges))
+	for _, edge := range n.edges {
+		metrics.Edges = append(metrics.Edges, edge.GetMetrics())
+	}
+
+	return metrics
+}
+
 func (n *networkNode) CloseNode(reason string) {
+	n.network.lock()
+	defer n.network.unlock()
+
 	for _, edge := range n.edges {
 		n.disconnectEdgeFromNetwork(edge, reason)
 	}
@@ -164,25 +224,64 @@ func (n *networkNode) Unsubscribe(id SubscriptionID) {
 /* DiagnosticsAPI interface implementation */
 
 func (n *networkNode) OnConnectionEstablished(callback func(bridgedNodeID string, portal Portal)) {
+	n.network.lock()
+	defer n.network.unlock()
+
 	n.diagnostics.OnConnectionEstablished(callback)
 }
 
 func (n *networkNode) OnConnectionLost(callback func(bridgedNodeID, reason string, portal Portal)) {
+	n.network.lock()
+	defer n.network.unlock()
+
 	n.onConnectionLostCallback = callback
 }
 
 func (n *networkNode) OnPublication(callback func(message PublishMessage)) {
+	n.network.lock()
+	defer n.network.unlock()
+
 	n.diagnostics.OnPublication(callback)
 }
 
 func (n *networkNode) OnSubscription(callback func(message SubscribeMessage)) {
+	n.network.lock()
+	defer n.network.unlock()
+
 	n.diagnostics.OnSubscription(callback)
 }
 
 func (n *networkNode) OnUnsubscribe(callback func(message UnsubscribeMessage)) {
+	n.network.lock()
+	defer n.network.unlock()
+
 	n.diagnostics.OnUnsubscribe(callback)
 }
 
 func (n *networkNode) OnTerminateNetwork(callback func(message TerminateNetworkMessage)) {
+	n.network.lock()
+	defer n.network.unlock()
+
 	n.diagnostics.OnTerminateNetwork(callback)
 }
+
+func (n *networkNode) OnFlowControlStall(callback func(bridgedNodeID string, queuedPublications int)) {
+	n.network.lock()
+	defer n.network.unlock()
+
+	n.diagnostics.OnFlowControlStall(callback)
+}
+
+func (n *networkNode) OnEdgeStateChanged(callback func(edge EdgeInfo, previous EdgeState)) {
+	n.network.lock()
+	defer n.network.unlock()
+
+	n.diagnostics.OnEdgeStateChanged(callback)
+}
+
+func (n *networkNode) OnCorruptedPacket(callback func(bridgedNodeID, reason string, portal Portal)) {
+	n.network.lock()
+	defer n.network.unlock()
+
+	n.diagnostics.OnCorruptedPacket(callback)
+}
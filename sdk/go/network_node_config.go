@@ -1,11 +1,15 @@
 package directmq
 
+import "log/slog"
+
 const (
 	DEFAULT_TTL                              = 32
 	ONLY_DIRECT_CONNECTION_TTL               = 1
 	ONLY_DIRECT_CONNECTION_WITH_RESPONSE_TTL = 2
 	NO_ANSWER_TTL                            = 0
 	NO_MAX_MESSAGE_SIZE                      = 0
+	NO_FLOW_CONTROL                          = 0
+	DEFAULT_OUTGOING_QUEUE_SIZE              = 256
 )
 
 type TTL int32
@@ -14,4 +18,38 @@ type NetworkNodeConfig struct {
 	HostTTL                    TTL
 	HostMaxIncomingMessageSize uint64
 	HostID                     string
+
+	// Protocol extensions enabled on this node, extension is used
+	// on the edge only when it is enabled on both bridged nodes.
+	HostFeatures ProtocolFeatures
+
+	// Credit granted to every bridged node when FEATURE_FLOW_CONTROL
+	// is negotiated, bridged node has to wait for a new credit
+	// after using it up. NO_FLOW_CONTROL disables given credit limit.
+	HostIncomingFrameCredit uint32
+	HostIncomingByteCredit  uint64
+
+	// Maximum number of publications waiting for the credit
+	// on a single edge, DEFAULT_OUTGOING_QUEUE_SIZE is used when zero.
+	HostOutgoingQueueSize int
+
+	// Number of topic aliases every bridged node may bind
+	// when FEATURE_TOPIC_ALIASES is negotiated,
+	// DEFAULT_MAX_TOPIC_ALIASES is used when zero.
+	HostMaxTopicAliases uint32
+
+	// Token bucket rate limits applied to the publications
+	// exchanged with bridged nodes, first matching rule wins.
+	HostRateLimits []RateLimitRule
+
+	// Receives spans of the publications passing through the node,
+	// tracing is disabled when nil.
+	SpanExporter SpanExporter
+
+	// Structured logger of the node, logging is disabled when nil.
+	Logger *slog.Logger
+
+	// Syntax of the topic patterns given to the Subscribe method,
+	// TOPIC_GRAMMAR_GLOB is used by default.
+	HostTopicGrammar TopicGrammar
 }
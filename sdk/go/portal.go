@@ -15,3 +15,25 @@ type Portal interface {
 	PacketWriter
 	io.Closer
 }
+
+// CorruptedPacketsReporter is implemented by the portals detecting
+// corrupted packets on the transport level, e.g. by the checksum.
+// Corrupted packets are dropped by the portal, the edge registers
+// the handler when the portal is added to the node and reports
+// them through the diagnostics API.
+type CorruptedPacketsReporter interface {
+	OnCorruptedPacket(handler func(reason string))
+}
+
+// UnreliablePortal is implemented by the portals which may lose,
+// duplicate or reorder the packets. Topic aliases are not negotiated
+// over such portals, as the lost frame binding the alias would make
+// the bridged node resolve the following frames to the wrong topic.
+type UnreliablePortal interface {
+	IsUnreliable() bool
+}
+
+func isUnreliablePortal(portal Portal) bool {
+	unreliable, ok := portal.(UnreliablePortal)
+	return ok && unreliable.IsUnreliable()
+}
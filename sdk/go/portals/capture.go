@@ -0,0 +1,282 @@
+package dmqportals
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+)
+
+// The capture file starts with the CAPTURE_FILE_MAGIC followed
+// by the version byte, then the records follow until the end
+// of the file. Every record is prefixed with its length encoded
+// as unsigned varint and contains:
+//
+//	timestamp  8 bytes, unix nanoseconds, big endian
+//	direction  1 byte
+//	edge ID    unsigned varint length and the bytes
+//	packet     the rest of the record
+const (
+	CAPTURE_FILE_MAGIC   = "DMQCAP"
+	CAPTURE_FILE_VERSION = 1
+
+	CAPTURE_MAX_RECORD_SIZE = STREAM_MAX_PACKET_SIZE + 1024
+)
+
+var (
+	ErrCaptureInvalidHeader  = errors.New("invalid capture file header")
+	ErrCaptureRecordTooLarge = errors.New("capture record too large")
+	ErrCaptureRecordInvalid  = errors.New("invalid capture record")
+)
+
+type CaptureDirection byte
+
+const (
+	CAPTURE_DIRECTION_INCOMING CaptureDirection = 1
+	CAPTURE_DIRECTION_OUTGOING CaptureDirection = 2
+)
+
+func (d CaptureDirection) String() string {
+	switch d {
+	case CAPTURE_DIRECTION_INCOMING:
+		return "in"
+	case CAPTURE_DIRECTION_OUTGOING:
+		return "out"
+	default:
+		return "unknown"
+	}
+}
+
+type CaptureRecord struct {
+	Timestamp time.Time
+	Direction CaptureDirection
+	EdgeID    string
+	Packet    []byte
+}
+
+// CaptureWriter writes the records to the capture file,
+// it can be shared by the portals of many edges.
+type CaptureWriter struct {
+	mutex  sync.Mutex
+	writer io.Writer
+	err    error
+}
+
+// NewCaptureWriter writes the file header, the writer
+// is closed with the capture when it implements io.Closer.
+func NewCaptureWriter(writer io.Writer) (*CaptureWriter, error) {
+	header := append([]byte(CAPTURE_FILE_MAGIC), CAPTURE_FILE_VERSION)
+	if _, err := writer.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &CaptureWriter{writer: writer}, nil
+}
+
+// WriteRecord writes the record at once, so the records written
+// from many goroutines are never interleaved.
+func (c *CaptureWriter) WriteRecord(record CaptureRecord) error {
+	body := binary.BigEndian.AppendUint64(nil, uint64(record.Timestamp.UnixNano()))
+	body = append(body, byte(record.Direction))
+	body = appendLengthPrefixedPacket(body, []byte(record.EdgeID))
+	body = append(body, record.Packet...)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.err != nil {
+		return c.err
+	}
+
+	if _, err := c.writer.Write(appendLengthPrefixedPacket(nil, body)); err != nil {
+		c.err = err
+		return err
+	}
+
+	return nil
+}
+
+// Err returns the first error of writing the records,
+// the records are not written after the error.
+func (c *CaptureWriter) Err() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.err
+}
+
+func (c *CaptureWriter) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.err == nil {
+		c.err = io.ErrClosedPipe
+	}
+
+	if closer, ok := c.writer.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+type CaptureReader struct {
+	reader *bufio.Reader
+}
+
+// NewCaptureReader reads and validates the file header.
+func NewCaptureReader(reader io.Reader) (*CaptureReader, error) {
+	r := bufio.NewReader(reader)
+
+	header := make([]byte, len(CAPTURE_FILE_MAGIC)+1)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, ErrCaptureInvalidHeader
+	}
+
+	if !bytes.Equal(header[:len(CAPTURE_FILE_MAGIC)], []byte(CAPTURE_FILE_MAGIC)) || header[len(header)-1] != CAPTURE_FILE_VERSION {
+		return nil, ErrCaptureInvalidHeader
+	}
+
+	return &CaptureReader{reader: r}, nil
+}
+
+// ReadRecord returns io.EOF after the last record
+// and io.ErrUnexpectedEOF when the file is truncated.
+func (c *CaptureReader) ReadRecord() (CaptureRecord, error) {
+	size, err := binary.ReadUvarint(c.reader)
+	if err != nil {
+		return CaptureRecord{}, err
+	}
+
+	if size > CAPTURE_MAX_RECORD_SIZE {
+		return CaptureRecord{}, ErrCaptureRecordTooLarge
+	}
+
+	body := make([]byte, size)
+	if _, err := io.ReadFull(c.reader, body); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return CaptureRecord{}, err
+	}
+
+	if len(body) < 9 {
+		return CaptureRecord{}, ErrCaptureRecordInvalid
+	}
+
+	record := CaptureRecord{
+		Timestamp: time.Unix(0, int64(binary.BigEndian.Uint64(body))),
+		Direction: CaptureDirection(body[8]),
+	}
+
+	body = body[9:]
+	size, n := binary.Uvarint(body)
+	if n <= 0 || size > uint64(len(body)-n) {
+		return CaptureRecord{}, ErrCaptureRecordInvalid
+	}
+
+	record.EdgeID = string(body[n : n+int(size)])
+	record.Packet = body[n+int(size):]
+	return record, nil
+}
+
+// CapturePortal writes every packet crossing the wrapped portal
+// to the capture. Failing to write the record does not affect
+// the traffic, the error is kept by the CaptureWriter.
+type CapturePortal struct {
+	portalDecorator
+
+	capture *CaptureWriter
+	edgeID  string
+}
+
+var _ directmq.Portal = (*CapturePortal)(nil)
+
+// NewCapturePortal wraps the portal, the edge ID is stored with
+// the records to tell the edges apart in the shared capture.
+func NewCapturePortal(portal directmq.Portal, capture *CaptureWriter, edgeID string) *CapturePortal {
+	return &CapturePortal{
+		portalDecorator: portalDecorator{portal},
+		capture:         capture,
+		edgeID:          edgeID,
+	}
+}
+
+func (p *CapturePortal) ReadPacket() ([]byte, error) {
+	packet, err := p.portal.ReadPacket()
+	if err != nil {
+		return nil, err
+	}
+
+	p.record(CAPTURE_DIRECTION_INCOMING, packet)
+	return packet, nil
+}
+
+func (p *CapturePortal) WritePacket(packet []byte) error {
+	if err := p.portal.WritePacket(packet); err != nil {
+		return err
+	}
+
+	p.record(CAPTURE_DIRECTION_OUTGOING, packet)
+	return nil
+}
+
+func (p *CapturePortal) record(direction CaptureDirection, packet []byte) {
+	p.capture.WriteRecord(CaptureRecord{
+		Timestamp: time.Now(),
+		Direction: direction,
+		EdgeID:    p.edgeID,
+		Packet:    packet,
+	})
+}
+
+type ReplayConfig struct {
+	// Filter returns true for the records written to the portal,
+	// all records are written when nil.
+	Filter func(record CaptureRecord) bool
+
+	// KeepTiming delays the writes by the time elapsed
+	// between the records when they were captured.
+	KeepTiming bool
+}
+
+// ReplayCapture writes the packets of the captured records to the portal
+// until the end of the capture, e.g. to reproduce the traffic which led
+// to the bug offline. It returns the number of the written packets.
+func ReplayCapture(capture *CaptureReader, portal directmq.Portal, config ReplayConfig) (int, error) {
+	replayed := 0
+	var previous time.Time
+
+	for {
+		record, err := capture.ReadRecord()
+		if errors.Is(err, io.EOF) {
+			return replayed, nil
+		}
+
+		if err != nil {
+			return replayed, err
+		}
+
+		if config.Filter != nil && !config.Filter(record) {
+			continue
+		}
+
+		if config.KeepTiming && !previous.IsZero() {
+			time.Sleep(record.Timestamp.Sub(previous))
+		}
+
+		previous = record.Timestamp
+
+		if err := portal.WritePacket(record.Packet); err != nil {
+			return replayed, err
+		}
+
+		replayed++
+	}
+}
@@ -0,0 +1,171 @@
+package dmqportals
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Capture", func() {
+	readAllRecords := func(data []byte) []CaptureRecord {
+		reader, err := NewCaptureReader(bytes.NewReader(data))
+		Expect(err).ToNot(HaveOccurred())
+
+		records := []CaptureRecord{}
+		for {
+			record, err := reader.ReadRecord()
+			if err == io.EOF {
+				return records
+			}
+
+			Expect(err).ToNot(HaveOccurred())
+			records = append(records, record)
+		}
+	}
+
+	It("should read the written records", func() {
+		output := new(bytes.Buffer)
+		writer, err := NewCaptureWriter(output)
+		Expect(err).ToNot(HaveOccurred())
+
+		written := []CaptureRecord{
+			{time.Unix(1, 2), CAPTURE_DIRECTION_INCOMING, "edge-a", []byte("first")},
+			{time.Unix(3, 4), CAPTURE_DIRECTION_OUTGOING, "", []byte{}},
+		}
+
+		for _, record := range written {
+			Expect(writer.WriteRecord(record)).To(Succeed())
+		}
+
+		records := readAllRecords(output.Bytes())
+		Expect(records).To(HaveLen(2))
+		for i, record := range records {
+			Expect(record.Timestamp.Equal(written[i].Timestamp)).To(BeTrue())
+			Expect(record.Direction).To(Equal(written[i].Direction))
+			Expect(record.EdgeID).To(Equal(written[i].EdgeID))
+			Expect(record.Packet).To(Equal(written[i].Packet))
+		}
+	})
+
+	It("should reject the invalid header", func() {
+		_, err := NewCaptureReader(bytes.NewReader([]byte("NOTCAP\x01")))
+		Expect(err).To(MatchError(ErrCaptureInvalidHeader))
+
+		_, err = NewCaptureReader(bytes.NewReader([]byte(CAPTURE_FILE_MAGIC + "\x02")))
+		Expect(err).To(MatchError(ErrCaptureInvalidHeader))
+	})
+
+	It("should detect the truncated capture", func() {
+		output := new(bytes.Buffer)
+		writer, err := NewCaptureWriter(output)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(writer.WriteRecord(CaptureRecord{time.Now(), CAPTURE_DIRECTION_INCOMING, "edge", []byte("packet")})).To(Succeed())
+
+		reader, err := NewCaptureReader(bytes.NewReader(output.Bytes()[:output.Len()-2]))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = reader.ReadRecord()
+		Expect(err).To(MatchError(io.ErrUnexpectedEOF))
+	})
+
+	It("should capture the packets in both directions", func() {
+		a, b := NewMemoryPipe()
+		defer a.Close()
+
+		output := new(bytes.Buffer)
+		writer, err := NewCaptureWriter(output)
+		Expect(err).ToNot(HaveOccurred())
+
+		portal := NewCapturePortal(a, writer, "edge-a")
+
+		Expect(portal.WritePacket([]byte("written"))).To(Succeed())
+		Expect(b.ReadPacket()).To(Equal([]byte("written")))
+
+		Expect(b.WritePacket([]byte("read"))).To(Succeed())
+		Expect(portal.ReadPacket()).To(Equal([]byte("read")))
+
+		records := readAllRecords(output.Bytes())
+		Expect(records).To(HaveLen(2))
+
+		Expect(records[0].Direction).To(Equal(CAPTURE_DIRECTION_OUTGOING))
+		Expect(records[0].Packet).To(Equal([]byte("written")))
+		Expect(records[1].Direction).To(Equal(CAPTURE_DIRECTION_INCOMING))
+		Expect(records[1].Packet).To(Equal([]byte("read")))
+
+		for _, record := range records {
+			Expect(record.EdgeID).To(Equal("edge-a"))
+		}
+	})
+
+	It("should replay the selected records into the portal", func() {
+		output := new(bytes.Buffer)
+		writer, err := NewCaptureWriter(output)
+		Expect(err).ToNot(HaveOccurred())
+
+		start := time.Now()
+		Expect(writer.WriteRecord(CaptureRecord{start, CAPTURE_DIRECTION_OUTGOING, "edge", []byte("first")})).To(Succeed())
+		Expect(writer.WriteRecord(CaptureRecord{start, CAPTURE_DIRECTION_INCOMING, "edge", []byte("skipped")})).To(Succeed())
+		Expect(writer.WriteRecord(CaptureRecord{start.Add(50 * time.Millisecond), CAPTURE_DIRECTION_OUTGOING, "edge", []byte("second")})).To(Succeed())
+
+		reader, err := NewCaptureReader(bytes.NewReader(output.Bytes()))
+		Expect(err).ToNot(HaveOccurred())
+
+		a, b := NewMemoryPipe()
+		defer a.Close()
+
+		began := time.Now()
+		replayed, err := ReplayCapture(reader, a, ReplayConfig{
+			Filter: func(record CaptureRecord) bool {
+				return record.Direction == CAPTURE_DIRECTION_OUTGOING
+			},
+			KeepTiming: true,
+		})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(replayed).To(Equal(2))
+		Expect(time.Since(began)).To(BeNumerically(">=", 50*time.Millisecond))
+
+		Expect(b.ReadPacket()).To(Equal([]byte("first")))
+		Expect(b.ReadPacket()).To(Equal([]byte("second")))
+	})
+
+	It("should capture the decodable traffic of the nodes", func() {
+		a, b := NewMemoryPipe()
+
+		output := new(bytes.Buffer)
+		writer, err := NewCaptureWriter(output)
+		Expect(err).ToNot(HaveOccurred())
+
+		first := newTestNode("first")
+		second := newTestNode("second")
+		defer second.CloseNode("test finished")
+
+		stopped := make(chan struct{})
+		go func() {
+			first.AddListeningEdge(NewCapturePortal(a, writer, "second"))
+			close(stopped)
+		}()
+
+		go second.AddConnectingEdge(b)
+
+		Eventually(first.GetBridgedNodeIDs).Should(ContainElement("second"))
+		first.CloseNode("test finished")
+		Eventually(stopped).Should(BeClosed())
+
+		types := []directmq.MessageType{}
+		for _, record := range readAllRecords(output.Bytes()) {
+			Expect(record.EdgeID).To(Equal("second"))
+
+			_, messageType, err := directmq.DecodeProtobufFrame(record.Packet, directmq.PROTOBUF_FORMAT_BINARY)
+			Expect(err).ToNot(HaveOccurred())
+			types = append(types, messageType)
+		}
+
+		Expect(types).To(ContainElements(directmq.MESSAGE_TYPE_INIT_CONNECTION, directmq.MESSAGE_TYPE_CONNECTION_ACCEPTED))
+	})
+})
@@ -0,0 +1,217 @@
+package dmqportals
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+)
+
+// ErrChaosPortalClosed is returned after the chaos portal
+// was suddenly closed by the injected fault.
+var ErrChaosPortalClosed = errors.New("chaos portal suddenly closed")
+
+type ChaosDirection int
+
+const (
+	CHAOS_DIRECTION_BOTH ChaosDirection = iota
+	CHAOS_DIRECTION_READ
+	CHAOS_DIRECTION_WRITE
+)
+
+type ChaosConfig struct {
+	// Seed of the random generator deciding about the faults,
+	// the same seed gives the same faults for the same packets.
+	Seed int64
+
+	// Direction of the packets affected by the faults.
+	Direction ChaosDirection
+
+	// Probability of dropping the packet, from 0 to 1.
+	DropRate float64
+
+	// Probability of passing the packet twice.
+	DuplicateRate float64
+
+	// Probability of flipping the random bit of the packet.
+	CorruptRate float64
+
+	// Probability of delaying the packet by the Delay.
+	DelayRate float64
+	Delay     time.Duration
+
+	// Probability of closing the wrapped portal instead of passing
+	// the packet, the following calls return ErrChaosPortalClosed.
+	CloseRate float64
+}
+
+// ChaosPortal injects the faults into the packets crossing
+// the wrapped portal, e.g. to test the behavior of the nodes
+// on the unreliable transport.
+type ChaosPortal struct {
+	portalDecorator
+
+	config ChaosConfig
+
+	mutex   sync.Mutex
+	random  *rand.Rand
+	pending [][]byte
+	closed  bool
+}
+
+var _ directmq.Portal = (*ChaosPortal)(nil)
+
+// chaosFaults are the faults drawn for the single packet.
+type chaosFaults struct {
+	drop      bool
+	duplicate bool
+	corrupt   bool
+	delay     bool
+	close     bool
+}
+
+func NewChaosPortal(portal directmq.Portal, config ChaosConfig) *ChaosPortal {
+	return &ChaosPortal{
+		portalDecorator: portalDecorator{portal},
+		config:          config,
+		random:          rand.New(rand.NewSource(config.Seed)),
+	}
+}
+
+// IsUnreliable reports the portal as unreliable when it may drop,
+// duplicate or corrupt the packets, delays keep the packets in order.
+func (p *ChaosPortal) IsUnreliable() bool {
+	faulty := p.config.DropRate > 0 || p.config.DuplicateRate > 0 || p.config.CorruptRate > 0
+	return faulty || p.portalDecorator.IsUnreliable()
+}
+
+func (p *ChaosPortal) ReadPacket() ([]byte, error) {
+	for {
+		p.mutex.Lock()
+		if p.closed {
+			p.mutex.Unlock()
+			return nil, ErrChaosPortalClosed
+		}
+
+		if len(p.pending) > 0 {
+			packet := p.pending[0]
+			p.pending = p.pending[1:]
+			p.mutex.Unlock()
+			return packet, nil
+		}
+
+		p.mutex.Unlock()
+
+		packet, err := p.portal.ReadPacket()
+		if err != nil {
+			if p.isClosed() {
+				return nil, ErrChaosPortalClosed
+			}
+
+			return nil, err
+		}
+
+		if p.config.Direction == CHAOS_DIRECTION_WRITE {
+			return packet, nil
+		}
+
+		faults, packet := p.draw(packet)
+		if faults.close {
+			return nil, p.suddenlyClose()
+		}
+
+		if faults.delay {
+			time.Sleep(p.config.Delay)
+		}
+
+		if faults.drop {
+			continue
+		}
+
+		if faults.duplicate {
+			p.mutex.Lock()
+			p.pending = append(p.pending, packet)
+			p.mutex.Unlock()
+		}
+
+		return packet, nil
+	}
+}
+
+func (p *ChaosPortal) WritePacket(packet []byte) error {
+	if p.isClosed() {
+		return ErrChaosPortalClosed
+	}
+
+	if p.config.Direction == CHAOS_DIRECTION_READ {
+		return p.portal.WritePacket(packet)
+	}
+
+	faults, packet := p.draw(packet)
+	if faults.close {
+		return p.suddenlyClose()
+	}
+
+	if faults.delay {
+		time.Sleep(p.config.Delay)
+	}
+
+	if faults.drop {
+		return nil
+	}
+
+	if err := p.portal.WritePacket(packet); err != nil {
+		return err
+	}
+
+	if faults.duplicate {
+		return p.portal.WritePacket(packet)
+	}
+
+	return nil
+}
+
+// draw decides about the faults of the packet, the corrupted
+// packet is the copy, so the caller buffer is never modified.
+func (p *ChaosPortal) draw(packet []byte) (chaosFaults, []byte) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	faults := chaosFaults{
+		close:     p.random.Float64() < p.config.CloseRate,
+		drop:      p.random.Float64() < p.config.DropRate,
+		duplicate: p.random.Float64() < p.config.DuplicateRate,
+		corrupt:   p.random.Float64() < p.config.CorruptRate,
+		delay:     p.random.Float64() < p.config.DelayRate,
+	}
+
+	if faults.corrupt && len(packet) > 0 {
+		corrupted := make([]byte, len(packet))
+		copy(corrupted, packet)
+
+		bit := p.random.Intn(len(packet) * 8)
+		corrupted[bit/8] ^= 1 << (bit % 8)
+		packet = corrupted
+	}
+
+	return faults, packet
+}
+
+func (p *ChaosPortal) suddenlyClose() error {
+	p.mutex.Lock()
+	p.closed = true
+	p.pending = nil
+	p.mutex.Unlock()
+
+	p.portal.Close()
+	return ErrChaosPortalClosed
+}
+
+func (p *ChaosPortal) isClosed() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.closed
+}
@@ -0,0 +1,143 @@
+package dmqportals
+
+import (
+	"fmt"
+	"time"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ChaosPortal", func() {
+	// writeThrough writes the packets through the chaos portal
+	// and returns the ones which reached the other end of the pipe
+	writeThrough := func(config ChaosConfig, count int) []string {
+		a, b := NewMemoryPipeWithConfig(MemoryPipeConfig{BufferSize: count*2 + 1})
+		defer a.Close()
+
+		portal := NewChaosPortal(a, config)
+		for i := 0; i < count; i++ {
+			Expect(portal.WritePacket([]byte(fmt.Sprintf("packet %03d", i)))).To(Succeed())
+		}
+
+		// the marker bypasses the chaos, so it always arrives
+		Expect(a.WritePacket([]byte("end"))).To(Succeed())
+
+		received := []string{}
+		for {
+			packet, err := b.ReadPacket()
+			Expect(err).ToNot(HaveOccurred())
+			if string(packet) == "end" {
+				return received
+			}
+
+			received = append(received, string(packet))
+		}
+	}
+
+	It("should drop the packets", func() {
+		received := writeThrough(ChaosConfig{Seed: 1, DropRate: 0.5}, 100)
+		Expect(len(received)).To(BeNumerically("~", 50, 20))
+	})
+
+	It("should duplicate the packets", func() {
+		received := writeThrough(ChaosConfig{DuplicateRate: 1}, 3)
+		Expect(received).To(Equal([]string{
+			"packet 000", "packet 000",
+			"packet 001", "packet 001",
+			"packet 002", "packet 002",
+		}))
+	})
+
+	It("should corrupt the copy of the packet", func() {
+		a, b := NewMemoryPipe()
+		defer a.Close()
+
+		portal := NewChaosPortal(a, ChaosConfig{CorruptRate: 1})
+
+		packet := []byte("packet")
+		Expect(portal.WritePacket(packet)).To(Succeed())
+		Expect(packet).To(Equal([]byte("packet")))
+
+		corrupted, err := b.ReadPacket()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(corrupted).To(HaveLen(len(packet)))
+		Expect(corrupted).ToNot(Equal(packet))
+	})
+
+	It("should delay the packets", func() {
+		a, b := NewMemoryPipe()
+		defer a.Close()
+
+		portal := NewChaosPortal(b, ChaosConfig{DelayRate: 1, Delay: 50 * time.Millisecond})
+		Expect(a.WritePacket([]byte("packet"))).To(Succeed())
+
+		start := time.Now()
+		Expect(portal.ReadPacket()).To(Equal([]byte("packet")))
+		Expect(time.Since(start)).To(BeNumerically(">=", 50*time.Millisecond))
+	})
+
+	It("should suddenly close the wrapped portal", func() {
+		a, b := NewMemoryPipe()
+
+		portal := NewChaosPortal(b, ChaosConfig{CloseRate: 1})
+		Expect(a.WritePacket([]byte("packet"))).To(Succeed())
+
+		_, err := portal.ReadPacket()
+		Expect(err).To(MatchError(ErrChaosPortalClosed))
+		Expect(portal.WritePacket([]byte("packet"))).To(MatchError(ErrChaosPortalClosed))
+
+		_, err = a.ReadPacket()
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should affect only the configured direction", func() {
+		a, b := NewMemoryPipe()
+		defer a.Close()
+
+		portal := NewChaosPortal(a, ChaosConfig{Direction: CHAOS_DIRECTION_READ, DropRate: 1})
+
+		Expect(portal.WritePacket([]byte("written"))).To(Succeed())
+		Expect(b.ReadPacket()).To(Equal([]byte("written")))
+
+		Expect(b.WritePacket([]byte("dropped"))).To(Succeed())
+		read := make(chan []byte, 1)
+		go func() {
+			packet, _ := portal.ReadPacket()
+			read <- packet
+		}()
+
+		Consistently(read, 100*time.Millisecond).ShouldNot(Receive())
+	})
+
+	It("should inject the same faults for the same seed", func() {
+		config := ChaosConfig{Seed: 42, DropRate: 0.3, DuplicateRate: 0.3, CorruptRate: 0.3}
+		Expect(writeThrough(config, 50)).To(Equal(writeThrough(config, 50)))
+	})
+
+	It("should keep the nodes connected over the lossless chaos", func() {
+		a, b := NewMemoryPipe()
+
+		first := newTestNode("first")
+		second := newTestNode("second")
+		defer first.CloseNode("test finished")
+		defer second.CloseNode("test finished")
+
+		go first.AddListeningEdge(NewChaosPortal(a, ChaosConfig{Seed: 7, DelayRate: 0.5, Delay: time.Millisecond}))
+		go second.AddConnectingEdge(NewCountingPortal(b))
+
+		Eventually(first.GetBridgedNodeIDs).Should(ContainElement("second"))
+
+		received := make(chan []byte, 16)
+		second.Subscribe("chaos/topic", func(payload []byte) { received <- payload })
+
+		Eventually(func() int {
+			first.Publish("chaos/topic", []byte("payload"), directmq.AT_MOST_ONCE)
+			return len(received)
+		}).Should(BeNumerically(">", 0))
+
+		Expect(<-received).To(Equal([]byte("payload")))
+	})
+})
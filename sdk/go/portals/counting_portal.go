@@ -0,0 +1,69 @@
+package dmqportals
+
+import (
+	"sync/atomic"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+)
+
+type PortalCounters struct {
+	PacketsRead    uint64
+	PacketsWritten uint64
+	BytesRead      uint64
+	BytesWritten   uint64
+	ReadErrors     uint64
+	WriteErrors    uint64
+}
+
+// CountingPortal counts the packets and bytes crossing the wrapped portal,
+// the counters can be read from any goroutine.
+type CountingPortal struct {
+	portalDecorator
+
+	packetsRead    atomic.Uint64
+	packetsWritten atomic.Uint64
+	bytesRead      atomic.Uint64
+	bytesWritten   atomic.Uint64
+	readErrors     atomic.Uint64
+	writeErrors    atomic.Uint64
+}
+
+var _ directmq.Portal = (*CountingPortal)(nil)
+
+func NewCountingPortal(portal directmq.Portal) *CountingPortal {
+	return &CountingPortal{portalDecorator: portalDecorator{portal}}
+}
+
+func (p *CountingPortal) ReadPacket() ([]byte, error) {
+	packet, err := p.portal.ReadPacket()
+	if err != nil {
+		p.readErrors.Add(1)
+		return nil, err
+	}
+
+	p.packetsRead.Add(1)
+	p.bytesRead.Add(uint64(len(packet)))
+	return packet, nil
+}
+
+func (p *CountingPortal) WritePacket(packet []byte) error {
+	if err := p.portal.WritePacket(packet); err != nil {
+		p.writeErrors.Add(1)
+		return err
+	}
+
+	p.packetsWritten.Add(1)
+	p.bytesWritten.Add(uint64(len(packet)))
+	return nil
+}
+
+func (p *CountingPortal) Counters() PortalCounters {
+	return PortalCounters{
+		PacketsRead:    p.packetsRead.Load(),
+		PacketsWritten: p.packetsWritten.Load(),
+		BytesRead:      p.bytesRead.Load(),
+		BytesWritten:   p.bytesWritten.Load(),
+		ReadErrors:     p.readErrors.Load(),
+		WriteErrors:    p.writeErrors.Load(),
+	}
+}
@@ -0,0 +1,63 @@
+package dmqportals
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CountingPortal", func() {
+	It("should count the packets, bytes and errors", func() {
+		a, b := NewMemoryPipe()
+
+		portal := NewCountingPortal(a)
+
+		Expect(portal.WritePacket([]byte("abc"))).To(Succeed())
+		Expect(portal.WritePacket([]byte("de"))).To(Succeed())
+		Expect(b.WritePacket([]byte("fghi"))).To(Succeed())
+
+		Expect(portal.ReadPacket()).To(Equal([]byte("fghi")))
+
+		Expect(portal.Close()).To(Succeed())
+		_, err := portal.ReadPacket()
+		Expect(err).To(HaveOccurred())
+		Expect(portal.WritePacket([]byte("j"))).ToNot(Succeed())
+
+		Expect(portal.Counters()).To(Equal(PortalCounters{
+			PacketsRead:    1,
+			PacketsWritten: 2,
+			BytesRead:      4,
+			BytesWritten:   5,
+			ReadErrors:     1,
+			WriteErrors:    1,
+		}))
+	})
+
+	It("should be composable with the other decorators", func() {
+		a, b := NewMemoryPipe()
+		defer a.Close()
+
+		inner := NewCountingPortal(a)
+		outer := NewCountingPortal(NewChaosPortal(inner, ChaosConfig{DuplicateRate: 1}))
+
+		Expect(outer.WritePacket([]byte("packet"))).To(Succeed())
+
+		Expect(b.ReadPacket()).To(Equal([]byte("packet")))
+		Expect(b.ReadPacket()).To(Equal([]byte("packet")))
+
+		Expect(outer.Counters().PacketsWritten).To(Equal(uint64(1)))
+		Expect(inner.Counters().PacketsWritten).To(Equal(uint64(2)))
+		Expect(outer.Unwrap().(*ChaosPortal).Unwrap()).To(BeIdenticalTo(inner))
+	})
+
+	It("should report the unreliable portal through the other decorators", func() {
+		lossy, _ := NewMemoryPipeWithConfig(MemoryPipeConfig{LossRate: 0.1})
+		defer lossy.Close()
+
+		reliable, _ := NewMemoryPipe()
+		defer reliable.Close()
+
+		Expect(NewCountingPortal(NewChaosPortal(lossy, ChaosConfig{})).IsUnreliable()).To(BeTrue())
+		Expect(NewCountingPortal(NewChaosPortal(reliable, ChaosConfig{})).IsUnreliable()).To(BeFalse())
+		Expect(NewCountingPortal(NewChaosPortal(reliable, ChaosConfig{DropRate: 0.1})).IsUnreliable()).To(BeTrue())
+	})
+})
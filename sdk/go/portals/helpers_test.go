@@ -0,0 +1,67 @@
+package dmqportals
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"time"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+
+	. "github.com/onsi/gomega"
+)
+
+func newTestNode(id string) directmq.NetworkNode {
+	return directmq.NewNetworkNode(directmq.NetworkNodeConfig{
+		HostID:  id,
+		HostTTL: directmq.DEFAULT_TTL,
+	}, directmq.NewProtobufBinaryProtocol())
+}
+
+// getFreeTestAddress returns the loopback address with the port
+// which was free at the moment of the call.
+func getFreeTestAddress() string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+	defer listener.Close()
+
+	return listener.Addr().String()
+}
+
+// newTestTLSConfigs returns the server and client configs
+// trusting the self signed certificate issued for 127.0.0.1.
+func newTestTLSConfigs() (server *tls.Config, client *tls.Config) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "directmq test"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).ToNot(HaveOccurred())
+
+	certificate, err := x509.ParseCertificate(der)
+	Expect(err).ToNot(HaveOccurred())
+
+	pool := x509.NewCertPool()
+	pool.AddCert(certificate)
+
+	server = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	client = &tls.Config{RootCAs: pool}
+	return
+}
@@ -0,0 +1,416 @@
+package dmqportals
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+)
+
+// Largest packet accepted by the HTTP portal in both directions.
+const HTTP_MAX_PACKET_SIZE = 16 * 1024 * 1024
+
+const DEFAULT_HTTP_MAX_BATCH_PACKETS = 256
+
+const (
+	httpSessionParameter       = "session"
+	httpEventStreamContentType = "text/event-stream"
+)
+
+var ErrHTTPPacketTooLarge = errors.New("http packet exceeds the maximum packet size")
+
+type HTTPDownstream int
+
+const (
+	// Packets for the client are received as Server-Sent Events
+	// over the single long lived GET request.
+	HTTP_DOWNSTREAM_SSE HTTPDownstream = iota
+
+	// Packets for the client are received in batches, one batch
+	// per GET request, for proxies buffering the responses.
+	HTTP_DOWNSTREAM_LONG_POLL
+)
+
+type HTTPClientConfig struct {
+	// http.DefaultClient is used when nil, the client
+	// should not have the timeout shorter than the poll
+	// timeout of the server or it should not have it at all.
+	Client *http.Client
+
+	Downstream HTTPDownstream
+
+	// Number of packets sent in the single POST request,
+	// writes block when the batch is full.
+	// DEFAULT_HTTP_MAX_BATCH_PACKETS is used when zero.
+	MaxBatchPackets int
+}
+
+// HTTPClientPortal is the client side of the HTTP portal session,
+// it works through the proxies and firewalls blocking the websockets.
+// Packets written to the portal are batched and sent in the POST
+// requests, one request at a time so they arrive in order.
+// Any failed request closes the portal.
+type HTTPClientPortal struct {
+	endpoint string
+	config   HTTPClientConfig
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	incoming chan []byte
+
+	mutex   sync.Mutex
+	space   *sync.Cond
+	pending [][]byte
+	flush   chan struct{}
+	flushed chan struct{}
+
+	err        error
+	closed     chan struct{}
+	closeOnce  sync.Once
+	deleteOnce sync.Once
+}
+
+var _ directmq.Portal = (*HTTPClientPortal)(nil)
+
+// HTTPConnect opens the session on the HTTP server.
+func HTTPConnect(u *url.URL, config HTTPClientConfig) (*HTTPClientPortal, error) {
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+
+	if config.MaxBatchPackets <= 0 {
+		config.MaxBatchPackets = DEFAULT_HTTP_MAX_BATCH_PACKETS
+	}
+
+	response, err := config.Client.Post(u.String(), "text/plain", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("failed to open the http session: %s", response.Status)
+	}
+
+	sessionID, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := *u
+	query := endpoint.Query()
+	query.Set(httpSessionParameter, string(sessionID))
+	endpoint.RawQuery = query.Encode()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	portal := &HTTPClientPortal{
+		endpoint: endpoint.String(),
+		config:   config,
+		ctx:      ctx,
+		cancel:   cancel,
+		incoming: make(chan []byte, DEFAULT_HTTP_INCOMING_QUEUE_SIZE),
+		pending:  make([][]byte, 0),
+		flush:    make(chan struct{}, 1),
+		flushed:  make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+
+	portal.space = sync.NewCond(&portal.mutex)
+
+	go portal.sendLoop()
+
+	if config.Downstream == HTTP_DOWNSTREAM_LONG_POLL {
+		go portal.pollLoop()
+	} else {
+		go portal.eventsLoop()
+	}
+
+	return portal, nil
+}
+
+func (p *HTTPClientPortal) ReadPacket() ([]byte, error) {
+	select {
+	case packet := <-p.incoming:
+		return packet, nil
+	case <-p.closed:
+		// packets received right before the server closed
+		// the session are still returned
+		select {
+		case packet := <-p.incoming:
+			return packet, nil
+		default:
+			return nil, p.err
+		}
+	}
+}
+
+func (p *HTTPClientPortal) WritePacket(packet []byte) error {
+	if len(packet) > HTTP_MAX_PACKET_SIZE {
+		return ErrHTTPPacketTooLarge
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for len(p.pending) >= p.config.MaxBatchPackets && !p.isClosed() {
+		p.space.Wait()
+	}
+
+	if p.isClosed() {
+		return p.err
+	}
+
+	p.pending = append(p.pending, append([]byte{}, packet...))
+
+	select {
+	case p.flush <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Close sends the pending packets and closes the session.
+func (p *HTTPClientPortal) Close() (err error) {
+	p.fail(net.ErrClosed)
+
+	<-p.flushed
+
+	p.deleteOnce.Do(func() {
+		var request *http.Request
+		request, err = http.NewRequest(http.MethodDelete, p.endpoint, nil)
+		if err != nil {
+			return
+		}
+
+		var response *http.Response
+		response, err = p.config.Client.Do(request)
+		if err != nil {
+			return
+		}
+
+		err = response.Body.Close()
+	})
+
+	return err
+}
+
+// fail closes the portal with the error returned
+// by the next reads and writes, the first error wins.
+func (p *HTTPClientPortal) fail(err error) {
+	p.closeOnce.Do(func() {
+		p.mutex.Lock()
+		p.err = err
+		close(p.closed)
+		p.space.Broadcast()
+		p.mutex.Unlock()
+
+		p.cancel()
+	})
+}
+
+func (p *HTTPClientPortal) isClosed() bool {
+	select {
+	case <-p.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *HTTPClientPortal) sendLoop() {
+	defer close(p.flushed)
+
+	for {
+		select {
+		case <-p.flush:
+		case <-p.closed:
+		}
+
+		p.mutex.Lock()
+		batch := p.pending
+		p.pending = make([][]byte, 0)
+		p.space.Broadcast()
+		closed := p.isClosed()
+		p.mutex.Unlock()
+
+		if len(batch) > 0 {
+			if err := p.send(batch); err != nil {
+				p.fail(err)
+				return
+			}
+		}
+
+		if closed {
+			return
+		}
+	}
+}
+
+func (p *HTTPClientPortal) send(batch [][]byte) error {
+	body := make([]byte, 0)
+	for _, packet := range batch {
+		body = appendLengthPrefixedPacket(body, packet)
+	}
+
+	// pending packets are sent even after the portal
+	// was closed, so the request is not canceled with it
+	request, err := http.NewRequest(http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/octet-stream")
+
+	response, err := p.config.Client.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+	return checkHTTPResponse(response)
+}
+
+func (p *HTTPClientPortal) pollLoop() {
+	for {
+		request, err := http.NewRequestWithContext(p.ctx, http.MethodGet, p.endpoint, nil)
+		if err != nil {
+			p.fail(err)
+			return
+		}
+
+		response, err := p.config.Client.Do(request)
+		if err != nil {
+			p.fail(err)
+			return
+		}
+
+		err = checkHTTPResponse(response)
+		if err == nil && response.StatusCode == http.StatusOK {
+			err = p.receiveBatch(bufio.NewReader(response.Body))
+		}
+
+		response.Body.Close()
+
+		if err != nil {
+			p.fail(err)
+			return
+		}
+	}
+}
+
+func (p *HTTPClientPortal) receiveBatch(reader *bufio.Reader) error {
+	for {
+		packet, tooLarge, err := readLengthPrefixedPacket(reader, HTTP_MAX_PACKET_SIZE)
+		if err == io.EOF {
+			return nil
+		}
+
+		if tooLarge {
+			return ErrHTTPPacketTooLarge
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !p.deliver(packet) {
+			return net.ErrClosed
+		}
+	}
+}
+
+func (p *HTTPClientPortal) eventsLoop() {
+	request, err := http.NewRequestWithContext(p.ctx, http.MethodGet, p.endpoint, nil)
+	if err != nil {
+		p.fail(err)
+		return
+	}
+
+	request.Header.Set("Accept", httpEventStreamContentType)
+
+	response, err := p.config.Client.Do(request)
+	if err != nil {
+		p.fail(err)
+		return
+	}
+
+	defer response.Body.Close()
+
+	if err := checkHTTPResponse(response); err != nil {
+		p.fail(err)
+		return
+	}
+
+	reader := bufio.NewReaderSize(response.Body, 64*1024)
+	data := ""
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// the server ends the stream when the session is closed
+			p.fail(io.EOF)
+			return
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case line == "":
+			if data == "" {
+				continue
+			}
+
+			packet, err := base64.StdEncoding.DecodeString(data)
+			if err != nil {
+				p.fail(err)
+				return
+			}
+
+			data = ""
+			if !p.deliver(packet) {
+				return
+			}
+
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " ")
+
+		default:
+			// comments and fields other than data are ignored
+		}
+	}
+}
+
+func (p *HTTPClientPortal) deliver(packet []byte) bool {
+	select {
+	case p.incoming <- packet:
+		return true
+	case <-p.closed:
+		return false
+	}
+}
+
+func checkHTTPResponse(response *http.Response) error {
+	switch {
+	// the session was closed or it expired and was already forgotten
+	case response.StatusCode == http.StatusGone || response.StatusCode == http.StatusNotFound:
+		return io.EOF
+	case response.StatusCode >= 300:
+		return fmt.Errorf("http portal request failed: %s", response.Status)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,452 @@
+package dmqportals
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+)
+
+const (
+	DEFAULT_HTTP_SESSION_TIMEOUT     = 60 * time.Second
+	DEFAULT_HTTP_POLL_TIMEOUT        = 25 * time.Second
+	DEFAULT_HTTP_MAX_QUEUED_PACKETS  = 1024
+	DEFAULT_HTTP_SSE_KEEPALIVE       = 15 * time.Second
+	DEFAULT_HTTP_INCOMING_QUEUE_SIZE = 256
+)
+
+var ErrHTTPSessionQueueFull = errors.New("http session queue is full, the client does not poll fast enough")
+
+type HTTPServerConfig struct {
+	// Time after which the session without any pending
+	// request is closed. DEFAULT_HTTP_SESSION_TIMEOUT
+	// is used when zero.
+	SessionTimeout time.Duration
+
+	// Time after which the long poll request without any packets
+	// is answered with no content. DEFAULT_HTTP_POLL_TIMEOUT
+	// is used when zero.
+	PollTimeout time.Duration
+
+	// Number of packets queued for the client, writes fail when
+	// the queue is full. DEFAULT_HTTP_MAX_QUEUED_PACKETS
+	// is used when zero.
+	MaxQueuedPackets int
+}
+
+// HTTPServer accepts the sessions of the HTTP portal clients,
+// every session is added to the node as the listening edge.
+// It is the http.Handler, so it can be mounted on any path
+// of the existing http.ServeMux.
+//
+// The session is opened with the POST request without the session
+// query parameter, the response body contains the session ID.
+// Requests with the session ID:
+//   - GET receives the packets for the client, either as Server-Sent
+//     Events with base64 encoded packets, when the client accepts
+//     text/event-stream, or as the long poll returning the batch
+//     of length prefixed packets,
+//   - POST sends the batch of length prefixed packets to the server,
+//   - DELETE closes the session.
+type HTTPServer struct {
+	config HTTPServerConfig
+	accept func(*HTTPServerPortal)
+
+	mutex    sync.Mutex
+	sessions map[string]*HTTPServerPortal
+}
+
+var _ http.Handler = (*HTTPServer)(nil)
+
+func NewHTTPServer(dmq directmq.NetworkNode, config HTTPServerConfig) *HTTPServer {
+	return newHTTPServer(config, func(portal *HTTPServerPortal) {
+		go dmq.AddListeningEdge(portal)
+	})
+}
+
+func newHTTPServer(config HTTPServerConfig, accept func(*HTTPServerPortal)) *HTTPServer {
+	if config.SessionTimeout <= 0 {
+		config.SessionTimeout = DEFAULT_HTTP_SESSION_TIMEOUT
+	}
+
+	if config.PollTimeout <= 0 {
+		config.PollTimeout = DEFAULT_HTTP_POLL_TIMEOUT
+	}
+
+	if config.MaxQueuedPackets <= 0 {
+		config.MaxQueuedPackets = DEFAULT_HTTP_MAX_QUEUED_PACKETS
+	}
+
+	return &HTTPServer{
+		config:   config,
+		accept:   accept,
+		sessions: make(map[string]*HTTPServerPortal),
+	}
+}
+
+// HTTPListen serves the HTTP portal on the path of the URL
+// until the context is done, the same way as WebsocketListen.
+func HTTPListen(u *url.URL, dmq directmq.NetworkNode, ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.Handle(u.Path, NewHTTPServer(dmq, HTTPServerConfig{}))
+
+	server := &http.Server{
+		Addr:    u.Host,
+		Handler: mux,
+	}
+
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	err := server.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *HTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.URL.Query().Get(httpSessionParameter)
+
+	if sessionID == "" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "session is required", http.StatusBadRequest)
+			return
+		}
+
+		s.openSession(w)
+		return
+	}
+
+	session := s.findSession(sessionID)
+	if session == nil {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+
+	session.beginRequest()
+	defer session.endRequest()
+
+	switch r.Method {
+	case http.MethodGet:
+		if r.Header.Get("Accept") == httpEventStreamContentType {
+			session.streamEvents(w, r)
+		} else {
+			session.poll(w, r, s.config.PollTimeout)
+		}
+
+	case http.MethodPost:
+		session.receive(w, r)
+
+	case http.MethodDelete:
+		session.Close()
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Sessions returns the number of the open sessions.
+func (s *HTTPServer) Sessions() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.sessions)
+}
+
+func (s *HTTPServer) openSession(w http.ResponseWriter) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		http.Error(w, "failed to generate the session ID", http.StatusInternalServerError)
+		return
+	}
+
+	session := newHTTPServerPortal(hex.EncodeToString(id), s)
+
+	s.mutex.Lock()
+	s.sessions[session.id] = session
+	s.mutex.Unlock()
+
+	s.accept(session)
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusCreated)
+	io.WriteString(w, session.id)
+}
+
+func (s *HTTPServer) findSession(id string) *HTTPServerPortal {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.sessions[id]
+}
+
+func (s *HTTPServer) removeSession(id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.sessions, id)
+}
+
+// HTTPServerPortal is the server side of the HTTP portal session.
+type HTTPServerPortal struct {
+	id     string
+	server *HTTPServer
+
+	incoming chan []byte
+
+	mutex          sync.Mutex
+	outgoing       [][]byte
+	notifier       chan struct{}
+	activeRequests int
+	expiration     *time.Timer
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+var _ directmq.Portal = (*HTTPServerPortal)(nil)
+
+func newHTTPServerPortal(id string, server *HTTPServer) *HTTPServerPortal {
+	session := &HTTPServerPortal{
+		id:       id,
+		server:   server,
+		incoming: make(chan []byte, DEFAULT_HTTP_INCOMING_QUEUE_SIZE),
+		outgoing: make([][]byte, 0),
+		notifier: make(chan struct{}, 1),
+		closed:   make(chan struct{}),
+	}
+
+	session.expiration = time.AfterFunc(server.config.SessionTimeout, func() {
+		session.Close()
+		server.removeSession(id)
+	})
+
+	return session
+}
+
+// SessionID returns the ID of the session.
+func (p *HTTPServerPortal) SessionID() string {
+	return p.id
+}
+
+func (p *HTTPServerPortal) ReadPacket() ([]byte, error) {
+	select {
+	case packet := <-p.incoming:
+		return packet, nil
+	case <-p.closed:
+		select {
+		case packet := <-p.incoming:
+			return packet, nil
+		default:
+			return nil, io.EOF
+		}
+	}
+}
+
+func (p *HTTPServerPortal) WritePacket(packet []byte) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	select {
+	case <-p.closed:
+		return io.ErrClosedPipe
+	default:
+	}
+
+	if len(p.outgoing) >= p.server.config.MaxQueuedPackets {
+		return ErrHTTPSessionQueueFull
+	}
+
+	p.outgoing = append(p.outgoing, append([]byte{}, packet...))
+
+	select {
+	case p.notifier <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Close closes the session, the packets written before are still
+// delivered to the client, so the session stays registered
+// until they are taken or until it expires.
+func (p *HTTPServerPortal) Close() error {
+	p.closeOnce.Do(func() {
+		p.mutex.Lock()
+		close(p.closed)
+		drained := len(p.outgoing) == 0
+		p.mutex.Unlock()
+
+		if drained {
+			p.expiration.Stop()
+			p.server.removeSession(p.id)
+		}
+	})
+
+	return nil
+}
+
+func (p *HTTPServerPortal) isClosed() bool {
+	select {
+	case <-p.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// The session expires only when there is no pending request,
+// so the open event stream keeps the session alive.
+func (p *HTTPServerPortal) beginRequest() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.activeRequests++
+	p.expiration.Stop()
+}
+
+func (p *HTTPServerPortal) endRequest() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.activeRequests--
+	if p.activeRequests == 0 {
+		p.expiration.Reset(p.server.config.SessionTimeout)
+	}
+}
+
+// take returns the queued packets and whether the session
+// was closed before taking them.
+func (p *HTTPServerPortal) take() ([][]byte, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	closed := p.isClosed()
+	packets := p.outgoing
+	p.outgoing = make([][]byte, 0)
+
+	if closed {
+		p.server.removeSession(p.id)
+	}
+
+	return packets, closed
+}
+
+func (p *HTTPServerPortal) poll(w http.ResponseWriter, r *http.Request, timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		packets, closed := p.take()
+
+		if len(packets) > 0 {
+			frame := make([]byte, 0)
+			for _, packet := range packets {
+				frame = appendLengthPrefixedPacket(frame, packet)
+			}
+
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(frame)
+			return
+		}
+
+		if closed {
+			http.Error(w, "session closed", http.StatusGone)
+			return
+		}
+
+		select {
+		case <-p.notifier:
+		case <-p.closed:
+		case <-timer.C:
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (p *HTTPServerPortal) streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", httpEventStreamContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(DEFAULT_HTTP_SSE_KEEPALIVE)
+	defer keepalive.Stop()
+
+	for {
+		packets, closed := p.take()
+
+		for _, packet := range packets {
+			io.WriteString(w, "data: "+base64.StdEncoding.EncodeToString(packet)+"\n\n")
+		}
+
+		flusher.Flush()
+
+		if closed {
+			return
+		}
+
+		select {
+		case <-p.notifier:
+		case <-p.closed:
+		case <-keepalive.C:
+			io.WriteString(w, ": keepalive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (p *HTTPServerPortal) receive(w http.ResponseWriter, r *http.Request) {
+	reader := bufio.NewReader(r.Body)
+
+	for {
+		packet, tooLarge, err := readLengthPrefixedPacket(reader, HTTP_MAX_PACKET_SIZE)
+		if err == io.EOF {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if tooLarge {
+			http.Error(w, ErrHTTPPacketTooLarge.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if err != nil {
+			http.Error(w, "malformed batch", http.StatusBadRequest)
+			return
+		}
+
+		select {
+		case p.incoming <- packet:
+		case <-p.closed:
+			http.Error(w, "session closed", http.StatusGone)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package dmqportals
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HTTPPortal", func() {
+	var server *HTTPServer
+	var httpServer *httptest.Server
+	var endpoint *url.URL
+	var node directmq.NetworkNode
+	var sessions chan *HTTPServerPortal
+
+	mount := func(handler *HTTPServer) {
+		server = handler
+
+		mux := http.NewServeMux()
+		mux.Handle("/dmq", server)
+		httpServer = httptest.NewServer(mux)
+
+		var err error
+		endpoint, err = url.Parse(httpServer.URL + "/dmq")
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	serveNode := func(config HTTPServerConfig) {
+		node = newTestNode("server")
+		mount(NewHTTPServer(node, config))
+	}
+
+	// serve accepts the sessions without the node,
+	// so the tests can use the server portals directly
+	serve := func(config HTTPServerConfig) {
+		sessions = make(chan *HTTPServerPortal, 1)
+		mount(newHTTPServer(config, func(portal *HTTPServerPortal) { sessions <- portal }))
+	}
+
+	AfterEach(func() {
+		httpServer.Close()
+	})
+
+	for _, downstream := range []HTTPDownstream{HTTP_DOWNSTREAM_SSE, HTTP_DOWNSTREAM_LONG_POLL} {
+		downstream := downstream
+		name := map[HTTPDownstream]string{HTTP_DOWNSTREAM_SSE: "server-sent events", HTTP_DOWNSTREAM_LONG_POLL: "long polling"}[downstream]
+
+		It("should bridge the nodes using "+name, func() {
+			serveNode(HTTPServerConfig{PollTimeout: 100 * time.Millisecond})
+			defer node.CloseNode("test finished")
+
+			client := newTestNode("client")
+			defer client.CloseNode("test finished")
+
+			portal, err := HTTPConnect(endpoint, HTTPClientConfig{Downstream: downstream})
+			Expect(err).ToNot(HaveOccurred())
+			go client.AddConnectingEdge(portal)
+
+			Eventually(node.GetBridgedNodeIDs).Should(Equal([]string{"client"}))
+			Eventually(client.GetBridgedNodeIDs).Should(Equal([]string{"server"}))
+
+			received := make(chan []byte, 16)
+			node.Subscribe("topic", func(payload []byte) { received <- payload })
+
+			Eventually(func() int {
+				client.Publish("topic", []byte("payload"), directmq.AT_MOST_ONCE)
+				return len(received)
+			}).Should(BeNumerically(">", 0))
+
+			Expect(<-received).To(Equal([]byte("payload")))
+		})
+
+		It("should pass the packets in order in both directions using "+name, func() {
+			serve(HTTPServerConfig{PollTimeout: 100 * time.Millisecond})
+
+			client, err := HTTPConnect(endpoint, HTTPClientConfig{Downstream: downstream, MaxBatchPackets: 4})
+			Expect(err).ToNot(HaveOccurred())
+			defer client.Close()
+
+			var session *HTTPServerPortal
+			Eventually(sessions).Should(Receive(&session))
+
+			go func() {
+				for i := byte(0); i < 20; i++ {
+					client.WritePacket([]byte{i})
+					session.WritePacket([]byte{i})
+				}
+			}()
+
+			for i := byte(0); i < 20; i++ {
+				Expect(client.ReadPacket()).To(Equal([]byte{i}))
+				Expect(session.ReadPacket()).To(Equal([]byte{i}))
+			}
+		})
+
+		It("should deliver the last packets and propagate the close of the session using "+name, func() {
+			serve(HTTPServerConfig{PollTimeout: 100 * time.Millisecond})
+
+			client, err := HTTPConnect(endpoint, HTTPClientConfig{Downstream: downstream})
+			Expect(err).ToNot(HaveOccurred())
+			defer client.Close()
+
+			var session *HTTPServerPortal
+			Eventually(sessions).Should(Receive(&session))
+
+			Expect(session.WritePacket([]byte("goodbye"))).To(Succeed())
+			Expect(session.Close()).To(Succeed())
+
+			Expect(client.ReadPacket()).To(Equal([]byte("goodbye")))
+
+			_, err = client.ReadPacket()
+			Expect(err).To(MatchError(io.EOF))
+		})
+	}
+
+	It("should send the pending packets and close the session when the client is closed", func() {
+		serve(HTTPServerConfig{})
+
+		client, err := HTTPConnect(endpoint, HTTPClientConfig{})
+		Expect(err).ToNot(HaveOccurred())
+
+		var session *HTTPServerPortal
+		Eventually(sessions).Should(Receive(&session))
+
+		Expect(client.WritePacket([]byte("goodbye"))).To(Succeed())
+		Expect(client.Close()).To(Succeed())
+		Expect(client.Close()).To(Succeed())
+
+		Expect(session.ReadPacket()).To(Equal([]byte("goodbye")))
+
+		_, err = session.ReadPacket()
+		Expect(err).To(MatchError(io.EOF))
+		Expect(server.Sessions()).To(BeZero())
+	})
+
+	It("should expire the session without any pending request", func() {
+		serve(HTTPServerConfig{SessionTimeout: 100 * time.Millisecond})
+
+		response, err := http.Post(endpoint.String(), "text/plain", nil)
+		Expect(err).ToNot(HaveOccurred())
+		response.Body.Close()
+		Expect(response.StatusCode).To(Equal(http.StatusCreated))
+
+		Expect(server.Sessions()).To(Equal(1))
+		Eventually(server.Sessions).Should(BeZero())
+	})
+
+	It("should reject the requests of unknown sessions", func() {
+		serve(HTTPServerConfig{})
+
+		response, err := http.Get(endpoint.String() + "?session=unknown")
+		Expect(err).ToNot(HaveOccurred())
+		response.Body.Close()
+		Expect(response.StatusCode).To(Equal(http.StatusNotFound))
+
+		response, err = http.Get(endpoint.String())
+		Expect(err).ToNot(HaveOccurred())
+		response.Body.Close()
+		Expect(response.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should fail the writes when the client does not take the queued packets", func() {
+		serve(HTTPServerConfig{MaxQueuedPackets: 2})
+
+		response, err := http.Post(endpoint.String(), "text/plain", nil)
+		Expect(err).ToNot(HaveOccurred())
+		response.Body.Close()
+
+		var session *HTTPServerPortal
+		Eventually(sessions).Should(Receive(&session))
+		defer session.Close()
+
+		Expect(session.WritePacket([]byte{1})).To(Succeed())
+		Expect(session.WritePacket([]byte{2})).To(Succeed())
+		Expect(session.WritePacket([]byte{3})).To(MatchError(ErrHTTPSessionQueueFull))
+	})
+})
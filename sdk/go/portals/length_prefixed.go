@@ -0,0 +1,136 @@
+package dmqportals
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+)
+
+// lengthPrefixedPortal sends packets over the byte stream, every packet
+// is prefixed with its length encoded as unsigned varint. It implements
+// the reading and writing of the TCP, unix socket and stream portals,
+// closing is left to them.
+type lengthPrefixedPortal struct {
+	reader *bufio.Reader
+	writer io.Writer
+
+	maxPacketSize int
+	errTooLarge   error
+
+	writeMutex sync.Mutex
+}
+
+func newLengthPrefixedPortal(reader io.Reader, writer io.Writer, maxPacketSize int, errTooLarge error) *lengthPrefixedPortal {
+	return &lengthPrefixedPortal{
+		reader: bufio.NewReader(reader),
+		writer: writer,
+
+		maxPacketSize: maxPacketSize,
+		errTooLarge:   errTooLarge,
+	}
+}
+
+func (p *lengthPrefixedPortal) ReadPacket() ([]byte, error) {
+	packet, tooLarge, err := readLengthPrefixedPacket(p.reader, uint64(p.maxPacketSize))
+	if tooLarge {
+		return nil, p.errTooLarge
+	}
+
+	return packet, err
+}
+
+func (p *lengthPrefixedPortal) WritePacket(packet []byte) error {
+	if len(packet) > p.maxPacketSize {
+		return p.errTooLarge
+	}
+
+	frame := appendLengthPrefixedPacket(nil, packet)
+
+	p.writeMutex.Lock()
+	defer p.writeMutex.Unlock()
+
+	_, err := p.writer.Write(frame)
+	return err
+}
+
+// connPortal is the length prefixed portal over the stream connection,
+// shared by the TCP and unix socket portals.
+type connPortal struct {
+	*lengthPrefixedPortal
+
+	conn net.Conn
+}
+
+func newConnPortal(conn net.Conn, maxPacketSize int, errTooLarge error) *connPortal {
+	return &connPortal{
+		lengthPrefixedPortal: newLengthPrefixedPortal(conn, conn, maxPacketSize, errTooLarge),
+		conn:                 conn,
+	}
+}
+
+func (p *connPortal) Close() error {
+	return p.conn.Close()
+}
+
+// RemoteAddr returns the address of the bridged node.
+func (p *connPortal) RemoteAddr() net.Addr {
+	return p.conn.RemoteAddr()
+}
+
+// serveListener accepts the connections until the context is done,
+// the listener is closed when it returns.
+func serveListener(ctx context.Context, listener net.Listener, accept func(conn net.Conn)) error {
+	defer listener.Close()
+
+	stopped := make(chan struct{})
+	defer close(stopped)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			listener.Close()
+		case <-stopped:
+		}
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		accept(conn)
+	}
+}
+
+// readLengthPrefixedPacket reads the packet prefixed with its length
+// encoded as unsigned varint, packets longer than maxSize are not read.
+func readLengthPrefixedPacket(reader *bufio.Reader, maxSize uint64) (packet []byte, tooLarge bool, err error) {
+	size, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if size > maxSize {
+		return nil, true, nil
+	}
+
+	packet = make([]byte, size)
+	if _, err := io.ReadFull(reader, packet); err != nil {
+		return nil, false, err
+	}
+
+	return packet, false, nil
+}
+
+func appendLengthPrefixedPacket(frame []byte, packet []byte) []byte {
+	frame = binary.AppendUvarint(frame, uint64(len(packet)))
+	return append(frame, packet...)
+}
@@ -0,0 +1,75 @@
+package dmqportals
+
+import (
+	"log/slog"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// LoggingPortal logs every packet crossing the wrapped portal
+// as the decoded frame at the debug level, failures and the close
+// are logged at the warn and info levels.
+type LoggingPortal struct {
+	portalDecorator
+
+	logger *slog.Logger
+	format directmq.ProtobufProtocolFormat
+}
+
+var _ directmq.Portal = (*LoggingPortal)(nil)
+
+// NewLoggingPortal wraps the portal, the format has to match
+// the protocol used by the node to decode the frames.
+func NewLoggingPortal(portal directmq.Portal, logger *slog.Logger, format directmq.ProtobufProtocolFormat) *LoggingPortal {
+	return &LoggingPortal{
+		portalDecorator: portalDecorator{portal},
+		logger:          logger,
+		format:          format,
+	}
+}
+
+func (p *LoggingPortal) ReadPacket() ([]byte, error) {
+	packet, err := p.portal.ReadPacket()
+	if err != nil {
+		p.logger.Warn("portal read failed", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	p.logPacket("packet read", packet)
+	return packet, nil
+}
+
+func (p *LoggingPortal) WritePacket(packet []byte) error {
+	if err := p.portal.WritePacket(packet); err != nil {
+		p.logger.Warn("portal write failed", slog.String("error", err.Error()))
+		return err
+	}
+
+	p.logPacket("packet written", packet)
+	return nil
+}
+
+func (p *LoggingPortal) Close() error {
+	p.logger.Info("portal closed")
+	return p.portal.Close()
+}
+
+func (p *LoggingPortal) logPacket(msg string, packet []byte) {
+	frame, messageType, err := directmq.DecodeProtobufFrame(packet, p.format)
+	if err != nil {
+		p.logger.Debug(msg,
+			slog.Int("size", len(packet)),
+			slog.String("message_type", string(messageType)),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	p.logger.Debug(msg,
+		slog.Int("size", len(packet)),
+		slog.String("message_type", string(messageType)),
+		slog.String("frame", protojson.Format(frame)),
+	)
+}
@@ -0,0 +1,68 @@
+package dmqportals
+
+import (
+	"bytes"
+	"log/slog"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LoggingPortal", func() {
+	newLogger := func() (*slog.Logger, *bytes.Buffer) {
+		output := new(bytes.Buffer)
+		handler := slog.NewTextHandler(output, &slog.HandlerOptions{Level: slog.LevelDebug})
+		return slog.New(handler), output
+	}
+
+	for _, format := range []directmq.ProtobufProtocolFormat{directmq.PROTOBUF_FORMAT_BINARY, directmq.PROTOBUF_FORMAT_JSON} {
+		format := format
+
+		It("should log the decoded frames in both directions", func() {
+			a, b := NewMemoryPipe()
+			defer a.Close()
+
+			logger, output := newLogger()
+			portal := NewLoggingPortal(a, logger, format)
+
+			factory := directmq.NewProtobufBinaryProtocol()
+			if format == directmq.PROTOBUF_FORMAT_JSON {
+				factory = directmq.NewProtobufJSONProtocol()
+			}
+
+			protocol := factory(nil, portal)
+			Expect(protocol.Publish(directmq.PublishMessage{Topic: "logged/topic", Payload: []byte("payload")})).To(Succeed())
+
+			packet, err := b.ReadPacket()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(b.WritePacket(packet)).To(Succeed())
+
+			Expect(portal.ReadPacket()).To(Equal(packet))
+
+			Expect(output.String()).To(ContainSubstring(`msg="packet written"`))
+			Expect(output.String()).To(ContainSubstring(`msg="packet read"`))
+			Expect(output.String()).To(ContainSubstring("message_type=" + string(directmq.MESSAGE_TYPE_PUBLISH)))
+			Expect(output.String()).To(ContainSubstring("logged/topic"))
+		})
+	}
+
+	It("should log the malformed packets and failures", func() {
+		a, b := NewMemoryPipe()
+
+		logger, output := newLogger()
+		portal := NewLoggingPortal(a, logger, directmq.PROTOBUF_FORMAT_BINARY)
+
+		Expect(b.WritePacket([]byte{0xff, 0xff})).To(Succeed())
+		Expect(portal.ReadPacket()).To(Equal([]byte{0xff, 0xff}))
+		Expect(output.String()).To(ContainSubstring("message_type=" + string(directmq.MESSAGE_TYPE_MALFORMED)))
+
+		Expect(portal.Close()).To(Succeed())
+		Expect(output.String()).To(ContainSubstring(`msg="portal closed"`))
+
+		_, err := portal.ReadPacket()
+		Expect(err).To(HaveOccurred())
+		Expect(output.String()).To(ContainSubstring(`msg="portal read failed"`))
+	})
+})
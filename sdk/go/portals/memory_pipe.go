@@ -0,0 +1,216 @@
+package dmqportals
+
+import (
+	"io"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+)
+
+const (
+	DEFAULT_MEMORY_PIPE_BUFFER_SIZE   = 64
+	DEFAULT_MEMORY_PIPE_REORDER_DELAY = 10 * time.Millisecond
+)
+
+type MemoryPipeConfig struct {
+	// Number of packets buffered in each direction, writes block
+	// when the buffer is full. DEFAULT_MEMORY_PIPE_BUFFER_SIZE
+	// is used when zero.
+	BufferSize int
+
+	// Time after which the written packet can be read.
+	Latency time.Duration
+
+	// Probability of losing the written packet, from 0 to 1.
+	LossRate float64
+
+	// Probability of delaying the written packet by the ReorderDelay,
+	// so the packets written during that time overtake it.
+	ReorderRate float64
+
+	// DEFAULT_MEMORY_PIPE_REORDER_DELAY is used when zero.
+	ReorderDelay time.Duration
+
+	// Seed of the random generator deciding about the loss and reordering,
+	// the same seed gives the same decisions for the same writes.
+	Seed int64
+}
+
+// NewMemoryPipe returns two connected portals exchanging packets
+// in memory, e.g. to bridge the nodes living in the same process.
+// Closing either portal closes both of them, reads from the closed
+// portal return io.ErrClosedPipe and reads from the other one io.EOF.
+func NewMemoryPipe() (directmq.Portal, directmq.Portal) {
+	return NewMemoryPipeWithConfig(MemoryPipeConfig{})
+}
+
+func NewMemoryPipeWithConfig(config MemoryPipeConfig) (directmq.Portal, directmq.Portal) {
+	if config.BufferSize <= 0 {
+		config.BufferSize = DEFAULT_MEMORY_PIPE_BUFFER_SIZE
+	}
+
+	if config.ReorderDelay <= 0 {
+		config.ReorderDelay = DEFAULT_MEMORY_PIPE_REORDER_DELAY
+	}
+
+	pipe := &memoryPipe{
+		config: config,
+		random: rand.New(rand.NewSource(config.Seed)),
+		done:   make(chan struct{}),
+	}
+
+	pipe.space = sync.NewCond(&pipe.mutex)
+
+	aToB := newMemoryPipeDirection()
+	bToA := newMemoryPipeDirection()
+
+	return &memoryPortal{pipe, bToA, aToB}, &memoryPortal{pipe, aToB, bToA}
+}
+
+type memoryPipe struct {
+	config MemoryPipeConfig
+
+	mutex  sync.Mutex
+	space  *sync.Cond
+	random *rand.Rand
+
+	closed   bool
+	closedBy *memoryPortal
+	done     chan struct{}
+}
+
+type memoryPipeDirection struct {
+	// packets sorted by the delivery time
+	queue    []memoryPipePacket
+	notifier chan struct{}
+}
+
+type memoryPipePacket struct {
+	data      []byte
+	deliverAt time.Time
+}
+
+func newMemoryPipeDirection() *memoryPipeDirection {
+	return &memoryPipeDirection{
+		queue:    make([]memoryPipePacket, 0),
+		notifier: make(chan struct{}, 1),
+	}
+}
+
+func (d *memoryPipeDirection) push(packet memoryPipePacket) {
+	index := sort.Search(len(d.queue), func(i int) bool {
+		return d.queue[i].deliverAt.After(packet.deliverAt)
+	})
+
+	d.queue = append(d.queue, memoryPipePacket{})
+	copy(d.queue[index+1:], d.queue[index:])
+	d.queue[index] = packet
+
+	select {
+	case d.notifier <- struct{}{}:
+	default:
+	}
+}
+
+type memoryPortal struct {
+	pipe     *memoryPipe
+	incoming *memoryPipeDirection
+	outgoing *memoryPipeDirection
+}
+
+var _ directmq.Portal = (*memoryPortal)(nil)
+var _ directmq.UnreliablePortal = (*memoryPortal)(nil)
+
+func (p *memoryPortal) IsUnreliable() bool {
+	return p.pipe.config.LossRate > 0 || p.pipe.config.ReorderRate > 0
+}
+
+func (p *memoryPortal) ReadPacket() ([]byte, error) {
+	for {
+		p.pipe.mutex.Lock()
+
+		if p.pipe.closed {
+			err := p.closeError()
+			p.pipe.mutex.Unlock()
+			return nil, err
+		}
+
+		var delivery <-chan time.Time
+		if len(p.incoming.queue) > 0 {
+			wait := time.Until(p.incoming.queue[0].deliverAt)
+			if wait <= 0 {
+				packet := p.incoming.queue[0]
+				p.incoming.queue = p.incoming.queue[1:]
+				p.pipe.space.Broadcast()
+				p.pipe.mutex.Unlock()
+				return packet.data, nil
+			}
+
+			delivery = time.After(wait)
+		}
+
+		p.pipe.mutex.Unlock()
+
+		select {
+		case <-p.incoming.notifier:
+		case <-delivery:
+		case <-p.pipe.done:
+		}
+	}
+}
+
+func (p *memoryPortal) WritePacket(packet []byte) error {
+	p.pipe.mutex.Lock()
+	defer p.pipe.mutex.Unlock()
+
+	for len(p.outgoing.queue) >= p.pipe.config.BufferSize && !p.pipe.closed {
+		p.pipe.space.Wait()
+	}
+
+	if p.pipe.closed {
+		return p.closeError()
+	}
+
+	if p.pipe.random.Float64() < p.pipe.config.LossRate {
+		return nil
+	}
+
+	deliverAt := time.Now().Add(p.pipe.config.Latency)
+	if p.pipe.random.Float64() < p.pipe.config.ReorderRate {
+		deliverAt = deliverAt.Add(p.pipe.config.ReorderDelay)
+	}
+
+	p.outgoing.push(memoryPipePacket{
+		data:      append([]byte{}, packet...),
+		deliverAt: deliverAt,
+	})
+
+	return nil
+}
+
+func (p *memoryPortal) Close() error {
+	p.pipe.mutex.Lock()
+	defer p.pipe.mutex.Unlock()
+
+	if p.pipe.closed {
+		return nil
+	}
+
+	p.pipe.closed = true
+	p.pipe.closedBy = p
+	close(p.pipe.done)
+	p.pipe.space.Broadcast()
+
+	return nil
+}
+
+func (p *memoryPortal) closeError() error {
+	if p.pipe.closedBy == p {
+		return io.ErrClosedPipe
+	}
+
+	return io.EOF
+}
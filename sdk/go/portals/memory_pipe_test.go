@@ -0,0 +1,196 @@
+package dmqportals
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MemoryPipe", func() {
+	readAll := func(portal directmq.Portal, count int) []string {
+		packets := make([]string, 0, count)
+		for len(packets) < count {
+			packet, err := portal.ReadPacket()
+			Expect(err).ToNot(HaveOccurred())
+			packets = append(packets, string(packet))
+		}
+
+		return packets
+	}
+
+	writeAll := func(portal directmq.Portal, count int) []string {
+		packets := make([]string, 0, count)
+		for i := 0; i < count; i++ {
+			packet := fmt.Sprintf("packet %03d", i)
+			Expect(portal.WritePacket([]byte(packet))).To(Succeed())
+			packets = append(packets, packet)
+		}
+
+		return packets
+	}
+
+	It("should pass the packets in order in both directions", func() {
+		a, b := NewMemoryPipe()
+		defer a.Close()
+
+		written := writeAll(a, 10)
+		Expect(readAll(b, 10)).To(Equal(written))
+
+		written = writeAll(b, 10)
+		Expect(readAll(a, 10)).To(Equal(written))
+	})
+
+	It("should not share the written buffer with the reader", func() {
+		a, b := NewMemoryPipe()
+		defer a.Close()
+
+		packet := []byte("packet")
+		Expect(a.WritePacket(packet)).To(Succeed())
+		packet[0] = 'X'
+
+		Expect(b.ReadPacket()).To(Equal([]byte("packet")))
+	})
+
+	It("should propagate the close to the other portal", func() {
+		a, b := NewMemoryPipe()
+
+		read := make(chan error, 1)
+		go func() { _, err := b.ReadPacket(); read <- err }()
+
+		Expect(a.Close()).To(Succeed())
+
+		Eventually(read).Should(Receive(Equal(io.EOF)))
+		Expect(b.WritePacket([]byte("packet"))).To(MatchError(io.EOF))
+
+		_, err := a.ReadPacket()
+		Expect(err).To(MatchError(io.ErrClosedPipe))
+		Expect(a.WritePacket([]byte("packet"))).To(MatchError(io.ErrClosedPipe))
+		Expect(b.Close()).To(Succeed())
+	})
+
+	It("should block the writes when the buffer is full", func() {
+		a, b := NewMemoryPipeWithConfig(MemoryPipeConfig{BufferSize: 2})
+		defer a.Close()
+
+		writeAll(a, 2)
+
+		written := make(chan error, 1)
+		go func() { written <- a.WritePacket([]byte("blocked")) }()
+		Consistently(written, 50*time.Millisecond).ShouldNot(Receive())
+
+		readAll(b, 1)
+		Eventually(written).Should(Receive(BeNil()))
+		Expect(readAll(b, 2)).To(Equal([]string{"packet 001", "blocked"}))
+	})
+
+	It("should unblock the blocked write when the pipe is closed", func() {
+		a, b := NewMemoryPipeWithConfig(MemoryPipeConfig{BufferSize: 1})
+
+		writeAll(a, 1)
+
+		written := make(chan error, 1)
+		go func() { written <- a.WritePacket([]byte("blocked")) }()
+
+		Expect(b.Close()).To(Succeed())
+		Eventually(written).Should(Receive(MatchError(io.EOF)))
+	})
+
+	It("should delay the packets by the latency", func() {
+		latency := 100 * time.Millisecond
+		a, b := NewMemoryPipeWithConfig(MemoryPipeConfig{Latency: latency})
+		defer a.Close()
+
+		start := time.Now()
+		writeAll(a, 3)
+
+		Expect(readAll(b, 3)).To(HaveLen(3))
+		Expect(time.Since(start)).To(BeNumerically(">=", latency))
+	})
+
+	It("should lose the packets with the configured probability", func() {
+		a, b := NewMemoryPipeWithConfig(MemoryPipeConfig{LossRate: 0.3, Seed: 1})
+		defer a.Close()
+
+		written := writeAll(a, 60)
+
+		// without latency all delivered packets are queued right after the write
+		delivered := len(a.(*memoryPortal).outgoing.queue)
+		Expect(delivered).To(BeNumerically(">", 20))
+		Expect(delivered).To(BeNumerically("<", 60))
+
+		Expect(written).To(ContainElements(readAll(b, delivered)))
+	})
+
+	It("should make the same decisions for the same seed", func() {
+		config := MemoryPipeConfig{LossRate: 0.5, Seed: 42}
+
+		lost := func() []int {
+			a, _ := NewMemoryPipeWithConfig(config)
+			defer a.Close()
+
+			writeAll(a, 30)
+
+			queue := a.(*memoryPortal).outgoing.queue
+			indexes := make([]int, 0, len(queue))
+			for _, packet := range queue {
+				var index int
+				fmt.Sscanf(string(packet.data), "packet %d", &index)
+				indexes = append(indexes, index)
+			}
+
+			return indexes
+		}
+
+		Expect(lost()).To(Equal(lost()))
+	})
+
+	It("should reorder the packets with the configured probability", func() {
+		a, b := NewMemoryPipeWithConfig(MemoryPipeConfig{
+			ReorderRate:  0.2,
+			ReorderDelay: 20 * time.Millisecond,
+			Seed:         7,
+		})
+		defer a.Close()
+
+		written := writeAll(a, 50)
+		received := readAll(b, 50)
+
+		Expect(received).ToNot(Equal(written))
+
+		sort.Strings(received)
+		Expect(received).To(Equal(written))
+	})
+
+	It("should connect the whole topology in the process", func() {
+		nodes := []directmq.NetworkNode{newTestNode("a"), newTestNode("b"), newTestNode("c")}
+		defer func() {
+			for _, node := range nodes {
+				node.CloseNode("test finished")
+			}
+		}()
+
+		for i := 0; i < len(nodes)-1; i++ {
+			connecting, listening := NewMemoryPipeWithConfig(MemoryPipeConfig{Latency: time.Millisecond})
+			go nodes[i].AddConnectingEdge(connecting)
+			go nodes[i+1].AddListeningEdge(listening)
+		}
+
+		Eventually(nodes[1].GetBridgedNodeIDs).Should(ConsistOf("a", "c"))
+
+		received := make(chan []byte, 16)
+		nodes[2].Subscribe("sensors/*", func(payload []byte) { received <- payload })
+
+		Eventually(func() int {
+			nodes[0].Publish("sensors/temperature", []byte("21.5"), directmq.AT_MOST_ONCE)
+			return len(received)
+		}).Should(BeNumerically(">", 0))
+
+		Expect(<-received).To(Equal([]byte("21.5")))
+	})
+})
@@ -0,0 +1,41 @@
+package dmqportals
+
+import directmq "github.com/sync-toys/DirectMQ/sdk/go"
+
+// portalDecorator is embedded by the portals wrapping the other portal,
+// it forwards everything the wrapper does not override, so the decorators
+// can be stacked without hiding the capabilities of the wrapped portal.
+type portalDecorator struct {
+	portal directmq.Portal
+}
+
+var _ directmq.CorruptedPacketsReporter = portalDecorator{}
+var _ directmq.UnreliablePortal = portalDecorator{}
+
+func (d portalDecorator) ReadPacket() ([]byte, error) {
+	return d.portal.ReadPacket()
+}
+
+func (d portalDecorator) WritePacket(packet []byte) error {
+	return d.portal.WritePacket(packet)
+}
+
+func (d portalDecorator) Close() error {
+	return d.portal.Close()
+}
+
+// Unwrap returns the wrapped portal.
+func (d portalDecorator) Unwrap() directmq.Portal {
+	return d.portal
+}
+
+func (d portalDecorator) IsUnreliable() bool {
+	unreliable, ok := d.portal.(directmq.UnreliablePortal)
+	return ok && unreliable.IsUnreliable()
+}
+
+func (d portalDecorator) OnCorruptedPacket(handler func(reason string)) {
+	if reporter, ok := d.portal.(directmq.CorruptedPacketsReporter); ok {
+		reporter.OnCorruptedPacket(handler)
+	}
+}
@@ -0,0 +1,13 @@
+package dmqportals_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPortals(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Portals")
+}
@@ -0,0 +1,222 @@
+package dmqportals
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"sync"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+)
+
+// Largest packet accepted by the serial portal, longer frames
+// are treated as corrupted and dropped up to the next delimiter.
+const SERIAL_MAX_PACKET_SIZE = 64 * 1024
+
+const (
+	serialFrameDelimiter = 0x00
+	serialChecksumSize   = crc32.Size
+)
+
+var ErrSerialPacketTooLarge = errors.New("serial packet exceeds the maximum packet size")
+
+var errCOBSMalformed = errors.New("malformed COBS frame")
+
+// SerialPortal sends packets over the byte stream, e.g. UART or
+// pseudo-terminal, which can lose or damage bytes.
+//
+// Every packet is followed by its CRC-32 (IEEE) checksum, encoded with
+// COBS and surrounded by zero byte delimiters, so the reader is able
+// to find the start of the next frame after the line noise. Frames
+// failing to decode or to pass the checksum are dropped and reported
+// to the node diagnostics.
+type SerialPortal struct {
+	port   io.ReadWriteCloser
+	reader *bufio.Reader
+
+	writeMutex sync.Mutex
+
+	onCorruptedPacket func(reason string)
+}
+
+var _ directmq.Portal = (*SerialPortal)(nil)
+var _ directmq.CorruptedPacketsReporter = (*SerialPortal)(nil)
+var _ directmq.UnreliablePortal = (*SerialPortal)(nil)
+
+func NewSerialPortal(port io.ReadWriteCloser) *SerialPortal {
+	return &SerialPortal{
+		port:   port,
+		reader: bufio.NewReader(port),
+	}
+}
+
+func (p *SerialPortal) OnCorruptedPacket(handler func(reason string)) {
+	p.onCorruptedPacket = handler
+}
+
+// IsUnreliable reports the portal as unreliable,
+// as the damaged frames are dropped.
+func (p *SerialPortal) IsUnreliable() bool {
+	return true
+}
+
+func (p *SerialPortal) Close() error {
+	return p.port.Close()
+}
+
+func (p *SerialPortal) ReadPacket() ([]byte, error) {
+	for {
+		frame, tooLarge, err := p.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		if tooLarge {
+			p.reportCorruptedPacket("frame exceeds the maximum packet size")
+			continue
+		}
+
+		// consecutive delimiters are used to separate
+		// frames from the line noise, they carry no data
+		if len(frame) == 0 {
+			continue
+		}
+
+		packet, err := decodeSerialFrame(frame)
+		if err != nil {
+			p.reportCorruptedPacket(err.Error())
+			continue
+		}
+
+		return packet, nil
+	}
+}
+
+func (p *SerialPortal) WritePacket(packet []byte) error {
+	if len(packet) > SERIAL_MAX_PACKET_SIZE {
+		return ErrSerialPacketTooLarge
+	}
+
+	frame := encodeSerialFrame(packet)
+
+	p.writeMutex.Lock()
+	defer p.writeMutex.Unlock()
+
+	_, err := p.port.Write(frame)
+	return err
+}
+
+// readFrame reads the bytes up to the next delimiter,
+// bytes of the too large frame are discarded.
+func (p *SerialPortal) readFrame() (frame []byte, tooLarge bool, err error) {
+	maxFrameSize := cobsMaxEncodedSize(SERIAL_MAX_PACKET_SIZE + serialChecksumSize)
+	frame = make([]byte, 0)
+
+	for {
+		b, err := p.reader.ReadByte()
+		if err != nil {
+			return nil, false, err
+		}
+
+		if b == serialFrameDelimiter {
+			return frame, tooLarge, nil
+		}
+
+		if len(frame) >= maxFrameSize {
+			tooLarge = true
+			continue
+		}
+
+		frame = append(frame, b)
+	}
+}
+
+func (p *SerialPortal) reportCorruptedPacket(reason string) {
+	if p.onCorruptedPacket != nil {
+		p.onCorruptedPacket(reason)
+	}
+}
+
+func encodeSerialFrame(packet []byte) []byte {
+	data := make([]byte, 0, len(packet)+serialChecksumSize)
+	data = append(data, packet...)
+	data = binary.LittleEndian.AppendUint32(data, crc32.ChecksumIEEE(packet))
+
+	frame := make([]byte, 0, cobsMaxEncodedSize(len(data))+2)
+	frame = append(frame, serialFrameDelimiter)
+	frame = cobsEncode(frame, data)
+	return append(frame, serialFrameDelimiter)
+}
+
+func decodeSerialFrame(frame []byte) ([]byte, error) {
+	data, err := cobsDecode(frame)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < serialChecksumSize {
+		return nil, errors.New("frame too short to contain the checksum")
+	}
+
+	packet := data[:len(data)-serialChecksumSize]
+	checksum := binary.LittleEndian.Uint32(data[len(data)-serialChecksumSize:])
+
+	if crc32.ChecksumIEEE(packet) != checksum {
+		return nil, errors.New("checksum mismatch")
+	}
+
+	return packet, nil
+}
+
+/* Consistent Overhead Byte Stuffing */
+
+func cobsMaxEncodedSize(size int) int {
+	return size + size/254 + 1
+}
+
+// cobsEncode appends the data encoded with COBS to the destination,
+// the result does not contain any zero bytes.
+func cobsEncode(destination []byte, data []byte) []byte {
+	codeIndex := len(destination)
+	destination = append(destination, 0)
+	code := byte(1)
+
+	for _, b := range data {
+		if b != 0 {
+			destination = append(destination, b)
+			code++
+		}
+
+		if b == 0 || code == 0xFF {
+			destination[codeIndex] = code
+			codeIndex = len(destination)
+			destination = append(destination, 0)
+			code = 1
+		}
+	}
+
+	destination[codeIndex] = code
+	return destination
+}
+
+func cobsDecode(frame []byte) ([]byte, error) {
+	data := make([]byte, 0, len(frame))
+
+	for i := 0; i < len(frame); {
+		code := int(frame[i])
+		if code == 0 || i+code > len(frame) {
+			return nil, errCOBSMalformed
+		}
+
+		data = append(data, frame[i+1:i+code]...)
+		i += code
+
+		if code != 0xFF && i < len(frame) {
+			data = append(data, 0)
+		}
+	}
+
+	return data, nil
+}
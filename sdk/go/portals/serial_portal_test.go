@@ -0,0 +1,267 @@
+package dmqportals
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"sync"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// testSerialLine is one end of the in-memory serial line,
+// the line field gives the access to the raw bytes sent to the other end.
+type testSerialLine struct {
+	io.Reader
+	line *io.PipeWriter
+}
+
+func (l *testSerialLine) Write(data []byte) (int, error) {
+	return l.line.Write(data)
+}
+
+func (l *testSerialLine) Close() error {
+	return l.line.Close()
+}
+
+func newTestSerialLinePair() (*testSerialLine, *testSerialLine) {
+	aReader, bWriter := io.Pipe()
+	bReader, aWriter := io.Pipe()
+
+	return &testSerialLine{aReader, aWriter}, &testSerialLine{bReader, bWriter}
+}
+
+// lossySerialLine drops the first write containing the marker,
+// like the frame damaged by the line noise.
+type lossySerialLine struct {
+	*testSerialLine
+	marker  []byte
+	dropped bool
+}
+
+func (l *lossySerialLine) Write(data []byte) (int, error) {
+	if !l.dropped && bytes.Contains(data, l.marker) {
+		l.dropped = true
+		return len(data), nil
+	}
+
+	return l.testSerialLine.Write(data)
+}
+
+var _ = Describe("COBS", func() {
+	It("should encode and decode the data without zero bytes", func() {
+		random := rand.New(rand.NewSource(GinkgoRandomSeed()))
+
+		samples := [][]byte{
+			{0},
+			{0, 0},
+			{1, 0, 2},
+			bytes.Repeat([]byte{1}, 254),
+			bytes.Repeat([]byte{1}, 255),
+			append(bytes.Repeat([]byte{1}, 254), 0),
+		}
+
+		for i := 0; i < 100; i++ {
+			sample := make([]byte, 1+random.Intn(600))
+			for j := range sample {
+				// zero bytes are frequent, so the blocks are of the various lengths
+				sample[j] = byte(random.Intn(4) * random.Intn(256))
+			}
+
+			samples = append(samples, sample)
+		}
+
+		for _, sample := range samples {
+			encoded := cobsEncode(nil, sample)
+			Expect(encoded).ToNot(ContainElement(byte(0)))
+			Expect(len(encoded)).To(BeNumerically("<=", cobsMaxEncodedSize(len(sample))))
+
+			decoded, err := cobsDecode(encoded)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decoded).To(Equal(sample))
+		}
+	})
+
+	It("should reject the block exceeding the frame", func() {
+		_, err := cobsDecode([]byte{5, 1, 2})
+		Expect(err).To(MatchError(errCOBSMalformed))
+	})
+})
+
+var _ = Describe("SerialPortal", func() {
+	var aLine, bLine *testSerialLine
+	var a, b *SerialPortal
+	var corrupted []string
+	var corruptedMutex sync.Mutex
+
+	getCorrupted := func() []string {
+		corruptedMutex.Lock()
+		defer corruptedMutex.Unlock()
+		return append([]string{}, corrupted...)
+	}
+
+	BeforeEach(func() {
+		aLine, bLine = newTestSerialLinePair()
+		a, b = NewSerialPortal(aLine), NewSerialPortal(bLine)
+
+		corrupted = make([]string, 0)
+		b.OnCorruptedPacket(func(reason string) {
+			corruptedMutex.Lock()
+			defer corruptedMutex.Unlock()
+			corrupted = append(corrupted, reason)
+		})
+	})
+
+	AfterEach(func() {
+		a.Close()
+		b.Close()
+	})
+
+	write := func(write func() error) {
+		go func() {
+			defer GinkgoRecover()
+			Expect(write()).To(Succeed())
+		}()
+	}
+
+	It("should preserve the packets", func() {
+		packets := [][]byte{[]byte("first"), {0, 0, 0}, bytes.Repeat([]byte{7}, 1000), {}}
+
+		write(func() error {
+			for _, packet := range packets {
+				if err := a.WritePacket(packet); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+		for _, expected := range packets {
+			packet, err := b.ReadPacket()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(packet).To(Equal(expected))
+		}
+
+		Expect(getCorrupted()).To(BeEmpty())
+	})
+
+	It("should resynchronize after the line noise", func() {
+		write(func() error {
+			if _, err := aLine.Write([]byte{0x13, 0x37, 0xFF}); err != nil {
+				return err
+			}
+			return a.WritePacket([]byte("after noise"))
+		})
+
+		packet, err := b.ReadPacket()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(packet).To(Equal([]byte("after noise")))
+		Expect(getCorrupted()).To(HaveLen(1))
+	})
+
+	It("should drop and report the damaged frame", func() {
+		frame := encodeSerialFrame([]byte("damaged"))
+		frame[3] ^= 0x01
+
+		write(func() error {
+			if _, err := aLine.Write(frame); err != nil {
+				return err
+			}
+			return a.WritePacket([]byte("intact"))
+		})
+
+		packet, err := b.ReadPacket()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(packet).To(Equal([]byte("intact")))
+		Expect(getCorrupted()).To(Equal([]string{"checksum mismatch"}))
+	})
+
+	It("should drop and report the too large frame", func() {
+		write(func() error {
+			if _, err := aLine.Write(bytes.Repeat([]byte{1}, 2*SERIAL_MAX_PACKET_SIZE)); err != nil {
+				return err
+			}
+			return a.WritePacket([]byte("next"))
+		})
+
+		packet, err := b.ReadPacket()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(packet).To(Equal([]byte("next")))
+		Expect(getCorrupted()).To(HaveLen(1))
+	})
+
+	It("should fail to read after the other side is closed", func() {
+		a.Close()
+
+		_, err := b.ReadPacket()
+		Expect(err).To(MatchError(io.EOF))
+	})
+
+	It("should not resolve the publications to the wrong topic after the frame is lost", func() {
+		config := func(id string) directmq.NetworkNodeConfig {
+			return directmq.NetworkNodeConfig{
+				HostID:              id,
+				HostTTL:             directmq.DEFAULT_TTL,
+				HostFeatures:        directmq.FEATURE_TOPIC_ALIASES,
+				HostMaxTopicAliases: 1,
+			}
+		}
+
+		server := directmq.NewNetworkNode(config("server"), directmq.NewProtobufBinaryProtocol())
+		client := directmq.NewNetworkNode(config("client"), directmq.NewProtobufBinaryProtocol())
+		defer server.CloseNode("test finished")
+		defer client.CloseNode("test finished")
+
+		subscribed := make(chan struct{}, 2)
+		client.OnSubscription(func(message directmq.SubscribeMessage) {
+			subscribed <- struct{}{}
+		})
+
+		received := make(chan string, 3)
+		for _, topic := range []string{"topics/first", "topics/second"} {
+			topic := topic
+			server.Subscribe(topic, func(payload []byte) {
+				received <- topic + ": " + string(payload)
+			})
+		}
+
+		// the frame with the second topic would rebind the only alias
+		lossy := &lossySerialLine{testSerialLine: aLine, marker: []byte("topics/second")}
+		go server.AddListeningEdge(b)
+		go client.AddConnectingEdge(NewSerialPortal(lossy))
+
+		Eventually(subscribed).Should(Receive())
+		Eventually(subscribed).Should(Receive())
+
+		client.Publish("topics/first", []byte("1"), directmq.AT_MOST_ONCE)
+		client.Publish("topics/second", []byte("lost"), directmq.AT_MOST_ONCE)
+		client.Publish("topics/second", []byte("2"), directmq.AT_MOST_ONCE)
+
+		Eventually(received).Should(Receive(Equal("topics/first: 1")))
+		Eventually(received).Should(Receive(Equal("topics/second: 2")))
+		Consistently(received).ShouldNot(Receive())
+	})
+
+	It("should report the corrupted packets through the node diagnostics", func() {
+		server, client := newTestNode("server"), newTestNode("client")
+		defer client.CloseNode("test finished")
+
+		reported := make(chan string, 1)
+		server.OnCorruptedPacket(func(bridgedNodeID, reason string, portal directmq.Portal) {
+			reported <- bridgedNodeID
+		})
+
+		go server.AddListeningEdge(b)
+		go client.AddConnectingEdge(a)
+		Eventually(server.GetBridgedNodeIDs).Should(Equal([]string{"client"}))
+
+		_, err := aLine.Write([]byte{0x13, 0x37, 0x00})
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(reported).Should(Receive(Equal("client")))
+		Consistently(server.GetBridgedNodeIDs).Should(Equal([]string{"client"}))
+	})
+})
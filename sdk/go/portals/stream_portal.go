@@ -0,0 +1,142 @@
+package dmqportals
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+)
+
+// Largest packet accepted by the stream portal, protects the reader
+// from allocating huge buffers after reading a corrupted length prefix.
+const STREAM_MAX_PACKET_SIZE = 16 * 1024 * 1024
+
+var ErrStreamPacketTooLarge = errors.New("stream packet exceeds the maximum packet size")
+
+// StreamPortal sends packets over the reader and writer pair, e.g. pipes
+// or the standard streams of the process. Every packet is prefixed
+// with its length encoded as unsigned varint, the same way as in
+// the TCP portal, so the peer can be written in any language.
+type StreamPortal struct {
+	*lengthPrefixedPortal
+
+	closers   []io.Closer
+	closeOnce sync.Once
+	closeErr  error
+}
+
+var _ directmq.Portal = (*StreamPortal)(nil)
+
+// NewStreamPortal wraps the reader and writer pair, closing
+// the portal closes those of them implementing io.Closer.
+func NewStreamPortal(reader io.Reader, writer io.Writer) *StreamPortal {
+	portal := &StreamPortal{
+		lengthPrefixedPortal: newLengthPrefixedPortal(reader, writer, STREAM_MAX_PACKET_SIZE, ErrStreamPacketTooLarge),
+		closers:              make([]io.Closer, 0, 2),
+	}
+
+	if closer, ok := writer.(io.Closer); ok {
+		portal.closers = append(portal.closers, closer)
+	}
+
+	if closer, ok := reader.(io.Closer); ok {
+		portal.closers = append(portal.closers, closer)
+	}
+
+	return portal
+}
+
+// NewStdioPortal returns the portal over the standard input and output
+// of the process, used by the child processes spawned with ExecConnect.
+// Nothing else can be written to the standard output of the process.
+func NewStdioPortal() *StreamPortal {
+	return NewStreamPortal(os.Stdin, os.Stdout)
+}
+
+func (p *StreamPortal) Close() error {
+	p.closeOnce.Do(func() {
+		for _, closer := range p.closers {
+			if err := closer.Close(); err != nil && p.closeErr == nil {
+				p.closeErr = err
+			}
+		}
+	})
+
+	return p.closeErr
+}
+
+// ExecPortal is the stream portal over the standard streams
+// of the child process started by ExecConnect.
+type ExecPortal struct {
+	*StreamPortal
+
+	cmd    *exec.Cmd
+	stdin  io.Closer
+	exited chan struct{}
+	err    error
+}
+
+// ExecConnect starts the command and adds the connecting edge bridging
+// the node with the child process over its stdin and stdout, the child
+// is expected to add the listening edge using NewStdioPortal or
+// the equivalent length prefixed framing.
+//
+// Closing the portal closes the stdin of the child, which should exit
+// after reading EOF. The command is waited for when the edge stops.
+func ExecConnect(dmq directmq.NetworkNode, cmd *exec.Cmd) (*ExecPortal, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		stdin.Close()
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	portal := &ExecPortal{
+		StreamPortal: NewStreamPortal(stdout, stdin),
+		cmd:          cmd,
+		stdin:        stdin,
+		exited:       make(chan struct{}),
+	}
+
+	go func() {
+		dmq.AddConnectingEdge(portal)
+
+		// waiting for the command closes the pipes,
+		// so it can happen only after the edge stopped reading
+		portal.StreamPortal.Close()
+		portal.err = cmd.Wait()
+		close(portal.exited)
+	}()
+
+	return portal, nil
+}
+
+// Close closes only the stdin of the child, the stdout is read
+// until the child exits, so it is not killed by the broken pipe
+// while writing its last packets.
+func (p *ExecPortal) Close() error {
+	return p.stdin.Close()
+}
+
+// Wait waits until the edge stops and the child process exits,
+// it returns the exit error of the command.
+func (p *ExecPortal) Wait() error {
+	<-p.exited
+	return p.err
+}
+
+// Process returns the started child process.
+func (p *ExecPortal) Process() *os.Process {
+	return p.cmd.Process
+}
@@ -0,0 +1,99 @@
+package dmqportals
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"testing"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const childProcessEnv = "DIRECTMQ_TEST_CHILD_PROCESS"
+
+// TestStreamPortalChildProcess is not a test, it is the child
+// process spawned by the ExecConnect specs, it echoes
+// the payloads published on the "requests" topic.
+func TestStreamPortalChildProcess(t *testing.T) {
+	if os.Getenv(childProcessEnv) != "1" {
+		t.Skip("run only as the child process")
+	}
+
+	node := newTestNode("child")
+	node.Subscribe("requests", func(payload []byte) {
+		node.Publish("responses", payload, directmq.AT_MOST_ONCE)
+	})
+
+	node.AddListeningEdge(NewStdioPortal())
+	os.Exit(0)
+}
+
+var _ = Describe("StreamPortal", func() {
+	newStreamPortalPair := func() (*StreamPortal, *StreamPortal) {
+		aReader, bWriter := io.Pipe()
+		bReader, aWriter := io.Pipe()
+		return NewStreamPortal(aReader, aWriter), NewStreamPortal(bReader, bWriter)
+	}
+
+	It("should pass the packets over the reader and writer pair", func() {
+		a, b := newStreamPortalPair()
+		defer a.Close()
+		defer b.Close()
+
+		go a.WritePacket([]byte("packet"))
+		Expect(b.ReadPacket()).To(Equal([]byte("packet")))
+
+		go b.WritePacket([]byte{})
+		Expect(a.ReadPacket()).To(BeEmpty())
+	})
+
+	It("should refuse to write the too large packet", func() {
+		a, b := newStreamPortalPair()
+		defer a.Close()
+		defer b.Close()
+
+		Expect(a.WritePacket(make([]byte, STREAM_MAX_PACKET_SIZE+1))).To(MatchError(ErrStreamPacketTooLarge))
+	})
+
+	It("should close both the reader and the writer", func() {
+		a, b := newStreamPortalPair()
+		defer b.Close()
+
+		Expect(a.Close()).To(Succeed())
+		Expect(a.Close()).To(Succeed())
+
+		_, err := b.ReadPacket()
+		Expect(err).To(MatchError(io.EOF))
+		Expect(b.WritePacket([]byte("packet"))).To(MatchError(io.ErrClosedPipe))
+	})
+
+	It("should bridge the node with the child process", func() {
+		parent := newTestNode("parent")
+		defer parent.CloseNode("test finished")
+
+		cmd := exec.Command(os.Args[0], "-test.run=^TestStreamPortalChildProcess$")
+		cmd.Env = append(os.Environ(), childProcessEnv+"=1")
+
+		portal, err := ExecConnect(parent, cmd)
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(parent.GetBridgedNodeIDs).Should(Equal([]string{"child"}))
+
+		responses := make(chan []byte, 16)
+		parent.Subscribe("responses", func(payload []byte) { responses <- payload })
+
+		Eventually(func() int {
+			parent.Publish("requests", []byte("ping"), directmq.AT_MOST_ONCE)
+			return len(responses)
+		}).Should(BeNumerically(">", 0))
+
+		Expect(<-responses).To(Equal([]byte("ping")))
+
+		Expect(portal.Close()).To(Succeed())
+		Expect(portal.Wait()).To(Succeed())
+		Expect(portal.Process().Pid).ToNot(BeZero())
+	})
+})
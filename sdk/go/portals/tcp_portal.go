@@ -0,0 +1,74 @@
+package dmqportals
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+)
+
+// Largest packet accepted by the TCP portal, protects the reader
+// from allocating huge buffers after reading a corrupted length prefix.
+const TCP_MAX_PACKET_SIZE = 16 * 1024 * 1024
+
+var ErrTCPPacketTooLarge = errors.New("tcp packet exceeds the maximum packet size")
+
+// TCPPortal sends packets over the stream connection,
+// every packet is prefixed with its length encoded as unsigned varint.
+type TCPPortal struct {
+	*connPortal
+}
+
+var _ directmq.Portal = (*TCPPortal)(nil)
+
+// NewTCPPortal wraps the already established connection,
+// it can be used with any net.Conn, including the TLS ones.
+func NewTCPPortal(conn net.Conn) *TCPPortal {
+	return &TCPPortal{newConnPortal(conn, TCP_MAX_PACKET_SIZE, ErrTCPPacketTooLarge)}
+}
+
+func TCPConnect(addr string) (*TCPPortal, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTCPPortal(conn), nil
+}
+
+func TCPConnectTLS(addr string, config *tls.Config) (*TCPPortal, error) {
+	conn, err := tls.Dial("tcp", addr, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTCPPortal(conn), nil
+}
+
+// TCPListen accepts the connections until the context is done,
+// every accepted connection is added to the node as the listening edge.
+func TCPListen(ctx context.Context, addr string, dmq directmq.NetworkNode) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return serveTCP(ctx, listener, dmq)
+}
+
+func TCPListenTLS(ctx context.Context, addr string, dmq directmq.NetworkNode, config *tls.Config) error {
+	listener, err := tls.Listen("tcp", addr, config)
+	if err != nil {
+		return err
+	}
+
+	return serveTCP(ctx, listener, dmq)
+}
+
+func serveTCP(ctx context.Context, listener net.Listener, dmq directmq.NetworkNode) error {
+	return serveListener(ctx, listener, func(conn net.Conn) {
+		go dmq.AddListeningEdge(NewTCPPortal(conn))
+	})
+}
@@ -0,0 +1,116 @@
+package dmqportals
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TCPPortal", func() {
+	var a, b *TCPPortal
+
+	BeforeEach(func() {
+		connA, connB := net.Pipe()
+		a, b = NewTCPPortal(connA), NewTCPPortal(connB)
+	})
+
+	AfterEach(func() {
+		a.Close()
+		b.Close()
+	})
+
+	It("should preserve the packet boundaries", func() {
+		packets := [][]byte{[]byte("first"), {}, make([]byte, 300), []byte("last")}
+
+		go func() {
+			defer GinkgoRecover()
+			for _, packet := range packets {
+				Expect(a.WritePacket(packet)).To(Succeed())
+			}
+		}()
+
+		for _, expected := range packets {
+			packet, err := b.ReadPacket()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(packet).To(Equal(expected))
+		}
+	})
+
+	It("should reject the packet exceeding the maximum size", func() {
+		go a.conn.Write(binary.AppendUvarint(nil, TCP_MAX_PACKET_SIZE+1))
+
+		_, err := b.ReadPacket()
+		Expect(err).To(MatchError(ErrTCPPacketTooLarge))
+	})
+
+	It("should fail to read after the other side is closed", func() {
+		a.Close()
+
+		_, err := b.ReadPacket()
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("TCPListen", func() {
+	var ctx context.Context
+	var cancel context.CancelFunc
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("should bridge the nodes over TCP", func() {
+		addr := getFreeTestAddress()
+		server, client := newTestNode("server"), newTestNode("client")
+
+		go TCPListen(ctx, addr, server)
+
+		var portal *TCPPortal
+		Eventually(func() (err error) {
+			portal, err = TCPConnect(addr)
+			return
+		}).Should(Succeed())
+
+		go client.AddConnectingEdge(portal)
+
+		Eventually(server.GetBridgedNodeIDs).Should(Equal([]string{"client"}))
+		Eventually(client.GetBridgedNodeIDs).Should(Equal([]string{"server"}))
+
+		client.CloseNode("test finished")
+	})
+
+	It("should bridge the nodes over TLS", func() {
+		addr := getFreeTestAddress()
+		server, client := newTestNode("server"), newTestNode("client")
+		serverConfig, clientConfig := newTestTLSConfigs()
+
+		go TCPListenTLS(ctx, addr, server, serverConfig)
+
+		var portal *TCPPortal
+		Eventually(func() (err error) {
+			portal, err = TCPConnectTLS(addr, clientConfig)
+			return
+		}).Should(Succeed())
+
+		go client.AddConnectingEdge(portal)
+
+		Eventually(server.GetBridgedNodeIDs).Should(Equal([]string{"client"}))
+
+		client.CloseNode("test finished")
+	})
+
+	It("should stop listening when the context is done", func() {
+		result := make(chan error, 1)
+		go func() { result <- TCPListen(ctx, getFreeTestAddress(), newTestNode("server")) }()
+
+		cancel()
+		Eventually(result).Should(Receive(BeNil()))
+	})
+})
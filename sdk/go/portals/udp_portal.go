@@ -0,0 +1,602 @@
+package dmqportals
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+)
+
+const (
+	DEFAULT_UDP_RETRANSMISSION_TIMEOUT = 200 * time.Millisecond
+	DEFAULT_UDP_MAX_RETRANSMISSIONS    = 10
+	DEFAULT_UDP_WINDOW_SIZE            = 32
+	DEFAULT_UDP_RECEIVE_QUEUE_SIZE     = 256
+	DEFAULT_UDP_MAX_BUSY_TIME          = 30 * time.Second
+
+	// Window is limited by the size of the selective acknowledgement bitmap.
+	MAX_UDP_WINDOW_SIZE = 64
+)
+
+const (
+	udpDatagramData  byte = 1
+	udpDatagramAck   byte = 2
+	udpDatagramClose byte = 3
+
+	// acknowledgement sent while the receive queue is full, the packets
+	// following the acknowledged ones are kept but not acknowledged
+	// until the queue has room for them
+	udpDatagramBusy byte = 4
+
+	// type, session ID and sequence number
+	udpHeaderSize = 1 + 8 + 4
+
+	// largest payload of the IPv4 UDP datagram
+	udpMaxDatagramSize = 65507
+)
+
+// Largest packet accepted by the UDP portal, packets are never fragmented
+// by the portal, so every packet has to fit into a single datagram.
+const UDP_MAX_PACKET_SIZE = udpMaxDatagramSize - udpHeaderSize
+
+var ErrUDPPacketTooLarge = errors.New("udp packet exceeds the maximum packet size")
+var ErrUDPPeerUnreachable = errors.New("udp peer did not acknowledge the packet")
+
+type UDPConfig struct {
+	// Reliable enables sequence numbers, selective acknowledgements,
+	// retransmissions and in-order delivery. Without it packets may be
+	// lost, duplicated or reordered. Both sides have to use the same setting.
+	Reliable bool
+
+	// Time after which the unacknowledged packet is sent again,
+	// DEFAULT_UDP_RETRANSMISSION_TIMEOUT is used when zero.
+	RetransmissionTimeout time.Duration
+
+	// Number of retransmissions of a single packet after which
+	// the peer is considered unreachable and the portal is closed,
+	// DEFAULT_UDP_MAX_RETRANSMISSIONS is used when zero. Retransmissions
+	// answered by the peer with the full receive queue are not counted,
+	// until the packet waits for the busy peer longer than MaxBusyTime.
+	MaxRetransmissions int
+
+	// Time the single packet may wait for the peer with the full receive
+	// queue, after it the retransmissions are counted again, so the peer
+	// never draining its queue is considered unreachable.
+	// DEFAULT_UDP_MAX_BUSY_TIME is used when zero.
+	MaxBusyTime time.Duration
+
+	// Maximum number of unacknowledged packets, writes block when
+	// the window is full. DEFAULT_UDP_WINDOW_SIZE is used when zero,
+	// values above MAX_UDP_WINDOW_SIZE are limited to it.
+	WindowSize int
+}
+
+func (c UDPConfig) withDefaults() UDPConfig {
+	if c.RetransmissionTimeout <= 0 {
+		c.RetransmissionTimeout = DEFAULT_UDP_RETRANSMISSION_TIMEOUT
+	}
+
+	if c.MaxRetransmissions <= 0 {
+		c.MaxRetransmissions = DEFAULT_UDP_MAX_RETRANSMISSIONS
+	}
+
+	if c.MaxBusyTime <= 0 {
+		c.MaxBusyTime = DEFAULT_UDP_MAX_BUSY_TIME
+	}
+
+	if c.WindowSize <= 0 {
+		c.WindowSize = DEFAULT_UDP_WINDOW_SIZE
+	}
+
+	if c.WindowSize > MAX_UDP_WINDOW_SIZE {
+		c.WindowSize = MAX_UDP_WINDOW_SIZE
+	}
+
+	return c
+}
+
+// UDPPortal exchanges packets with a single peer, every packet is sent
+// as a single datagram. Datagrams carry the session ID chosen by the
+// connecting side, so the listening side recognizes the peer even when
+// its source address changes, e.g. after the NAT rebinding.
+type UDPPortal struct {
+	sessionID uint64
+	config    UDPConfig
+
+	send    func(datagram []byte) error
+	onClose func()
+
+	mutex sync.Mutex
+	cond  *sync.Cond
+
+	closed    chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+
+	// outgoing direction
+	nextSeq uint32
+	unacked map[uint32]*udpPendingPacket
+
+	// incoming direction, packets waiting for ReadPacket and packets
+	// not delivered yet, received ahead of the expected one or kept
+	// until the received queue has room for them
+	received    [][]byte
+	readable    chan struct{}
+	expectedSeq uint32
+	outOfOrder  map[uint32][]byte
+}
+
+type udpPendingPacket struct {
+	datagram  []byte
+	sentAt    time.Time
+	retries   int
+	busySince time.Time
+}
+
+var _ directmq.Portal = (*UDPPortal)(nil)
+var _ directmq.UnreliablePortal = (*UDPPortal)(nil)
+
+func newUDPPortal(sessionID uint64, config UDPConfig, send func([]byte) error, onClose func()) *UDPPortal {
+	portal := &UDPPortal{
+		sessionID: sessionID,
+		config:    config.withDefaults(),
+
+		send:    send,
+		onClose: onClose,
+
+		closed: make(chan struct{}),
+
+		nextSeq: 1,
+		unacked: make(map[uint32]*udpPendingPacket),
+
+		received:    make([][]byte, 0),
+		readable:    make(chan struct{}, 1),
+		expectedSeq: 1,
+		outOfOrder:  make(map[uint32][]byte),
+	}
+
+	portal.cond = sync.NewCond(&portal.mutex)
+
+	if portal.config.Reliable {
+		go portal.runRetransmissions()
+	}
+
+	return portal
+}
+
+// IsUnreliable reports the portal as unreliable without the Reliable mode.
+func (p *UDPPortal) IsUnreliable() bool {
+	return !p.config.Reliable
+}
+
+func (p *UDPPortal) Close() error {
+	p.send(encodeUDPDatagram(udpDatagramClose, p.sessionID, 0, nil))
+	p.shutdown(net.ErrClosed)
+	return nil
+}
+
+// shutdown closes the portal, err is returned by the subsequent
+// reads and writes.
+func (p *UDPPortal) shutdown(err error) {
+	p.closeOnce.Do(func() {
+		p.mutex.Lock()
+		p.closeErr = err
+		close(p.closed)
+		p.cond.Broadcast()
+		p.mutex.Unlock()
+
+		if p.onClose != nil {
+			p.onClose()
+		}
+	})
+}
+
+func (p *UDPPortal) ReadPacket() ([]byte, error) {
+	for {
+		p.mutex.Lock()
+		if len(p.received) > 0 {
+			packet := p.received[0]
+			p.received = p.received[1:]
+
+			// the kept packets are acknowledged once they are delivered
+			if _, kept := p.outOfOrder[p.expectedSeq]; kept {
+				p.deliverOutOfOrder()
+				p.sendAck()
+			}
+
+			p.mutex.Unlock()
+			return packet, nil
+		}
+		p.mutex.Unlock()
+
+		select {
+		case <-p.readable:
+		case <-p.closed:
+			return nil, p.closeErr
+		}
+	}
+}
+
+func (p *UDPPortal) WritePacket(packet []byte) error {
+	if len(packet) > UDP_MAX_PACKET_SIZE {
+		return ErrUDPPacketTooLarge
+	}
+
+	if !p.config.Reliable {
+		select {
+		case <-p.closed:
+			return p.closeErr
+		default:
+		}
+
+		return p.send(encodeUDPDatagram(udpDatagramData, p.sessionID, 0, packet))
+	}
+
+	p.mutex.Lock()
+	for len(p.unacked) >= p.config.WindowSize && p.closeErr == nil {
+		p.cond.Wait()
+	}
+
+	if p.closeErr != nil {
+		p.mutex.Unlock()
+		return p.closeErr
+	}
+
+	seq := p.nextSeq
+	p.nextSeq++
+
+	datagram := encodeUDPDatagram(udpDatagramData, p.sessionID, seq, packet)
+	p.unacked[seq] = &udpPendingPacket{datagram: datagram, sentAt: time.Now()}
+	p.mutex.Unlock()
+
+	return p.send(datagram)
+}
+
+// handleDatagram processes the datagram of the portal session,
+// body is the datagram without the header.
+func (p *UDPPortal) handleDatagram(kind byte, seq uint32, body []byte) {
+	switch kind {
+	case udpDatagramData:
+		p.handleData(seq, body)
+	case udpDatagramAck:
+		p.handleAck(seq, body, false)
+	case udpDatagramBusy:
+		p.handleAck(seq, body, true)
+	case udpDatagramClose:
+		p.shutdown(io.EOF)
+	}
+}
+
+func (p *UDPPortal) handleData(seq uint32, body []byte) {
+	packet := append([]byte{}, body...)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.config.Reliable {
+		if len(p.received) < DEFAULT_UDP_RECEIVE_QUEUE_SIZE {
+			p.enqueue(packet)
+		}
+
+		return
+	}
+
+	// packets before the expected one are duplicates,
+	// they are acknowledged again as the previous ack could be lost,
+	// packets within the window are kept until they can be delivered
+	isWithinWindow := seq >= p.expectedSeq && seq-p.expectedSeq <= MAX_UDP_WINDOW_SIZE
+	if isWithinWindow {
+		p.outOfOrder[seq] = packet
+		p.deliverOutOfOrder()
+	}
+
+	p.sendAck()
+}
+
+// deliverOutOfOrder moves the packets following the delivered ones
+// to the received queue, as long as there is a space for them.
+func (p *UDPPortal) deliverOutOfOrder() {
+	for len(p.received) < DEFAULT_UDP_RECEIVE_QUEUE_SIZE {
+		packet, exists := p.outOfOrder[p.expectedSeq]
+		if !exists {
+			return
+		}
+
+		delete(p.outOfOrder, p.expectedSeq)
+		p.enqueue(packet)
+		p.expectedSeq++
+	}
+}
+
+func (p *UDPPortal) enqueue(packet []byte) {
+	p.received = append(p.received, packet)
+
+	select {
+	case p.readable <- struct{}{}:
+	default:
+	}
+}
+
+// sendAck acknowledges every packet before the expected one and selectively
+// the packets received ahead of it, bit N of the bitmap stands for
+// the packet expected + 1 + N. When the expected packet is kept
+// because the received queue is full, the busy datagram without
+// the selective acknowledgements is sent instead, so the sender
+// stops sending new packets but knows the peer is reachable.
+func (p *UDPPortal) sendAck() {
+	if _, kept := p.outOfOrder[p.expectedSeq]; kept {
+		p.send(encodeUDPDatagram(udpDatagramBusy, p.sessionID, p.expectedSeq, binary.BigEndian.AppendUint64(nil, 0)))
+		return
+	}
+
+	var bitmap uint64
+	for seq := range p.outOfOrder {
+		bitmap |= 1 << (seq - p.expectedSeq - 1)
+	}
+
+	p.send(encodeUDPDatagram(udpDatagramAck, p.sessionID, p.expectedSeq, binary.BigEndian.AppendUint64(nil, bitmap)))
+}
+
+// handleAck removes the acknowledged packets, busy peer is reachable
+// but slow, so the retransmissions of the remaining packets are not counted
+// until they wait for the peer longer than the maximum busy time.
+func (p *UDPPortal) handleAck(expectedSeq uint32, body []byte, busy bool) {
+	if len(body) != 8 {
+		return
+	}
+
+	bitmap := binary.BigEndian.Uint64(body)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for seq := range p.unacked {
+		isSelectivelyAcked := seq > expectedSeq && seq-expectedSeq <= MAX_UDP_WINDOW_SIZE && bitmap&(1<<(seq-expectedSeq-1)) != 0
+		if seq < expectedSeq || isSelectivelyAcked {
+			delete(p.unacked, seq)
+		} else if busy {
+			pending := p.unacked[seq]
+			if pending.busySince.IsZero() {
+				pending.busySince = time.Now()
+			}
+
+			if time.Since(pending.busySince) < p.config.MaxBusyTime {
+				pending.retries = 0
+			}
+		}
+	}
+
+	p.cond.Broadcast()
+}
+
+func (p *UDPPortal) runRetransmissions() {
+	ticker := time.NewTicker(p.config.RetransmissionTimeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.closed:
+			return
+		case <-ticker.C:
+		}
+
+		if unreachable := p.retransmit(); unreachable {
+			p.shutdown(ErrUDPPeerUnreachable)
+			return
+		}
+	}
+}
+
+func (p *UDPPortal) retransmit() (unreachable bool) {
+	p.mutex.Lock()
+
+	datagrams := make([][]byte, 0)
+	for _, pending := range p.unacked {
+		if time.Since(pending.sentAt) < p.config.RetransmissionTimeout {
+			continue
+		}
+
+		if pending.retries >= p.config.MaxRetransmissions {
+			p.mutex.Unlock()
+			return true
+		}
+
+		pending.retries++
+		pending.sentAt = time.Now()
+		datagrams = append(datagrams, pending.datagram)
+	}
+
+	p.mutex.Unlock()
+
+	for _, datagram := range datagrams {
+		p.send(datagram)
+	}
+
+	return false
+}
+
+func encodeUDPDatagram(kind byte, sessionID uint64, seq uint32, body []byte) []byte {
+	datagram := make([]byte, 0, udpHeaderSize+len(body))
+	datagram = append(datagram, kind)
+	datagram = binary.BigEndian.AppendUint64(datagram, sessionID)
+	datagram = binary.BigEndian.AppendUint32(datagram, seq)
+	return append(datagram, body...)
+}
+
+func decodeUDPDatagram(datagram []byte) (kind byte, sessionID uint64, seq uint32, body []byte, ok bool) {
+	if len(datagram) < udpHeaderSize {
+		return 0, 0, 0, nil, false
+	}
+
+	kind = datagram[0]
+	sessionID = binary.BigEndian.Uint64(datagram[1:9])
+	seq = binary.BigEndian.Uint32(datagram[9:13])
+	return kind, sessionID, seq, datagram[udpHeaderSize:], true
+}
+
+func UDPConnect(addr string, config UDPConfig) (*UDPPortal, error) {
+	remote, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.DialUDP("udp", nil, remote)
+	if err != nil {
+		return nil, err
+	}
+
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	sessionID := binary.BigEndian.Uint64(id[:])
+	send := func(datagram []byte) error {
+		_, err := conn.Write(datagram)
+		return err
+	}
+
+	portal := newUDPPortal(sessionID, config, send, func() { conn.Close() })
+
+	go func() {
+		buffer := make([]byte, udpMaxDatagramSize)
+
+		for {
+			n, err := conn.Read(buffer)
+			if errors.Is(err, net.ErrClosed) {
+				portal.shutdown(net.ErrClosed)
+				return
+			}
+
+			// errors caused by the ICMP messages, e.g. when the listener
+			// is not started yet, are not fatal for the connectionless socket
+			if err != nil {
+				continue
+			}
+
+			kind, datagramSessionID, seq, body, ok := decodeUDPDatagram(buffer[:n])
+			if ok && datagramSessionID == sessionID {
+				portal.handleDatagram(kind, seq, body)
+			}
+		}
+	}()
+
+	return portal, nil
+}
+
+// UDPListen accepts the sessions until the context is done,
+// every new session is added to the node as the listening edge.
+func UDPListen(ctx context.Context, addr string, dmq directmq.NetworkNode, config UDPConfig) error {
+	local, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.ListenUDP("udp", local)
+	if err != nil {
+		return err
+	}
+
+	return serveUDP(ctx, conn, config, func(portal *UDPPortal) {
+		go dmq.AddListeningEdge(portal)
+	})
+}
+
+type udpSession struct {
+	portal *UDPPortal
+	addr   *net.UDPAddr
+}
+
+func serveUDP(ctx context.Context, conn *net.UDPConn, config UDPConfig, accept func(portal *UDPPortal)) error {
+	defer conn.Close()
+
+	stopped := make(chan struct{})
+	defer close(stopped)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stopped:
+		}
+	}()
+
+	sessionsMutex := sync.Mutex{}
+	sessions := make(map[uint64]*udpSession)
+
+	defer func() {
+		sessionsMutex.Lock()
+		closing := make([]*UDPPortal, 0, len(sessions))
+		for _, session := range sessions {
+			closing = append(closing, session.portal)
+		}
+		sessionsMutex.Unlock()
+
+		for _, portal := range closing {
+			portal.shutdown(net.ErrClosed)
+		}
+	}()
+
+	buffer := make([]byte, udpMaxDatagramSize)
+
+	for {
+		n, from, err := conn.ReadFromUDP(buffer)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
+
+			continue
+		}
+
+		kind, sessionID, seq, body, ok := decodeUDPDatagram(buffer[:n])
+		if !ok {
+			continue
+		}
+
+		sessionsMutex.Lock()
+		session, exists := sessions[sessionID]
+
+		// only the first packet of the session starts it, so the late
+		// datagrams of the already closed session are not mistaken for a new one
+		isFirstPacket := kind == udpDatagramData && (seq == 1 || !config.Reliable)
+
+		if !exists && isFirstPacket {
+			session = &udpSession{addr: from}
+			session.portal = newUDPPortal(sessionID, config, func(datagram []byte) error {
+				sessionsMutex.Lock()
+				addr := session.addr
+				sessionsMutex.Unlock()
+
+				_, err := conn.WriteToUDP(datagram, addr)
+				return err
+			}, func() {
+				sessionsMutex.Lock()
+				delete(sessions, sessionID)
+				sessionsMutex.Unlock()
+			})
+
+			sessions[sessionID] = session
+			accept(session.portal)
+		} else if exists {
+			// peer may change its source address during the session
+			session.addr = from
+		}
+		sessionsMutex.Unlock()
+
+		if session != nil {
+			session.portal.handleDatagram(kind, seq, body)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package dmqportals
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// lossyUDPProxy forwards the datagrams between the client and the server
+// dropping some of them, the source port of the datagrams sent
+// to the server changes after every rebind, like behind the NAT.
+type lossyUDPProxy struct {
+	mutex    sync.Mutex
+	random   *rand.Rand
+	lossRate float64
+
+	listener *net.UDPConn
+	upstream *net.UDPConn
+	server   *net.UDPAddr
+	client   *net.UDPAddr
+}
+
+func newLossyUDPProxy(server *net.UDPAddr, lossRate float64) *lossyUDPProxy {
+	listener, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	Expect(err).ToNot(HaveOccurred())
+
+	proxy := &lossyUDPProxy{
+		random:   rand.New(rand.NewSource(GinkgoRandomSeed())),
+		lossRate: lossRate,
+		listener: listener,
+		server:   server,
+	}
+
+	proxy.Rebind()
+	go proxy.forwardToServer()
+
+	return proxy
+}
+
+func (p *lossyUDPProxy) Addr() string {
+	return p.listener.LocalAddr().String()
+}
+
+func (p *lossyUDPProxy) Rebind() {
+	upstream, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	Expect(err).ToNot(HaveOccurred())
+
+	p.mutex.Lock()
+	previous := p.upstream
+	p.upstream = upstream
+	p.mutex.Unlock()
+
+	if previous != nil {
+		previous.Close()
+	}
+
+	go p.forwardToClient(upstream)
+}
+
+func (p *lossyUDPProxy) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.listener.Close()
+	p.upstream.Close()
+}
+
+func (p *lossyUDPProxy) shouldDrop() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.random.Float64() < p.lossRate
+}
+
+func (p *lossyUDPProxy) forwardToServer() {
+	buffer := make([]byte, udpMaxDatagramSize)
+
+	for {
+		n, from, err := p.listener.ReadFromUDP(buffer)
+		if err != nil {
+			return
+		}
+
+		p.mutex.Lock()
+		p.client = from
+		upstream := p.upstream
+		p.mutex.Unlock()
+
+		if !p.shouldDrop() {
+			upstream.WriteToUDP(buffer[:n], p.server)
+		}
+	}
+}
+
+func (p *lossyUDPProxy) forwardToClient(upstream *net.UDPConn) {
+	buffer := make([]byte, udpMaxDatagramSize)
+
+	for {
+		n, _, err := upstream.ReadFromUDP(buffer)
+		if err != nil {
+			return
+		}
+
+		p.mutex.Lock()
+		client := p.client
+		p.mutex.Unlock()
+
+		if !p.shouldDrop() {
+			p.listener.WriteToUDP(buffer[:n], client)
+		}
+	}
+}
+
+var _ = Describe("UDPPortal", func() {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	var serverAddr *net.UDPAddr
+	var accepted chan *UDPPortal
+
+	reliable := UDPConfig{
+		Reliable:              true,
+		RetransmissionTimeout: 20 * time.Millisecond,
+		MaxRetransmissions:    50,
+	}
+
+	listen := func(config UDPConfig) {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+
+		serverAddr = conn.LocalAddr().(*net.UDPAddr)
+		accepted = make(chan *UDPPortal, 4)
+
+		go serveUDP(ctx, conn, config, func(portal *UDPPortal) { accepted <- portal })
+	}
+
+	exchange := func(client *UDPPortal, count int) (server *UDPPortal) {
+		go func() {
+			defer GinkgoRecover()
+			for i := 0; i < count; i++ {
+				Expect(client.WritePacket([]byte(fmt.Sprint(i)))).To(Succeed())
+			}
+		}()
+
+		Eventually(accepted).Should(Receive(&server))
+
+		for i := 0; i < count; i++ {
+			packet, err := server.ReadPacket()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(packet)).To(Equal(fmt.Sprint(i)))
+		}
+
+		return server
+	}
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("should deliver the packets without reliability", func() {
+		listen(UDPConfig{})
+
+		client, err := UDPConnect(serverAddr.String(), UDPConfig{})
+		Expect(err).ToNot(HaveOccurred())
+		defer client.Close()
+
+		var server *UDPPortal
+		Expect(client.WritePacket([]byte("hello"))).To(Succeed())
+		Eventually(accepted).Should(Receive(&server))
+
+		packet, err := server.ReadPacket()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(packet).To(Equal([]byte("hello")))
+	})
+
+	It("should deliver every packet in order despite the loss", func() {
+		listen(reliable)
+		proxy := newLossyUDPProxy(serverAddr, 0.3)
+		defer proxy.Close()
+
+		client, err := UDPConnect(proxy.Addr(), reliable)
+		Expect(err).ToNot(HaveOccurred())
+		defer client.Close()
+
+		server := exchange(client, 200)
+
+		go func() {
+			defer GinkgoRecover()
+			Expect(server.WritePacket([]byte("reply"))).To(Succeed())
+		}()
+
+		packet, err := client.ReadPacket()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(packet).To(Equal([]byte("reply")))
+	})
+
+	It("should keep the session when the source port changes", func() {
+		listen(reliable)
+		proxy := newLossyUDPProxy(serverAddr, 0)
+		defer proxy.Close()
+
+		client, err := UDPConnect(proxy.Addr(), reliable)
+		Expect(err).ToNot(HaveOccurred())
+		defer client.Close()
+
+		server := exchange(client, 10)
+		proxy.Rebind()
+
+		Expect(client.WritePacket([]byte("after rebind"))).To(Succeed())
+		packet, err := server.ReadPacket()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(packet).To(Equal([]byte("after rebind")))
+
+		Expect(server.WritePacket([]byte("reply"))).To(Succeed())
+		packet, err = client.ReadPacket()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(packet).To(Equal([]byte("reply")))
+
+		Consistently(accepted).ShouldNot(Receive())
+	})
+
+	It("should propagate the close to the other side", func() {
+		listen(reliable)
+
+		client, err := UDPConnect(serverAddr.String(), reliable)
+		Expect(err).ToNot(HaveOccurred())
+
+		server := exchange(client, 1)
+		Expect(client.Close()).To(Succeed())
+
+		_, err = server.ReadPacket()
+		Expect(err).To(MatchError(io.EOF))
+
+		_, err = client.ReadPacket()
+		Expect(err).To(MatchError(net.ErrClosed))
+	})
+
+	It("should close the portal when the peer does not acknowledge packets", func() {
+		client, err := UDPConnect("127.0.0.1:9", UDPConfig{
+			Reliable:              true,
+			RetransmissionTimeout: 10 * time.Millisecond,
+			MaxRetransmissions:    3,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(client.WritePacket([]byte("lost"))).To(Succeed())
+
+		_, err = client.ReadPacket()
+		Expect(err).To(MatchError(ErrUDPPeerUnreachable))
+	})
+
+	It("should keep the packets and the session while the reader is slow", func() {
+		config := UDPConfig{
+			Reliable:              true,
+			RetransmissionTimeout: 10 * time.Millisecond,
+			MaxRetransmissions:    3,
+		}
+
+		listen(config)
+
+		client, err := UDPConnect(serverAddr.String(), config)
+		Expect(err).ToNot(HaveOccurred())
+		defer client.Close()
+
+		count := DEFAULT_UDP_RECEIVE_QUEUE_SIZE + 2*DEFAULT_UDP_WINDOW_SIZE
+		written := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			for i := 0; i < count; i++ {
+				Expect(client.WritePacket([]byte(fmt.Sprint(i)))).To(Succeed())
+			}
+			close(written)
+		}()
+
+		var server *UDPPortal
+		Eventually(accepted).Should(Receive(&server))
+
+		// the full received queue blocks the writes of the client
+		// for much longer than the retransmissions would allow
+		Consistently(written, 300*time.Millisecond).ShouldNot(BeClosed())
+
+		for i := 0; i < count; i++ {
+			packet, err := server.ReadPacket()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(packet)).To(Equal(fmt.Sprint(i)))
+		}
+
+		Eventually(written).Should(BeClosed())
+	})
+
+	It("should close the portal when the reader of the peer never drains its queue", func() {
+		config := UDPConfig{
+			Reliable:              true,
+			RetransmissionTimeout: 10 * time.Millisecond,
+			MaxRetransmissions:    3,
+			MaxBusyTime:           200 * time.Millisecond,
+		}
+
+		listen(config)
+
+		client, err := UDPConnect(serverAddr.String(), config)
+		Expect(err).ToNot(HaveOccurred())
+		defer client.Close()
+
+		go func() {
+			for i := 0; i < DEFAULT_UDP_RECEIVE_QUEUE_SIZE+DEFAULT_UDP_WINDOW_SIZE; i++ {
+				if client.WritePacket([]byte(fmt.Sprint(i))) != nil {
+					return
+				}
+			}
+		}()
+
+		var server *UDPPortal
+		Eventually(accepted).Should(Receive(&server))
+		defer server.Close()
+
+		_, err = client.ReadPacket()
+		Expect(err).To(MatchError(ErrUDPPeerUnreachable))
+	})
+
+	It("should bridge the nodes over the lossy link", func() {
+		listen(reliable)
+		proxy := newLossyUDPProxy(serverAddr, 0.2)
+		defer proxy.Close()
+
+		server, client := newTestNode("server"), newTestNode("client")
+		defer client.CloseNode("test finished")
+
+		go func() {
+			for portal := range accepted {
+				go server.AddListeningEdge(portal)
+			}
+		}()
+
+		portal, err := UDPConnect(proxy.Addr(), reliable)
+		Expect(err).ToNot(HaveOccurred())
+		go client.AddConnectingEdge(portal)
+
+		Eventually(server.GetBridgedNodeIDs).Should(Equal([]string{"client"}))
+		Eventually(client.GetBridgedNodeIDs).Should(Equal([]string{"server"}))
+	})
+})
@@ -0,0 +1,32 @@
+//go:build linux
+
+package dmqportals
+
+import (
+	"net"
+	"syscall"
+)
+
+func getUnixPeerCredentials(conn *net.UnixConn) (UnixPeerCredentials, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return UnixPeerCredentials{}, err
+	}
+
+	var ucred *syscall.Ucred
+	var ucredErr error
+
+	err = raw.Control(func(fd uintptr) {
+		ucred, ucredErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	})
+
+	if err != nil {
+		return UnixPeerCredentials{}, err
+	}
+
+	if ucredErr != nil {
+		return UnixPeerCredentials{}, ucredErr
+	}
+
+	return UnixPeerCredentials{PID: ucred.Pid, UID: ucred.Uid, GID: ucred.Gid}, nil
+}
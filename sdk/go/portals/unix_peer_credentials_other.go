@@ -0,0 +1,9 @@
+//go:build !linux
+
+package dmqportals
+
+import "net"
+
+func getUnixPeerCredentials(conn *net.UnixConn) (UnixPeerCredentials, error) {
+	return UnixPeerCredentials{}, ErrUnixPeerCredentialsUnsupported
+}
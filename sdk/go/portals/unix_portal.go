@@ -0,0 +1,90 @@
+package dmqportals
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+)
+
+// Largest packet accepted by the unix socket portal.
+const UNIX_MAX_PACKET_SIZE = 16 * 1024 * 1024
+
+var ErrUnixPacketTooLarge = errors.New("unix socket packet exceeds the maximum packet size")
+var ErrUnixPeerCredentialsUnsupported = errors.New("unix socket peer credentials are not supported on this platform")
+
+// UnixPeerCredentials identifies the process on the other side
+// of the unix socket, as reported by the kernel at connection time.
+type UnixPeerCredentials struct {
+	PID int32
+	UID uint32
+	GID uint32
+}
+
+// UnixAuthorizer decides if the connecting process may join the node,
+// the connection is closed when the returned error is not nil.
+type UnixAuthorizer func(credentials UnixPeerCredentials) error
+
+// UnixPortal sends packets over the unix domain stream socket,
+// framed the same way as by the TCPPortal.
+type UnixPortal struct {
+	*connPortal
+
+	unixConn *net.UnixConn
+}
+
+var _ directmq.Portal = (*UnixPortal)(nil)
+
+func NewUnixPortal(conn *net.UnixConn) *UnixPortal {
+	return &UnixPortal{
+		connPortal: newConnPortal(conn, UNIX_MAX_PACKET_SIZE, ErrUnixPacketTooLarge),
+		unixConn:   conn,
+	}
+}
+
+// PeerCredentials returns the credentials of the process on the other
+// side of the socket, it is supported only on Linux (SO_PEERCRED).
+func (p *UnixPortal) PeerCredentials() (UnixPeerCredentials, error) {
+	return getUnixPeerCredentials(p.unixConn)
+}
+
+func UnixConnect(path string) (*UnixPortal, error) {
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewUnixPortal(conn), nil
+}
+
+// UnixListen accepts the connections on the socket file until the context
+// is done, every accepted connection is added to the node as the listening
+// edge. When authorize is not nil, it is called with the peer credentials
+// of every connection before it is added to the node.
+func UnixListen(ctx context.Context, path string, dmq directmq.NetworkNode, authorize UnixAuthorizer) error {
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		return err
+	}
+
+	return serveListener(ctx, listener, func(conn net.Conn) {
+		go acceptUnixConnection(NewUnixPortal(conn.(*net.UnixConn)), dmq, authorize)
+	})
+}
+
+func acceptUnixConnection(portal *UnixPortal, dmq directmq.NetworkNode, authorize UnixAuthorizer) {
+	if authorize != nil {
+		credentials, err := portal.PeerCredentials()
+		if err == nil {
+			err = authorize(credentials)
+		}
+
+		if err != nil {
+			portal.Close()
+			return
+		}
+	}
+
+	dmq.AddListeningEdge(portal)
+}
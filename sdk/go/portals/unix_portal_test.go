@@ -0,0 +1,94 @@
+package dmqportals
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UnixPortal", func() {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	var path string
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		path = filepath.Join(GinkgoT().TempDir(), "dmq.sock")
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	connect := func() (portal *UnixPortal) {
+		Eventually(func() (err error) {
+			portal, err = UnixConnect(path)
+			return
+		}).Should(Succeed())
+
+		return portal
+	}
+
+	It("should bridge the nodes over the unix socket", func() {
+		server, client := newTestNode("server"), newTestNode("client")
+		defer client.CloseNode("test finished")
+
+		go UnixListen(ctx, path, server, nil)
+		go client.AddConnectingEdge(connect())
+
+		Eventually(server.GetBridgedNodeIDs).Should(Equal([]string{"client"}))
+		Eventually(client.GetBridgedNodeIDs).Should(Equal([]string{"server"}))
+	})
+
+	It("should expose the credentials of the connecting process", func() {
+		if runtime.GOOS != "linux" {
+			Skip("peer credentials are supported only on Linux")
+		}
+
+		credentials := make(chan UnixPeerCredentials, 1)
+		go UnixListen(ctx, path, newTestNode("server"), func(peer UnixPeerCredentials) error {
+			credentials <- peer
+			return nil
+		})
+
+		portal := connect()
+		defer portal.Close()
+
+		Eventually(credentials).Should(Receive(Equal(UnixPeerCredentials{
+			PID: int32(os.Getpid()),
+			UID: uint32(os.Getuid()),
+			GID: uint32(os.Getgid()),
+		})))
+	})
+
+	It("should close the connection rejected by the authorizer", func() {
+		server := newTestNode("server")
+
+		go UnixListen(ctx, path, server, func(UnixPeerCredentials) error {
+			return errors.New("not allowed")
+		})
+
+		portal := connect()
+		defer portal.Close()
+
+		_, err := portal.ReadPacket()
+		Expect(err).To(HaveOccurred())
+		Expect(server.GetEdges()).To(BeEmpty())
+	})
+
+	It("should stop listening and remove the socket file when the context is done", func() {
+		result := make(chan error, 1)
+		go func() { result <- UnixListen(ctx, path, newTestNode("server"), nil) }()
+
+		Eventually(func() error { _, err := os.Stat(path); return err }).Should(Succeed())
+
+		cancel()
+		Eventually(result).Should(Receive(BeNil()))
+		Expect(path).ToNot(BeAnExistingFile())
+	})
+})
@@ -2,9 +2,13 @@ package dmqportals
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 
 	directmq "github.com/sync-toys/DirectMQ/sdk/go"
 
@@ -18,93 +22,225 @@ const (
 	BinaryMessages              = websocket.BinaryMessage
 )
 
-type WebsocketPortal struct {
-	c      *websocket.Conn
-	closed bool
+const (
+	DEFAULT_WEBSOCKET_PING_INTERVAL = 20 * time.Second
+	DEFAULT_WEBSOCKET_PONG_TIMEOUT  = 45 * time.Second
+	DEFAULT_WEBSOCKET_WRITE_TIMEOUT = 10 * time.Second
+)
+
+var ErrWebsocketUnexpectedMessageType = errors.New("unexpected websocket message type")
+
+type WebsocketPortalConfig struct {
+	// Interval of the pings sent to the peer, negative
+	// disables the pings and the read deadlines.
+	// DEFAULT_WEBSOCKET_PING_INTERVAL is used when zero.
+	PingInterval time.Duration
 
+	// The peer is considered dead when nothing, not even the pong,
+	// was received for this time, it should be longer than
+	// the ping interval. DEFAULT_WEBSOCKET_PONG_TIMEOUT
+	// is used when zero.
+	PongTimeout time.Duration
+
+	// DEFAULT_WEBSOCKET_WRITE_TIMEOUT is used when zero.
+	WriteTimeout time.Duration
+
+	// Per-message deflate is used when both peers enable it.
+	EnableCompression bool
+
+	// Largest incoming message, reading the larger one fails.
+	// It should fit the HostMaxIncomingMessageSize announced
+	// by the node, see WebsocketReadLimit. Zero means no limit.
+	ReadLimit uint64
+}
+
+// Room left by WebsocketReadLimit for the rest of the frame carrying
+// the payload: the topic, traversed node IDs, trace context and
+// the protobuf encoding overhead.
+const WEBSOCKET_FRAME_OVERHEAD = 64 * 1024
+
+// WebsocketReadLimit returns the read limit matching the largest
+// message the node announces it is able to receive. The bridged node
+// limits the size of the payload, not of the encoded frame, so the limit
+// leaves WEBSOCKET_FRAME_OVERHEAD for the rest of the frame, and room
+// for the base64 encoding of the payload in the text messages.
+func WebsocketReadLimit(config directmq.NetworkNodeConfig, messagesType MessagesType) uint64 {
+	if config.HostMaxIncomingMessageSize == directmq.NO_MAX_MESSAGE_SIZE {
+		return 0
+	}
+
+	size := config.HostMaxIncomingMessageSize
+	if messagesType == TextMessages {
+		size = (size + 2) / 3 * 4
+	}
+
+	return size + WEBSOCKET_FRAME_OVERHEAD
+}
+
+// WebsocketPortal sends every packet as the single websocket message.
+// Writes are serialized, so the portal can be used from many goroutines,
+// and the peer is pinged to detect the dead connections.
+type WebsocketPortal struct {
+	c            *websocket.Conn
 	messagesType MessagesType
+	config       WebsocketPortalConfig
+
+	writeMutex sync.Mutex
+
+	closed    chan struct{}
+	closeOnce sync.Once
+	closeErr  error
 }
 
 var _ directmq.Portal = (*WebsocketPortal)(nil)
 
-func (p *WebsocketPortal) Close() error {
-	if p.closed {
-		return nil
+func newWebsocketPortal(c *websocket.Conn, messagesType MessagesType, config WebsocketPortalConfig) *WebsocketPortal {
+	if config.PingInterval == 0 {
+		config.PingInterval = DEFAULT_WEBSOCKET_PING_INTERVAL
 	}
 
-	p.closed = true
+	if config.PongTimeout <= 0 {
+		config.PongTimeout = DEFAULT_WEBSOCKET_PONG_TIMEOUT
+	}
 
-	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
-	if err := p.c.WriteMessage(websocket.CloseMessage, msg); err != nil {
-		p.c.Close()
-		return err
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = DEFAULT_WEBSOCKET_WRITE_TIMEOUT
 	}
 
-	return p.c.Close()
-}
+	portal := &WebsocketPortal{
+		c:            c,
+		messagesType: messagesType,
+		config:       config,
+		closed:       make(chan struct{}),
+	}
 
-func (p *WebsocketPortal) ReadPacket() ([]byte, error) {
-	messageType, data, err := p.c.ReadMessage()
+	if config.ReadLimit > 0 {
+		c.SetReadLimit(int64(config.ReadLimit))
+	}
+
+	c.EnableWriteCompression(config.EnableCompression)
+
+	if config.PingInterval > 0 {
+		portal.extendReadDeadline()
+		c.SetPongHandler(func(string) error {
+			portal.extendReadDeadline()
+			return nil
+		})
 
-	if websocket.IsCloseError(err, websocket.CloseNormalClosure) || websocket.IsUnexpectedCloseError(err) {
-		p.closed = true
+		go portal.pingLoop()
 	}
 
+	return portal
+}
+
+func (p *WebsocketPortal) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.closed)
+
+		// the peer may be already gone, so the failure
+		// of the close message is not reported
+		msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+		p.c.WriteControl(websocket.CloseMessage, msg, time.Now().Add(p.config.WriteTimeout))
+
+		p.closeErr = p.c.Close()
+	})
+
+	return p.closeErr
+}
+
+func (p *WebsocketPortal) ReadPacket() ([]byte, error) {
+	messageType, data, err := p.c.ReadMessage()
 	if err != nil {
 		return nil, err
 	}
 
 	if messageType != int(p.messagesType) {
-		panic("Unexpected message type")
+		return nil, ErrWebsocketUnexpectedMessageType
+	}
+
+	// the busy peer may send the pong only after the data
+	if p.config.PingInterval > 0 {
+		p.extendReadDeadline()
 	}
 
 	return data, nil
 }
 
 func (p *WebsocketPortal) WritePacket(packet []byte) error {
-	err := p.c.WriteMessage(int(p.messagesType), packet)
+	p.writeMutex.Lock()
+	defer p.writeMutex.Unlock()
 
-	if websocket.IsCloseError(err, websocket.CloseNormalClosure) || websocket.IsUnexpectedCloseError(err) {
-		p.closed = true
+	if err := p.c.SetWriteDeadline(time.Now().Add(p.config.WriteTimeout)); err != nil {
+		return err
 	}
 
-	return err
+	return p.c.WriteMessage(int(p.messagesType), packet)
 }
 
-func WebsocketConnect(u *url.URL, messagesType MessagesType) (*WebsocketPortal, error) {
-	c, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
-	if err != nil {
-		return nil, err
-	}
-
-	return &WebsocketPortal{c: c, messagesType: messagesType}, nil
+func (p *WebsocketPortal) extendReadDeadline() {
+	p.c.SetReadDeadline(time.Now().Add(p.config.PongTimeout))
 }
 
-func WebsocketListen(u *url.URL, messagesType MessagesType, dmq directmq.NetworkNode, ctx context.Context) error {
-	var upgrader = websocket.Upgrader{}
+func (p *WebsocketPortal) pingLoop() {
+	ticker := time.NewTicker(p.config.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// control messages can be written concurrently with the data
+			err := p.c.WriteControl(websocket.PingMessage, nil, time.Now().Add(p.config.WriteTimeout))
+			if err != nil {
+				return
+			}
 
-	mux := http.NewServeMux()
-	mux.HandleFunc(u.Path, func(w http.ResponseWriter, r *http.Request) {
-		c, err := upgrader.Upgrade(w, r, nil)
-		if err != nil {
+		case <-p.closed:
 			return
 		}
+	}
+}
 
-		portal := &WebsocketPortal{c: c, messagesType: messagesType}
-		dmq.AddListeningEdge(portal)
-	})
+type WebsocketClientConfig struct {
+	MessagesType MessagesType
+	Portal       WebsocketPortalConfig
+
+	TLSConfig *tls.Config
+
+	// Header is sent with the upgrade request, e.g. to authenticate.
+	Header http.Header
+}
+
+func WebsocketConnect(u *url.URL, messagesType MessagesType) (*WebsocketPortal, error) {
+	return WebsocketConnectWithConfig(u, WebsocketClientConfig{MessagesType: messagesType})
+}
+
+func WebsocketConnectWithConfig(u *url.URL, config WebsocketClientConfig) (*WebsocketPortal, error) {
+	dialer := *websocket.DefaultDialer
+	dialer.TLSClientConfig = config.TLSConfig
+	dialer.EnableCompression = config.Portal.EnableCompression
 
-	server := &http.Server{
-		Addr:    u.Host,
-		Handler: mux,
+	c, _, err := dialer.Dial(u.String(), config.Header)
+	if err != nil {
+		return nil, err
 	}
 
+	return newWebsocketPortal(c, config.MessagesType, config.Portal), nil
+}
+
+// WebsocketListen serves the websocket portal on the path of the URL
+// until the context is done, the connected edges are gracefully closed.
+//
+// Deprecated: use the WebsocketServer, which supports TLS, origin checks,
+// authentication and connection limits.
+func WebsocketListen(u *url.URL, messagesType MessagesType, dmq directmq.NetworkNode, ctx context.Context) error {
+	server := NewWebsocketServer(dmq, WebsocketServerConfig{MessagesType: messagesType})
+
 	go func() {
 		<-ctx.Done()
 		server.Shutdown(context.Background())
 	}()
 
-	return server.ListenAndServe()
+	return server.ListenAndServe(u)
 }
 
 func IsNetworkConnectionClosedError(err error) bool {
@@ -0,0 +1,219 @@
+package dmqportals
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WebsocketPortal", func() {
+	// startWebsocketPeer returns the URL of the server
+	// passing every accepted raw connection to the channel
+	startWebsocketPeer := func(compression bool) (*url.URL, chan *websocket.Conn) {
+		connections := make(chan *websocket.Conn, 1)
+		upgrader := websocket.Upgrader{EnableCompression: compression}
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c, err := upgrader.Upgrade(w, r, nil)
+			if err == nil {
+				connections <- c
+			}
+		}))
+
+		DeferCleanup(server.Close)
+
+		u, err := url.Parse("ws" + strings.TrimPrefix(server.URL, "http"))
+		Expect(err).ToNot(HaveOccurred())
+		return u, connections
+	}
+
+	connect := func(config WebsocketPortalConfig) (*WebsocketPortal, *WebsocketPortal) {
+		u, connections := startWebsocketPeer(config.EnableCompression)
+
+		client, err := WebsocketConnectWithConfig(u, WebsocketClientConfig{MessagesType: BinaryMessages, Portal: config})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(client.Close)
+
+		var c *websocket.Conn
+		Eventually(connections).Should(Receive(&c))
+
+		server := newWebsocketPortal(c, BinaryMessages, config)
+		DeferCleanup(server.Close)
+
+		return client, server
+	}
+
+	It("should serialize the concurrent writes", func() {
+		client, server := connect(WebsocketPortalConfig{})
+
+		const writers, packets = 8, 50
+
+		var wg sync.WaitGroup
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func(writer byte) {
+				defer wg.Done()
+				for j := 0; j < packets; j++ {
+					Expect(client.WritePacket([]byte{writer, byte(j)})).To(Succeed())
+				}
+			}(byte(i))
+		}
+
+		received := make(map[byte]int)
+		for i := 0; i < writers*packets; i++ {
+			packet, err := server.ReadPacket()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(packet[1]).To(Equal(byte(received[packet[0]])))
+			received[packet[0]]++
+		}
+
+		wg.Wait()
+	})
+
+	It("should return the error on the unexpected message type", func() {
+		u, connections := startWebsocketPeer(false)
+
+		client, err := WebsocketConnect(u, BinaryMessages)
+		Expect(err).ToNot(HaveOccurred())
+		defer client.Close()
+
+		var peer *websocket.Conn
+		Eventually(connections).Should(Receive(&peer))
+		defer peer.Close()
+
+		Expect(peer.WriteMessage(websocket.TextMessage, []byte("text"))).To(Succeed())
+
+		_, err = client.ReadPacket()
+		Expect(err).To(MatchError(ErrWebsocketUnexpectedMessageType))
+	})
+
+	DescribeTable("should deliver the payload of the largest size announced by the node",
+		func(messagesType MessagesType, protocol func() directmq.ProtocolFactory) {
+			const maxSize = 1024
+
+			config := directmq.NetworkNodeConfig{
+				HostID:                     "server",
+				HostTTL:                    directmq.DEFAULT_TTL,
+				HostMaxIncomingMessageSize: maxSize,
+			}
+
+			server := directmq.NewNetworkNode(config, protocol())
+			client := directmq.NewNetworkNode(directmq.NetworkNodeConfig{HostID: "client", HostTTL: directmq.DEFAULT_TTL}, protocol())
+			defer server.CloseNode("test finished")
+			defer client.CloseNode("test finished")
+
+			httpServer := httptest.NewServer(NewWebsocketServer(server, WebsocketServerConfig{
+				MessagesType: messagesType,
+				Portal:       WebsocketPortalConfig{ReadLimit: WebsocketReadLimit(config, messagesType)},
+			}))
+			defer httpServer.Close()
+
+			u, err := url.Parse("ws" + strings.TrimPrefix(httpServer.URL, "http"))
+			Expect(err).ToNot(HaveOccurred())
+
+			lost := make(chan string, 1)
+			server.OnConnectionLost(func(bridgedNodeID, reason string, portal directmq.Portal) {
+				lost <- reason
+			})
+
+			received := make(chan []byte, 1)
+			server.Subscribe("sensors/**", func(payload []byte) {
+				received <- payload
+			})
+
+			subscribed := make(chan struct{}, 1)
+			client.OnSubscription(func(message directmq.SubscribeMessage) {
+				subscribed <- struct{}{}
+			})
+
+			portal, err := WebsocketConnect(u, messagesType)
+			Expect(err).ToNot(HaveOccurred())
+			go client.AddConnectingEdge(portal)
+			Eventually(subscribed).Should(Receive())
+
+			payload := make([]byte, maxSize)
+			for i := range payload {
+				payload[i] = byte(i)
+			}
+
+			client.Publish("sensors/building/floor/room/temperature", payload, directmq.AT_MOST_ONCE)
+
+			Eventually(received).Should(Receive(Equal(payload)))
+			Expect(lost).ToNot(Receive())
+		},
+
+		Entry("binary messages", MessagesType(BinaryMessages), directmq.NewProtobufBinaryProtocol),
+		Entry("text messages", TextMessages, directmq.NewProtobufJSONProtocol),
+	)
+
+	It("should pass the packets with per-message deflate", func() {
+		client, server := connect(WebsocketPortalConfig{EnableCompression: true})
+
+		packet := []byte(strings.Repeat("compressible ", 100))
+		Expect(client.WritePacket(packet)).To(Succeed())
+		Expect(server.ReadPacket()).To(Equal(packet))
+	})
+
+	It("should keep the connection alive with pings", func() {
+		config := WebsocketPortalConfig{PingInterval: 20 * time.Millisecond, PongTimeout: 100 * time.Millisecond}
+		client, server := connect(config)
+
+		// pongs are sent by the peer only while it is reading
+		failed := make(chan error, 2)
+		go func() { _, err := client.ReadPacket(); failed <- err }()
+		go func() { _, err := server.ReadPacket(); failed <- err }()
+
+		Consistently(failed, 400*time.Millisecond).ShouldNot(Receive())
+	})
+
+	It("should detect the dead peer", func() {
+		u, connections := startWebsocketPeer(false)
+
+		client, err := WebsocketConnectWithConfig(u, WebsocketClientConfig{
+			MessagesType: BinaryMessages,
+			Portal:       WebsocketPortalConfig{PingInterval: 20 * time.Millisecond, PongTimeout: 100 * time.Millisecond},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		defer client.Close()
+
+		// the peer never reads, so it never answers the pings
+		var peer *websocket.Conn
+		Eventually(connections).Should(Receive(&peer))
+		defer peer.Close()
+
+		_, err = client.ReadPacket()
+
+		var netErr net.Error
+		Expect(errors.As(err, &netErr)).To(BeTrue())
+		Expect(netErr.Timeout()).To(BeTrue())
+	})
+
+	It("should close the portal once from many goroutines", func() {
+		client, server := connect(WebsocketPortalConfig{})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				client.Close()
+			}()
+		}
+
+		wg.Wait()
+
+		_, err := server.ReadPacket()
+		Expect(websocket.IsCloseError(err, websocket.CloseNormalClosure)).To(BeTrue())
+	})
+})
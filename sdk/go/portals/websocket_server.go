@@ -0,0 +1,245 @@
+package dmqportals
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+
+	"github.com/gorilla/websocket"
+)
+
+// Reason of the graceful close sent to the edges on the server shutdown.
+const WEBSOCKET_SERVER_SHUTDOWN_REASON = "websocket server shutdown"
+
+type WebsocketServerConfig struct {
+	MessagesType MessagesType
+	Portal       WebsocketPortalConfig
+
+	// TLS is used by ListenAndServe when set,
+	// it has to contain the server certificate.
+	TLSConfig *tls.Config
+
+	// CheckOrigin returns true if the origin of the request
+	// is accepted, when nil, the requests with the Origin header
+	// are accepted only when it matches the Host header.
+	CheckOrigin func(r *http.Request) bool
+
+	// Authenticate is called before the upgrade with access to the
+	// request headers, the request is rejected with 401 Unauthorized
+	// when it returns the error.
+	Authenticate func(r *http.Request) error
+
+	// MaxConnections limits the number of the connected edges,
+	// requests over the limit are rejected with 503 Service
+	// Unavailable. Zero means no limit.
+	MaxConnections int
+
+	// OnEdgeError is called when the edge stops with the error
+	// other than the closed connection.
+	OnEdgeError func(err error)
+}
+
+// WebsocketServer accepts the websocket connections, every connection
+// is added to the node as the listening edge. It is the http.Handler,
+// so it can be mounted on any path of the existing http.ServeMux.
+type WebsocketServer struct {
+	dmq      directmq.NetworkNode
+	config   WebsocketServerConfig
+	upgrader websocket.Upgrader
+
+	mutex        sync.Mutex
+	portals      map[*WebsocketPortal]struct{}
+	connections  int
+	shuttingDown bool
+	server       *http.Server
+	handlers     sync.WaitGroup
+}
+
+var _ http.Handler = (*WebsocketServer)(nil)
+
+func NewWebsocketServer(dmq directmq.NetworkNode, config WebsocketServerConfig) *WebsocketServer {
+	return &WebsocketServer{
+		dmq:    dmq,
+		config: config,
+		upgrader: websocket.Upgrader{
+			CheckOrigin:       config.CheckOrigin,
+			EnableCompression: config.Portal.EnableCompression,
+		},
+		portals: make(map[*WebsocketPortal]struct{}),
+	}
+}
+
+func (s *WebsocketServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.config.Authenticate != nil {
+		if err := s.config.Authenticate(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	if !s.reserveConnection() {
+		http.Error(w, "websocket server is not accepting connections", http.StatusServiceUnavailable)
+		return
+	}
+
+	defer s.releaseConnection()
+
+	// the upgrader responds with the error on its own
+	c, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	portal := newWebsocketPortal(c, s.config.MessagesType, s.config.Portal)
+	if !s.register(portal) {
+		portal.Close()
+		return
+	}
+
+	defer s.unregister(portal)
+
+	err = s.dmq.AddListeningEdge(portal)
+	if err != nil && !isWebsocketClosedError(err) && s.config.OnEdgeError != nil {
+		s.config.OnEdgeError(err)
+	}
+}
+
+// Connections returns the number of the connected edges.
+func (s *WebsocketServer) Connections() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.portals)
+}
+
+// ListenAndServe serves the websocket portal on the path of the URL,
+// with TLS when the config contains it, until the server is shut down.
+func (s *WebsocketServer) ListenAndServe(u *url.URL) error {
+	mux := http.NewServeMux()
+	mux.Handle(u.Path, s)
+
+	server := &http.Server{
+		Addr:      u.Host,
+		Handler:   mux,
+		TLSConfig: s.config.TLSConfig,
+	}
+
+	s.mutex.Lock()
+	if s.shuttingDown {
+		s.mutex.Unlock()
+		return nil
+	}
+
+	s.server = server
+	s.mutex.Unlock()
+
+	var err error
+	if s.config.TLSConfig != nil {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// Shutdown stops accepting the connections and gracefully closes
+// all connected edges, it waits until they are closed or until
+// the context is done.
+func (s *WebsocketServer) Shutdown(ctx context.Context) error {
+	s.mutex.Lock()
+	s.shuttingDown = true
+	server := s.server
+
+	portals := make([]*WebsocketPortal, 0, len(s.portals))
+	for portal := range s.portals {
+		portals = append(portals, portal)
+	}
+
+	s.mutex.Unlock()
+
+	for _, portal := range portals {
+		s.dmq.RemoveEdge(portal, WEBSOCKET_SERVER_SHUTDOWN_REASON)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		s.handlers.Wait()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	// hijacked websocket connections are not tracked by the http server,
+	// so it is shut down after the edges were closed
+	if server != nil {
+		return server.Shutdown(ctx)
+	}
+
+	return nil
+}
+
+func (s *WebsocketServer) reserveConnection() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.shuttingDown {
+		return false
+	}
+
+	if s.config.MaxConnections > 0 && s.connections >= s.config.MaxConnections {
+		return false
+	}
+
+	s.connections++
+	s.handlers.Add(1)
+	return true
+}
+
+func (s *WebsocketServer) releaseConnection() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.connections--
+	s.handlers.Done()
+}
+
+// register fails when the server started shutting down during the upgrade.
+func (s *WebsocketServer) register(portal *WebsocketPortal) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.shuttingDown {
+		return false
+	}
+
+	s.portals[portal] = struct{}{}
+	return true
+}
+
+func (s *WebsocketServer) unregister(portal *WebsocketPortal) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.portals, portal)
+}
+
+func isWebsocketClosedError(err error) bool {
+	return errors.Is(err, net.ErrClosed) ||
+		IsNetworkConnectionClosedError(err) ||
+		websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway)
+}
@@ -0,0 +1,189 @@
+package dmqportals
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	directmq "github.com/sync-toys/DirectMQ/sdk/go"
+
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WebsocketServer", func() {
+	var node directmq.NetworkNode
+	var server *WebsocketServer
+	var httpServer *httptest.Server
+	var endpoint *url.URL
+
+	serve := func(config WebsocketServerConfig) {
+		config.MessagesType = BinaryMessages
+
+		node = newTestNode("server")
+		server = NewWebsocketServer(node, config)
+
+		mux := http.NewServeMux()
+		mux.Handle("/dmq", server)
+		httpServer = httptest.NewServer(mux)
+
+		var err error
+		endpoint, err = url.Parse("ws" + strings.TrimPrefix(httpServer.URL, "http") + "/dmq")
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		httpServer = nil
+	})
+
+	AfterEach(func() {
+		node.CloseNode("test finished")
+
+		if httpServer != nil {
+			httpServer.Close()
+		}
+	})
+
+	dial := func(header http.Header) (int, error) {
+		c, response, err := websocket.DefaultDialer.Dial(endpoint.String(), header)
+		if err != nil {
+			if response == nil {
+				return 0, err
+			}
+
+			return response.StatusCode, err
+		}
+
+		DeferCleanup(c.Close)
+		return response.StatusCode, nil
+	}
+
+	It("should bridge the nodes over the server mounted on the mux", func() {
+		serve(WebsocketServerConfig{})
+
+		client := newTestNode("client")
+		defer client.CloseNode("test finished")
+
+		portal, err := WebsocketConnect(endpoint, BinaryMessages)
+		Expect(err).ToNot(HaveOccurred())
+		go client.AddConnectingEdge(portal)
+
+		Eventually(node.GetBridgedNodeIDs).Should(Equal([]string{"client"}))
+		Eventually(client.GetBridgedNodeIDs).Should(Equal([]string{"server"}))
+		Expect(server.Connections()).To(Equal(1))
+	})
+
+	It("should reject the cross origin requests by default", func() {
+		serve(WebsocketServerConfig{})
+
+		status, err := dial(http.Header{"Origin": []string{"https://example.com"}})
+		Expect(err).To(HaveOccurred())
+		Expect(status).To(Equal(http.StatusForbidden))
+	})
+
+	It("should use the origin policy", func() {
+		serve(WebsocketServerConfig{
+			CheckOrigin: func(r *http.Request) bool {
+				return r.Header.Get("Origin") == "https://example.com"
+			},
+		})
+
+		status, err := dial(http.Header{"Origin": []string{"https://example.com"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status).To(Equal(http.StatusSwitchingProtocols))
+
+		status, err = dial(http.Header{"Origin": []string{"https://attacker.com"}})
+		Expect(err).To(HaveOccurred())
+		Expect(status).To(Equal(http.StatusForbidden))
+	})
+
+	It("should authenticate the request before the upgrade", func() {
+		serve(WebsocketServerConfig{
+			Authenticate: func(r *http.Request) error {
+				if r.Header.Get("Authorization") != "Bearer secret" {
+					return errors.New("invalid token")
+				}
+
+				return nil
+			},
+		})
+
+		status, err := dial(http.Header{"Authorization": []string{"Bearer wrong"}})
+		Expect(err).To(HaveOccurred())
+		Expect(status).To(Equal(http.StatusUnauthorized))
+
+		status, err = dial(http.Header{"Authorization": []string{"Bearer secret"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status).To(Equal(http.StatusSwitchingProtocols))
+	})
+
+	It("should limit the number of connections", func() {
+		serve(WebsocketServerConfig{MaxConnections: 1})
+
+		_, err := dial(nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		status, err := dial(nil)
+		Expect(err).To(HaveOccurred())
+		Expect(status).To(Equal(http.StatusServiceUnavailable))
+	})
+
+	It("should gracefully close the edges on shutdown", func() {
+		serve(WebsocketServerConfig{})
+
+		client := newTestNode("client")
+		defer client.CloseNode("test finished")
+
+		reasons := make(chan string, 1)
+		client.OnConnectionLost(func(bridgedNodeID, reason string, portal directmq.Portal) {
+			reasons <- reason
+		})
+
+		portal, err := WebsocketConnect(endpoint, BinaryMessages)
+		Expect(err).ToNot(HaveOccurred())
+		go client.AddConnectingEdge(portal)
+
+		Eventually(node.GetBridgedNodeIDs).Should(Equal([]string{"client"}))
+
+		Expect(server.Shutdown(context.Background())).To(Succeed())
+		Expect(server.Connections()).To(BeZero())
+		Eventually(reasons).Should(Receive(Equal(WEBSOCKET_SERVER_SHUTDOWN_REASON)))
+
+		status, err := dial(nil)
+		Expect(err).To(HaveOccurred())
+		Expect(status).To(Equal(http.StatusServiceUnavailable))
+	})
+
+	It("should serve over TLS", func() {
+		serverTLS, clientTLS := newTestTLSConfigs()
+
+		node = newTestNode("server")
+		server = NewWebsocketServer(node, WebsocketServerConfig{
+			MessagesType: BinaryMessages,
+			TLSConfig:    serverTLS,
+		})
+
+		u, err := url.Parse("wss://" + getFreeTestAddress() + "/dmq")
+		Expect(err).ToNot(HaveOccurred())
+
+		result := make(chan error, 1)
+		go func() { result <- server.ListenAndServe(u) }()
+
+		dialer := websocket.Dialer{TLSClientConfig: clientTLS}
+		Eventually(func() error {
+			c, _, err := dialer.Dial(u.String(), nil)
+			if err == nil {
+				c.Close()
+			}
+
+			return err
+		}).Should(Succeed())
+
+		Expect(server.Shutdown(context.Background())).To(Succeed())
+		Eventually(result).Should(Receive(BeNil()))
+	})
+})
@@ -14,8 +14,9 @@ const (
 )
 
 type DataFrame struct {
-	TTL       int32
-	Traversed []string
+	TTL         int32
+	Traversed   []string
+	TraceParent string
 }
 
 type SupportedProtocolVersionsMessage struct {
@@ -25,12 +26,20 @@ type SupportedProtocolVersionsMessage struct {
 
 type InitConnectionMessage struct {
 	DataFrame
-	MaxMessageSize uint64
+	MaxMessageSize    uint64
+	SupportedFeatures ProtocolFeatures
+	FrameCredit       uint32
+	ByteCredit        uint64
+	MaxTopicAliases   uint32
 }
 
 type ConnectionAcceptedMessage struct {
 	DataFrame
-	MaxMessageSize uint64
+	MaxMessageSize    uint64
+	SupportedFeatures ProtocolFeatures
+	FrameCredit       uint32
+	ByteCredit        uint64
+	MaxTopicAliases   uint32
 }
 
 type GracefullyCloseMessage struct {
@@ -48,6 +57,10 @@ type PublishMessage struct {
 	Topic            string
 	DeliveryStrategy DeliveryStrategy
 	Payload          []byte
+
+	// Alias of the topic bound on the edge, NO_TOPIC_ALIAS
+	// when the publication carries only the topic.
+	TopicAlias uint32
 }
 
 type SubscribeMessage struct {
@@ -60,6 +73,49 @@ type UnsubscribeMessage struct {
 	Topic string
 }
 
+type FlowCreditMessage struct {
+	DataFrame
+	Frames uint32
+	Bytes  uint64
+}
+
+type TopologyProbeMessage struct {
+	DataFrame
+	ProbeID uint64
+}
+
+type TopologyReportMessage struct {
+	DataFrame
+	ProbeID        uint64
+	NodeID         string
+	BridgedNodeIDs []string
+
+	// Nodes the report has to pass through to reach the probe origin,
+	// the origin is the first one and the next hop is the last one.
+	Route []string
+}
+
+type EchoRequestMessage struct {
+	DataFrame
+	EchoID       uint64
+	TargetNodeID string
+
+	// Nodes the request has to pass through to reach the target,
+	// the target is the first one and the next hop is the last one.
+	// Request without the route is flooded to find the target.
+	Route []string
+}
+
+type EchoReplyMessage struct {
+	DataFrame
+	EchoID uint64
+	NodeID string
+
+	// Nodes the reply has to pass through to reach the request origin,
+	// the origin is the first one and the next hop is the last one.
+	Route []string
+}
+
 type MalformedMessage struct {
 	Message []byte
 }
@@ -73,6 +129,11 @@ type ProtocolEncoder interface {
 	Publish(message PublishMessage) error
 	Subscribe(message SubscribeMessage) error
 	Unsubscribe(message UnsubscribeMessage) error
+	FlowCredit(message FlowCreditMessage) error
+	TopologyProbe(message TopologyProbeMessage) error
+	TopologyReport(message TopologyReportMessage) error
+	EchoRequest(message EchoRequestMessage) error
+	EchoReply(message EchoReplyMessage) error
 }
 
 type ProtocolDecoder interface {
@@ -88,6 +149,11 @@ type ProtocolDecoderHandler interface {
 	OnPublish(message PublishMessage)
 	OnSubscribe(message SubscribeMessage)
 	OnUnsubscribe(message UnsubscribeMessage)
+	OnFlowCredit(message FlowCreditMessage)
+	OnTopologyProbe(message TopologyProbeMessage)
+	OnTopologyReport(message TopologyReportMessage)
+	OnEchoRequest(message EchoRequestMessage)
+	OnEchoReply(message EchoReplyMessage)
 	OnMalformedMessage(message MalformedMessage)
 }
 
@@ -134,19 +200,28 @@ func NewProtobufJSONProtocol() ProtocolFactory {
 	}
 }
 
-func (p *ProtobufProtocol) writeFrame(message proto.Message) error {
-	encoded, err := p.marshal(message)
+func (p *ProtobufProtocol) writeFrame(frame *protocol.DataFrame) error {
+	encoded, err := p.marshal(frame)
 	if err != nil {
 		return err
 	}
 
-	return p.writer.WritePacket(encoded)
+	if err := p.writer.WritePacket(encoded); err != nil {
+		return err
+	}
+
+	if observer, ok := p.handler.(ProtocolTrafficObserver); ok {
+		observer.OnFrameWritten(getFrameMessageType(frame), len(encoded))
+	}
+
+	return nil
 }
 
 func (p *ProtobufProtocol) SupportedProtocolVersions(message SupportedProtocolVersionsMessage) error {
 	frame := protocol.DataFrame{
-		Ttl:       message.TTL,
-		Traversed: message.Traversed,
+		Ttl:         message.TTL,
+		Traversed:   message.Traversed,
+		Traceparent: message.TraceParent,
 		Message: &protocol.DataFrame_SupportedProtocolVersions{
 			SupportedProtocolVersions: &protocol.SupportedProtocolVersions{
 				SupportedProtocolVersions: message.SupportedVersions,
@@ -159,11 +234,16 @@ func (p *ProtobufProtocol) SupportedProtocolVersions(message SupportedProtocolVe
 
 func (p *ProtobufProtocol) InitConnection(message InitConnectionMessage) error {
 	frame := protocol.DataFrame{
-		Ttl:       message.TTL,
-		Traversed: message.Traversed,
+		Ttl:         message.TTL,
+		Traversed:   message.Traversed,
+		Traceparent: message.TraceParent,
 		Message: &protocol.DataFrame_InitConnection{
 			InitConnection: &protocol.InitConnection{
-				MaxMessageSize: message.MaxMessageSize,
+				MaxMessageSize:    message.MaxMessageSize,
+				SupportedFeatures: uint64(message.SupportedFeatures),
+				FrameCredit:       message.FrameCredit,
+				ByteCredit:        message.ByteCredit,
+				MaxTopicAliases:   message.MaxTopicAliases,
 			},
 		},
 	}
@@ -173,11 +253,16 @@ func (p *ProtobufProtocol) InitConnection(message InitConnectionMessage) error {
 
 func (p *ProtobufProtocol) ConnectionAccepted(message ConnectionAcceptedMessage) error {
 	frame := protocol.DataFrame{
-		Ttl:       message.TTL,
-		Traversed: message.Traversed,
+		Ttl:         message.TTL,
+		Traversed:   message.Traversed,
+		Traceparent: message.TraceParent,
 		Message: &protocol.DataFrame_ConnectionAccepted{
 			ConnectionAccepted: &protocol.ConnectionAccepted{
-				MaxMessageSize: message.MaxMessageSize,
+				MaxMessageSize:    message.MaxMessageSize,
+				SupportedFeatures: uint64(message.SupportedFeatures),
+				FrameCredit:       message.FrameCredit,
+				ByteCredit:        message.ByteCredit,
+				MaxTopicAliases:   message.MaxTopicAliases,
 			},
 		},
 	}
@@ -187,8 +272,9 @@ func (p *ProtobufProtocol) ConnectionAccepted(message ConnectionAcceptedMessage)
 
 func (p *ProtobufProtocol) GracefullyClose(message GracefullyCloseMessage) error {
 	frame := protocol.DataFrame{
-		Ttl:       message.TTL,
-		Traversed: message.Traversed,
+		Ttl:         message.TTL,
+		Traversed:   message.Traversed,
+		Traceparent: message.TraceParent,
 		Message: &protocol.DataFrame_GracefullyClose{
 			GracefullyClose: &protocol.GracefullyClose{
 				Reason: message.Reason,
@@ -201,8 +287,9 @@ func (p *ProtobufProtocol) GracefullyClose(message GracefullyCloseMessage) error
 
 func (p *ProtobufProtocol) TerminateNetwork(message TerminateNetworkMessage) error {
 	frame := protocol.DataFrame{
-		Ttl:       message.TTL,
-		Traversed: message.Traversed,
+		Ttl:         message.TTL,
+		Traversed:   message.Traversed,
+		Traceparent: message.TraceParent,
 		Message: &protocol.DataFrame_TerminateNetwork{
 			TerminateNetwork: &protocol.TerminateNetwork{
 				Reason: message.Reason,
@@ -215,13 +302,15 @@ func (p *ProtobufProtocol) TerminateNetwork(message TerminateNetworkMessage) err
 
 func (p *ProtobufProtocol) Publish(message PublishMessage) error {
 	frame := protocol.DataFrame{
-		Ttl:       message.TTL,
-		Traversed: message.Traversed,
+		Ttl:         message.TTL,
+		Traversed:   message.Traversed,
+		Traceparent: message.TraceParent,
 		Message: &protocol.DataFrame_Publish{
 			Publish: &protocol.Publish{
 				Topic:            message.Topic,
 				DeliveryStrategy: protocol.DeliveryStrategy(message.DeliveryStrategy),
 				Payload:          message.Payload,
+				TopicAlias:       message.TopicAlias,
 			},
 		},
 	}
@@ -231,8 +320,9 @@ func (p *ProtobufProtocol) Publish(message PublishMessage) error {
 
 func (p *ProtobufProtocol) Subscribe(message SubscribeMessage) error {
 	frame := protocol.DataFrame{
-		Ttl:       message.TTL,
-		Traversed: message.Traversed,
+		Ttl:         message.TTL,
+		Traversed:   message.Traversed,
+		Traceparent: message.TraceParent,
 		Message: &protocol.DataFrame_Subscribe{
 			Subscribe: &protocol.Subscribe{
 				Topic: message.Topic,
@@ -245,8 +335,9 @@ func (p *ProtobufProtocol) Subscribe(message SubscribeMessage) error {
 
 func (p *ProtobufProtocol) Unsubscribe(message UnsubscribeMessage) error {
 	frame := protocol.DataFrame{
-		Ttl:       message.TTL,
-		Traversed: message.Traversed,
+		Ttl:         message.TTL,
+		Traversed:   message.Traversed,
+		Traceparent: message.TraceParent,
 		Message: &protocol.DataFrame_Unsubscribe{
 			Unsubscribe: &protocol.Unsubscribe{
 				Topic: message.Topic,
@@ -257,6 +348,89 @@ func (p *ProtobufProtocol) Unsubscribe(message UnsubscribeMessage) error {
 	return p.writeFrame(&frame)
 }
 
+func (p *ProtobufProtocol) FlowCredit(message FlowCreditMessage) error {
+	frame := protocol.DataFrame{
+		Ttl:         message.TTL,
+		Traversed:   message.Traversed,
+		Traceparent: message.TraceParent,
+		Message: &protocol.DataFrame_FlowCredit{
+			FlowCredit: &protocol.FlowCredit{
+				Frames: message.Frames,
+				Bytes:  message.Bytes,
+			},
+		},
+	}
+
+	return p.writeFrame(&frame)
+}
+
+func (p *ProtobufProtocol) TopologyProbe(message TopologyProbeMessage) error {
+	frame := protocol.DataFrame{
+		Ttl:         message.TTL,
+		Traversed:   message.Traversed,
+		Traceparent: message.TraceParent,
+		Message: &protocol.DataFrame_TopologyProbe{
+			TopologyProbe: &protocol.TopologyProbe{
+				ProbeId: message.ProbeID,
+			},
+		},
+	}
+
+	return p.writeFrame(&frame)
+}
+
+func (p *ProtobufProtocol) TopologyReport(message TopologyReportMessage) error {
+	frame := protocol.DataFrame{
+		Ttl:         message.TTL,
+		Traversed:   message.Traversed,
+		Traceparent: message.TraceParent,
+		Message: &protocol.DataFrame_TopologyReport{
+			TopologyReport: &protocol.TopologyReport{
+				ProbeId:        message.ProbeID,
+				NodeId:         message.NodeID,
+				BridgedNodeIds: message.BridgedNodeIDs,
+				Route:          message.Route,
+			},
+		},
+	}
+
+	return p.writeFrame(&frame)
+}
+
+func (p *ProtobufProtocol) EchoRequest(message EchoRequestMessage) error {
+	frame := protocol.DataFrame{
+		Ttl:         message.TTL,
+		Traversed:   message.Traversed,
+		Traceparent: message.TraceParent,
+		Message: &protocol.DataFrame_EchoRequest{
+			EchoRequest: &protocol.EchoRequest{
+				EchoId:       message.EchoID,
+				TargetNodeId: message.TargetNodeID,
+				Route:        message.Route,
+			},
+		},
+	}
+
+	return p.writeFrame(&frame)
+}
+
+func (p *ProtobufProtocol) EchoReply(message EchoReplyMessage) error {
+	frame := protocol.DataFrame{
+		Ttl:         message.TTL,
+		Traversed:   message.Traversed,
+		Traceparent: message.TraceParent,
+		Message: &protocol.DataFrame_EchoReply{
+			EchoReply: &protocol.EchoReply{
+				EchoId: message.EchoID,
+				NodeId: message.NodeID,
+				Route:  message.Route,
+			},
+		},
+	}
+
+	return p.writeFrame(&frame)
+}
+
 func (p *ProtobufProtocol) ReadFrom(pr PacketReader) error {
 	data, err := pr.ReadPacket()
 	if err != nil {
@@ -268,6 +442,10 @@ func (p *ProtobufProtocol) ReadFrom(pr PacketReader) error {
 		return err
 	}
 
+	if observer, ok := p.handler.(ProtocolTrafficObserver); ok {
+		observer.OnFrameRead(getFrameMessageType(frame), len(data))
+	}
+
 	switch frame.Message.(type) {
 	case *protocol.DataFrame_SupportedProtocolVersions:
 		message := frame.Message.(*protocol.DataFrame_SupportedProtocolVersions).SupportedProtocolVersions
@@ -279,15 +457,23 @@ func (p *ProtobufProtocol) ReadFrom(pr PacketReader) error {
 	case *protocol.DataFrame_InitConnection:
 		message := frame.Message.(*protocol.DataFrame_InitConnection).InitConnection
 		p.handler.OnInitConnection(InitConnectionMessage{
-			DataFrame:      frameToDataFrame(frame),
-			MaxMessageSize: message.MaxMessageSize,
+			DataFrame:         frameToDataFrame(frame),
+			MaxMessageSize:    message.MaxMessageSize,
+			SupportedFeatures: ProtocolFeatures(message.SupportedFeatures),
+			FrameCredit:       message.FrameCredit,
+			ByteCredit:        message.ByteCredit,
+			MaxTopicAliases:   message.MaxTopicAliases,
 		})
 
 	case *protocol.DataFrame_ConnectionAccepted:
 		message := frame.Message.(*protocol.DataFrame_ConnectionAccepted).ConnectionAccepted
 		p.handler.OnConnectionAccepted(ConnectionAcceptedMessage{
-			DataFrame:      frameToDataFrame(frame),
-			MaxMessageSize: message.MaxMessageSize,
+			DataFrame:         frameToDataFrame(frame),
+			MaxMessageSize:    message.MaxMessageSize,
+			SupportedFeatures: ProtocolFeatures(message.SupportedFeatures),
+			FrameCredit:       message.FrameCredit,
+			ByteCredit:        message.ByteCredit,
+			MaxTopicAliases:   message.MaxTopicAliases,
 		})
 
 	case *protocol.DataFrame_GracefullyClose:
@@ -311,6 +497,7 @@ func (p *ProtobufProtocol) ReadFrom(pr PacketReader) error {
 			Topic:            message.Topic,
 			DeliveryStrategy: DeliveryStrategy(message.DeliveryStrategy),
 			Payload:          message.Payload,
+			TopicAlias:       message.TopicAlias,
 		})
 
 	case *protocol.DataFrame_Subscribe:
@@ -327,6 +514,49 @@ func (p *ProtobufProtocol) ReadFrom(pr PacketReader) error {
 			Topic:     message.Topic,
 		})
 
+	case *protocol.DataFrame_FlowCredit:
+		message := frame.Message.(*protocol.DataFrame_FlowCredit).FlowCredit
+		p.handler.OnFlowCredit(FlowCreditMessage{
+			DataFrame: frameToDataFrame(frame),
+			Frames:    message.Frames,
+			Bytes:     message.Bytes,
+		})
+
+	case *protocol.DataFrame_TopologyProbe:
+		message := frame.Message.(*protocol.DataFrame_TopologyProbe).TopologyProbe
+		p.handler.OnTopologyProbe(TopologyProbeMessage{
+			DataFrame: frameToDataFrame(frame),
+			ProbeID:   message.ProbeId,
+		})
+
+	case *protocol.DataFrame_TopologyReport:
+		message := frame.Message.(*protocol.DataFrame_TopologyReport).TopologyReport
+		p.handler.OnTopologyReport(TopologyReportMessage{
+			DataFrame:      frameToDataFrame(frame),
+			ProbeID:        message.ProbeId,
+			NodeID:         message.NodeId,
+			BridgedNodeIDs: message.BridgedNodeIds,
+			Route:          message.Route,
+		})
+
+	case *protocol.DataFrame_EchoRequest:
+		message := frame.Message.(*protocol.DataFrame_EchoRequest).EchoRequest
+		p.handler.OnEchoRequest(EchoRequestMessage{
+			DataFrame:    frameToDataFrame(frame),
+			EchoID:       message.EchoId,
+			TargetNodeID: message.TargetNodeId,
+			Route:        message.Route,
+		})
+
+	case *protocol.DataFrame_EchoReply:
+		message := frame.Message.(*protocol.DataFrame_EchoReply).EchoReply
+		p.handler.OnEchoReply(EchoReplyMessage{
+			DataFrame: frameToDataFrame(frame),
+			EchoID:    message.EchoId,
+			NodeID:    message.NodeId,
+			Route:     message.Route,
+		})
+
 	default:
 		p.handler.OnMalformedMessage(MalformedMessage{
 			Message: data,
@@ -336,10 +566,57 @@ func (p *ProtobufProtocol) ReadFrom(pr PacketReader) error {
 	return nil
 }
 
+// DecodeProtobufFrame decodes the packet written by the protobuf protocol
+// in the given format, it is used to inspect the traffic outside of the node.
+func DecodeProtobufFrame(packet []byte, format ProtobufProtocolFormat) (*protocol.DataFrame, MessageType, error) {
+	p := &ProtobufProtocol{format: format}
+
+	frame := new(protocol.DataFrame)
+	if err := p.unmarshal(packet, frame); err != nil {
+		return nil, MESSAGE_TYPE_MALFORMED, err
+	}
+
+	return frame, getFrameMessageType(frame), nil
+}
+
+func getFrameMessageType(frame *protocol.DataFrame) MessageType {
+	switch frame.Message.(type) {
+	case *protocol.DataFrame_SupportedProtocolVersions:
+		return MESSAGE_TYPE_SUPPORTED_PROTOCOL_VERSIONS
+	case *protocol.DataFrame_InitConnection:
+		return MESSAGE_TYPE_INIT_CONNECTION
+	case *protocol.DataFrame_ConnectionAccepted:
+		return MESSAGE_TYPE_CONNECTION_ACCEPTED
+	case *protocol.DataFrame_GracefullyClose:
+		return MESSAGE_TYPE_GRACEFULLY_CLOSE
+	case *protocol.DataFrame_TerminateNetwork:
+		return MESSAGE_TYPE_TERMINATE_NETWORK
+	case *protocol.DataFrame_Publish:
+		return MESSAGE_TYPE_PUBLISH
+	case *protocol.DataFrame_Subscribe:
+		return MESSAGE_TYPE_SUBSCRIBE
+	case *protocol.DataFrame_Unsubscribe:
+		return MESSAGE_TYPE_UNSUBSCRIBE
+	case *protocol.DataFrame_FlowCredit:
+		return MESSAGE_TYPE_FLOW_CREDIT
+	case *protocol.DataFrame_TopologyProbe:
+		return MESSAGE_TYPE_TOPOLOGY_PROBE
+	case *protocol.DataFrame_TopologyReport:
+		return MESSAGE_TYPE_TOPOLOGY_REPORT
+	case *protocol.DataFrame_EchoRequest:
+		return MESSAGE_TYPE_ECHO_REQUEST
+	case *protocol.DataFrame_EchoReply:
+		return MESSAGE_TYPE_ECHO_REPLY
+	default:
+		return MESSAGE_TYPE_MALFORMED
+	}
+}
+
 func frameToDataFrame(frame *protocol.DataFrame) DataFrame {
 	return DataFrame{
-		TTL:       frame.Ttl,
-		Traversed: frame.Traversed,
+		TTL:         frame.Ttl,
+		Traversed:   frame.Traversed,
+		TraceParent: frame.Traceparent,
 	}
 }
 
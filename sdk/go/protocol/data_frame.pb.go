@@ -37,7 +37,14 @@ type DataFrame struct {
 	//	*DataFrame_Unsubscribe
 	//	*DataFrame_GracefullyClose
 	//	*DataFrame_TerminateNetwork
+	//	*DataFrame_FlowCredit
+	//	*DataFrame_TopologyProbe
+	//	*DataFrame_TopologyReport
+	//	*DataFrame_EchoRequest
+	//	*DataFrame_EchoReply
 	Message isDataFrame_Message `protobuf_oneof:"message"`
+	// W3C trace context traceparent header value, empty when not traced
+	Traceparent string `protobuf:"bytes,12,opt,name=traceparent,proto3" json:"traceparent,omitempty"`
 }
 
 func (x *DataFrame) Reset() {
@@ -149,6 +156,48 @@ func (x *DataFrame) GetTerminateNetwork() *TerminateNetwork {
 	return nil
 }
 
+func (x *DataFrame) GetFlowCredit() *FlowCredit {
+	if x, ok := x.GetMessage().(*DataFrame_FlowCredit); ok {
+		return x.FlowCredit
+	}
+	return nil
+}
+
+func (x *DataFrame) GetTopologyProbe() *TopologyProbe {
+	if x, ok := x.GetMessage().(*DataFrame_TopologyProbe); ok {
+		return x.TopologyProbe
+	}
+	return nil
+}
+
+func (x *DataFrame) GetTopologyReport() *TopologyReport {
+	if x, ok := x.GetMessage().(*DataFrame_TopologyReport); ok {
+		return x.TopologyReport
+	}
+	return nil
+}
+
+func (x *DataFrame) GetEchoRequest() *EchoRequest {
+	if x, ok := x.GetMessage().(*DataFrame_EchoRequest); ok {
+		return x.EchoRequest
+	}
+	return nil
+}
+
+func (x *DataFrame) GetEchoReply() *EchoReply {
+	if x, ok := x.GetMessage().(*DataFrame_EchoReply); ok {
+		return x.EchoReply
+	}
+	return nil
+}
+
+func (x *DataFrame) GetTraceparent() string {
+	if x != nil {
+		return x.Traceparent
+	}
+	return ""
+}
+
 type isDataFrame_Message interface {
 	isDataFrame_Message()
 }
@@ -185,6 +234,26 @@ type DataFrame_TerminateNetwork struct {
 	TerminateNetwork *TerminateNetwork `protobuf:"bytes,10,opt,name=terminate_network,json=terminateNetwork,proto3,oneof"`
 }
 
+type DataFrame_FlowCredit struct {
+	FlowCredit *FlowCredit `protobuf:"bytes,11,opt,name=flow_credit,json=flowCredit,proto3,oneof"`
+}
+
+type DataFrame_TopologyProbe struct {
+	TopologyProbe *TopologyProbe `protobuf:"bytes,13,opt,name=topology_probe,json=topologyProbe,proto3,oneof"`
+}
+
+type DataFrame_TopologyReport struct {
+	TopologyReport *TopologyReport `protobuf:"bytes,14,opt,name=topology_report,json=topologyReport,proto3,oneof"`
+}
+
+type DataFrame_EchoRequest struct {
+	EchoRequest *EchoRequest `protobuf:"bytes,15,opt,name=echo_request,json=echoRequest,proto3,oneof"`
+}
+
+type DataFrame_EchoReply struct {
+	EchoReply *EchoReply `protobuf:"bytes,16,opt,name=echo_reply,json=echoReply,proto3,oneof"`
+}
+
 func (*DataFrame_SupportedProtocolVersions) isDataFrame_Message() {}
 
 func (*DataFrame_InitConnection) isDataFrame_Message() {}
@@ -201,6 +270,16 @@ func (*DataFrame_GracefullyClose) isDataFrame_Message() {}
 
 func (*DataFrame_TerminateNetwork) isDataFrame_Message() {}
 
+func (*DataFrame_FlowCredit) isDataFrame_Message() {}
+
+func (*DataFrame_TopologyProbe) isDataFrame_Message() {}
+
+func (*DataFrame_TopologyReport) isDataFrame_Message() {}
+
+func (*DataFrame_EchoRequest) isDataFrame_Message() {}
+
+func (*DataFrame_EchoReply) isDataFrame_Message() {}
+
 var File_directmq_v1_data_frame_proto protoreflect.FileDescriptor
 
 var file_directmq_v1_data_frame_proto_rawDesc = []byte{
@@ -214,49 +293,76 @@ var file_directmq_v1_data_frame_proto_rawDesc = []byte{
 	0x31, 0x2f, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
 	0x6f, 0x1a, 0x1d, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71, 0x2f, 0x76, 0x31, 0x2f, 0x75,
 	0x6e, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x22, 0x8d, 0x05, 0x0a, 0x09, 0x44, 0x61, 0x74, 0x61, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x12, 0x10,
-	0x0a, 0x03, 0x74, 0x74, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x74, 0x74, 0x6c,
-	0x12, 0x1c, 0x0a, 0x09, 0x74, 0x72, 0x61, 0x76, 0x65, 0x72, 0x73, 0x65, 0x64, 0x18, 0x02, 0x20,
-	0x03, 0x28, 0x09, 0x52, 0x09, 0x74, 0x72, 0x61, 0x76, 0x65, 0x72, 0x73, 0x65, 0x64, 0x12, 0x68,
-	0x0a, 0x1b, 0x73, 0x75, 0x70, 0x70, 0x6f, 0x72, 0x74, 0x65, 0x64, 0x5f, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x63, 0x6f, 0x6c, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71, 0x2e, 0x76,
-	0x31, 0x2e, 0x53, 0x75, 0x70, 0x70, 0x6f, 0x72, 0x74, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x74, 0x6f,
-	0x63, 0x6f, 0x6c, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x48, 0x00, 0x52, 0x19, 0x73,
-	0x75, 0x70, 0x70, 0x6f, 0x72, 0x74, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
-	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x46, 0x0a, 0x0f, 0x69, 0x6e, 0x69, 0x74,
-	0x5f, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x1b, 0x2e, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71, 0x2e, 0x76, 0x31, 0x2e,
-	0x49, 0x6e, 0x69, 0x74, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00,
-	0x52, 0x0e, 0x69, 0x6e, 0x69, 0x74, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e,
-	0x12, 0x52, 0x0a, 0x13, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x61,
-	0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e,
-	0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6e, 0x6e,
-	0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x48, 0x00,
-	0x52, 0x12, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x41, 0x63, 0x63, 0x65,
-	0x70, 0x74, 0x65, 0x64, 0x12, 0x30, 0x0a, 0x07, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x18,
-	0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71,
-	0x2e, 0x76, 0x31, 0x2e, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x48, 0x00, 0x52, 0x07, 0x70,
-	0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x12, 0x36, 0x0a, 0x09, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72,
-	0x69, 0x62, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x64, 0x69, 0x72, 0x65,
-	0x63, 0x74, 0x6d, 0x71, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62,
-	0x65, 0x48, 0x00, 0x52, 0x09, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x12, 0x3c,
-	0x0a, 0x0b, 0x75, 0x6e, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x18, 0x08, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71, 0x2e, 0x76,
-	0x31, 0x2e, 0x55, 0x6e, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x48, 0x00, 0x52,
-	0x0b, 0x75, 0x6e, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x12, 0x49, 0x0a, 0x10,
-	0x67, 0x72, 0x61, 0x63, 0x65, 0x66, 0x75, 0x6c, 0x6c, 0x79, 0x5f, 0x63, 0x6c, 0x6f, 0x73, 0x65,
-	0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d,
-	0x71, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x72, 0x61, 0x63, 0x65, 0x66, 0x75, 0x6c, 0x6c, 0x79, 0x43,
-	0x6c, 0x6f, 0x73, 0x65, 0x48, 0x00, 0x52, 0x0f, 0x67, 0x72, 0x61, 0x63, 0x65, 0x66, 0x75, 0x6c,
-	0x6c, 0x79, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x12, 0x4c, 0x0a, 0x11, 0x74, 0x65, 0x72, 0x6d, 0x69,
-	0x6e, 0x61, 0x74, 0x65, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x18, 0x0a, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71, 0x2e, 0x76, 0x31,
-	0x2e, 0x54, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x74, 0x65, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72,
-	0x6b, 0x48, 0x00, 0x52, 0x10, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x74, 0x65, 0x4e, 0x65,
-	0x74, 0x77, 0x6f, 0x72, 0x6b, 0x42, 0x09, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
-	0x42, 0x0c, 0x5a, 0x0a, 0x2e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x1a, 0x1e, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71, 0x2f, 0x76, 0x31, 0x2f, 0x66, 0x6c,
+	0x6f, 0x77, 0x5f, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x1a, 0x1a, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71, 0x2f, 0x76, 0x31, 0x2f, 0x74, 0x6f,
+	0x70, 0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x16, 0x64, 0x69,
+	0x72, 0x65, 0x63, 0x74, 0x6d, 0x71, 0x2f, 0x76, 0x31, 0x2f, 0x65, 0x63, 0x68, 0x6f, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x22, 0xf0, 0x07, 0x0a, 0x09, 0x44, 0x61, 0x74, 0x61, 0x46, 0x72, 0x61,
+	0x6d, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x74, 0x74, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x03, 0x74, 0x74, 0x6c, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x72, 0x61, 0x76, 0x65, 0x72, 0x73, 0x65,
+	0x64, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x74, 0x72, 0x61, 0x76, 0x65, 0x72, 0x73,
+	0x65, 0x64, 0x12, 0x68, 0x0a, 0x1b, 0x73, 0x75, 0x70, 0x70, 0x6f, 0x72, 0x74, 0x65, 0x64, 0x5f,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74,
+	0x6d, 0x71, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75, 0x70, 0x70, 0x6f, 0x72, 0x74, 0x65, 0x64, 0x50,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x48,
+	0x00, 0x52, 0x19, 0x73, 0x75, 0x70, 0x70, 0x6f, 0x72, 0x74, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x74,
+	0x6f, 0x63, 0x6f, 0x6c, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x46, 0x0a, 0x0f,
+	0x69, 0x6e, 0x69, 0x74, 0x5f, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71,
+	0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6e, 0x69, 0x74, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x48, 0x00, 0x52, 0x0e, 0x69, 0x6e, 0x69, 0x74, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x52, 0x0a, 0x13, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1f, 0x2e, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71, 0x2e, 0x76, 0x31, 0x2e,
+	0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74,
+	0x65, 0x64, 0x48, 0x00, 0x52, 0x12, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x12, 0x30, 0x0a, 0x07, 0x70, 0x75, 0x62, 0x6c,
+	0x69, 0x73, 0x68, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x64, 0x69, 0x72, 0x65,
+	0x63, 0x74, 0x6d, 0x71, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x48,
+	0x00, 0x52, 0x07, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x12, 0x36, 0x0a, 0x09, 0x73, 0x75,
+	0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e,
+	0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75, 0x62, 0x73,
+	0x63, 0x72, 0x69, 0x62, 0x65, 0x48, 0x00, 0x52, 0x09, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69,
+	0x62, 0x65, 0x12, 0x3c, 0x0a, 0x0b, 0x75, 0x6e, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62,
+	0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74,
+	0x6d, 0x71, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x6e, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62,
+	0x65, 0x48, 0x00, 0x52, 0x0b, 0x75, 0x6e, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65,
+	0x12, 0x49, 0x0a, 0x10, 0x67, 0x72, 0x61, 0x63, 0x65, 0x66, 0x75, 0x6c, 0x6c, 0x79, 0x5f, 0x63,
+	0x6c, 0x6f, 0x73, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x64, 0x69, 0x72,
+	0x65, 0x63, 0x74, 0x6d, 0x71, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x72, 0x61, 0x63, 0x65, 0x66, 0x75,
+	0x6c, 0x6c, 0x79, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x48, 0x00, 0x52, 0x0f, 0x67, 0x72, 0x61, 0x63,
+	0x65, 0x66, 0x75, 0x6c, 0x6c, 0x79, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x12, 0x4c, 0x0a, 0x11, 0x74,
+	0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x74, 0x65, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d,
+	0x71, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x74, 0x65, 0x4e, 0x65,
+	0x74, 0x77, 0x6f, 0x72, 0x6b, 0x48, 0x00, 0x52, 0x10, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61,
+	0x74, 0x65, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x12, 0x3a, 0x0a, 0x0b, 0x66, 0x6c, 0x6f,
+	0x77, 0x5f, 0x63, 0x72, 0x65, 0x64, 0x69, 0x74, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17,
+	0x2e, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x6c, 0x6f,
+	0x77, 0x43, 0x72, 0x65, 0x64, 0x69, 0x74, 0x48, 0x00, 0x52, 0x0a, 0x66, 0x6c, 0x6f, 0x77, 0x43,
+	0x72, 0x65, 0x64, 0x69, 0x74, 0x12, 0x43, 0x0a, 0x0e, 0x74, 0x6f, 0x70, 0x6f, 0x6c, 0x6f, 0x67,
+	0x79, 0x5f, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x6f, 0x70, 0x6f,
+	0x6c, 0x6f, 0x67, 0x79, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x48, 0x00, 0x52, 0x0d, 0x74, 0x6f, 0x70,
+	0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x12, 0x46, 0x0a, 0x0f, 0x74, 0x6f,
+	0x70, 0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x5f, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x0e, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71, 0x2e, 0x76,
+	0x31, 0x2e, 0x54, 0x6f, 0x70, 0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74,
+	0x48, 0x00, 0x52, 0x0e, 0x74, 0x6f, 0x70, 0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x52, 0x65, 0x70, 0x6f,
+	0x72, 0x74, 0x12, 0x3d, 0x0a, 0x0c, 0x65, 0x63, 0x68, 0x6f, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x64, 0x69, 0x72, 0x65, 0x63,
+	0x74, 0x6d, 0x71, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x63, 0x68, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x48, 0x00, 0x52, 0x0b, 0x65, 0x63, 0x68, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x37, 0x0a, 0x0a, 0x65, 0x63, 0x68, 0x6f, 0x5f, 0x72, 0x65, 0x70, 0x6c, 0x79, 0x18,
+	0x10, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71,
+	0x2e, 0x76, 0x31, 0x2e, 0x45, 0x63, 0x68, 0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x48, 0x00, 0x52,
+	0x09, 0x65, 0x63, 0x68, 0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x20, 0x0a, 0x0b, 0x74, 0x72,
+	0x61, 0x63, 0x65, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0b, 0x74, 0x72, 0x61, 0x63, 0x65, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x42, 0x09, 0x0a, 0x07,
+	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x42, 0x0c, 0x5a, 0x0a, 0x2e, 0x2f, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -282,21 +388,31 @@ var file_directmq_v1_data_frame_proto_goTypes = []interface{}{
 	(*Unsubscribe)(nil),               // 6: directmq.v1.Unsubscribe
 	(*GracefullyClose)(nil),           // 7: directmq.v1.GracefullyClose
 	(*TerminateNetwork)(nil),          // 8: directmq.v1.TerminateNetwork
+	(*FlowCredit)(nil),                // 9: directmq.v1.FlowCredit
+	(*TopologyProbe)(nil),             // 10: directmq.v1.TopologyProbe
+	(*TopologyReport)(nil),            // 11: directmq.v1.TopologyReport
+	(*EchoRequest)(nil),               // 12: directmq.v1.EchoRequest
+	(*EchoReply)(nil),                 // 13: directmq.v1.EchoReply
 }
 var file_directmq_v1_data_frame_proto_depIdxs = []int32{
-	1, // 0: directmq.v1.DataFrame.supported_protocol_versions:type_name -> directmq.v1.SupportedProtocolVersions
-	2, // 1: directmq.v1.DataFrame.init_connection:type_name -> directmq.v1.InitConnection
-	3, // 2: directmq.v1.DataFrame.connection_accepted:type_name -> directmq.v1.ConnectionAccepted
-	4, // 3: directmq.v1.DataFrame.publish:type_name -> directmq.v1.Publish
-	5, // 4: directmq.v1.DataFrame.subscribe:type_name -> directmq.v1.Subscribe
-	6, // 5: directmq.v1.DataFrame.unsubscribe:type_name -> directmq.v1.Unsubscribe
-	7, // 6: directmq.v1.DataFrame.gracefully_close:type_name -> directmq.v1.GracefullyClose
-	8, // 7: directmq.v1.DataFrame.terminate_network:type_name -> directmq.v1.TerminateNetwork
-	8, // [8:8] is the sub-list for method output_type
-	8, // [8:8] is the sub-list for method input_type
-	8, // [8:8] is the sub-list for extension type_name
-	8, // [8:8] is the sub-list for extension extendee
-	0, // [0:8] is the sub-list for field type_name
+	1,  // 0: directmq.v1.DataFrame.supported_protocol_versions:type_name -> directmq.v1.SupportedProtocolVersions
+	2,  // 1: directmq.v1.DataFrame.init_connection:type_name -> directmq.v1.InitConnection
+	3,  // 2: directmq.v1.DataFrame.connection_accepted:type_name -> directmq.v1.ConnectionAccepted
+	4,  // 3: directmq.v1.DataFrame.publish:type_name -> directmq.v1.Publish
+	5,  // 4: directmq.v1.DataFrame.subscribe:type_name -> directmq.v1.Subscribe
+	6,  // 5: directmq.v1.DataFrame.unsubscribe:type_name -> directmq.v1.Unsubscribe
+	7,  // 6: directmq.v1.DataFrame.gracefully_close:type_name -> directmq.v1.GracefullyClose
+	8,  // 7: directmq.v1.DataFrame.terminate_network:type_name -> directmq.v1.TerminateNetwork
+	9,  // 8: directmq.v1.DataFrame.flow_credit:type_name -> directmq.v1.FlowCredit
+	10, // 9: directmq.v1.DataFrame.topology_probe:type_name -> directmq.v1.TopologyProbe
+	11, // 10: directmq.v1.DataFrame.topology_report:type_name -> directmq.v1.TopologyReport
+	12, // 11: directmq.v1.DataFrame.echo_request:type_name -> directmq.v1.EchoRequest
+	13, // 12: directmq.v1.DataFrame.echo_reply:type_name -> directmq.v1.EchoReply
+	13, // [13:13] is the sub-list for method output_type
+	13, // [13:13] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
 }
 
 func init() { file_directmq_v1_data_frame_proto_init() }
@@ -308,6 +424,9 @@ func file_directmq_v1_data_frame_proto_init() {
 	file_directmq_v1_publish_proto_init()
 	file_directmq_v1_subscribe_proto_init()
 	file_directmq_v1_unsubscribe_proto_init()
+	file_directmq_v1_flow_control_proto_init()
+	file_directmq_v1_topology_proto_init()
+	file_directmq_v1_echo_proto_init()
 	if !protoimpl.UnsafeEnabled {
 		file_directmq_v1_data_frame_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*DataFrame); i {
@@ -331,6 +450,11 @@ func file_directmq_v1_data_frame_proto_init() {
 		(*DataFrame_Unsubscribe)(nil),
 		(*DataFrame_GracefullyClose)(nil),
 		(*DataFrame_TerminateNetwork)(nil),
+		(*DataFrame_FlowCredit)(nil),
+		(*DataFrame_TopologyProbe)(nil),
+		(*DataFrame_TopologyReport)(nil),
+		(*DataFrame_EchoRequest)(nil),
+		(*DataFrame_EchoReply)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
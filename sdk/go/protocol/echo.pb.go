@@ -0,0 +1,249 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.1
+// 	protoc        (unknown)
+// source: directmq/v1/echo.proto
+
+package protocol
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type EchoRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	EchoId       uint64 `protobuf:"varint,1,opt,name=echo_id,json=echoId,proto3" json:"echo_id,omitempty"`
+	TargetNodeId string `protobuf:"bytes,2,opt,name=target_node_id,json=targetNodeId,proto3" json:"target_node_id,omitempty"`
+	// nodes the request has to pass through to reach the target,
+	// the target is the first one and the next hop is the last one,
+	// the request without the route is flooded to find the target
+	Route []string `protobuf:"bytes,3,rep,name=route,proto3" json:"route,omitempty"`
+}
+
+func (x *EchoRequest) Reset() {
+	*x = EchoRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_directmq_v1_echo_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EchoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EchoRequest) ProtoMessage() {}
+
+func (x *EchoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_directmq_v1_echo_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EchoRequest.ProtoReflect.Descriptor instead.
+func (*EchoRequest) Descriptor() ([]byte, []int) {
+	return file_directmq_v1_echo_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EchoRequest) GetEchoId() uint64 {
+	if x != nil {
+		return x.EchoId
+	}
+	return 0
+}
+
+func (x *EchoRequest) GetTargetNodeId() string {
+	if x != nil {
+		return x.TargetNodeId
+	}
+	return ""
+}
+
+func (x *EchoRequest) GetRoute() []string {
+	if x != nil {
+		return x.Route
+	}
+	return nil
+}
+
+type EchoReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	EchoId uint64 `protobuf:"varint,1,opt,name=echo_id,json=echoId,proto3" json:"echo_id,omitempty"`
+	NodeId string `protobuf:"bytes,2,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	// nodes the reply has to pass through to reach the request origin,
+	// the origin is the first one and the next hop is the last one
+	Route []string `protobuf:"bytes,3,rep,name=route,proto3" json:"route,omitempty"`
+}
+
+func (x *EchoReply) Reset() {
+	*x = EchoReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_directmq_v1_echo_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EchoReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EchoReply) ProtoMessage() {}
+
+func (x *EchoReply) ProtoReflect() protoreflect.Message {
+	mi := &file_directmq_v1_echo_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EchoReply.ProtoReflect.Descriptor instead.
+func (*EchoReply) Descriptor() ([]byte, []int) {
+	return file_directmq_v1_echo_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *EchoReply) GetEchoId() uint64 {
+	if x != nil {
+		return x.EchoId
+	}
+	return 0
+}
+
+func (x *EchoReply) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *EchoReply) GetRoute() []string {
+	if x != nil {
+		return x.Route
+	}
+	return nil
+}
+
+var File_directmq_v1_echo_proto protoreflect.FileDescriptor
+
+var file_directmq_v1_echo_proto_rawDesc = []byte{
+	0x0a, 0x16, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71, 0x2f, 0x76, 0x31, 0x2f, 0x65, 0x63,
+	0x68, 0x6f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74,
+	0x6d, 0x71, 0x2e, 0x76, 0x31, 0x22, 0x62, 0x0a, 0x0b, 0x45, 0x63, 0x68, 0x6f, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x65, 0x63, 0x68, 0x6f, 0x5f, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x65, 0x63, 0x68, 0x6f, 0x49, 0x64, 0x12, 0x24, 0x0a,
+	0x0e, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x5f, 0x6e, 0x6f, 0x64, 0x65, 0x5f, 0x69, 0x64, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x4e, 0x6f, 0x64,
+	0x65, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x18, 0x03, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x05, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x22, 0x53, 0x0a, 0x09, 0x45, 0x63, 0x68,
+	0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x17, 0x0a, 0x07, 0x65, 0x63, 0x68, 0x6f, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x65, 0x63, 0x68, 0x6f, 0x49, 0x64, 0x12,
+	0x17, 0x0a, 0x07, 0x6e, 0x6f, 0x64, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x6e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x6f, 0x75, 0x74,
+	0x65, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x42, 0x0c,
+	0x5a, 0x0a, 0x2e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_directmq_v1_echo_proto_rawDescOnce sync.Once
+	file_directmq_v1_echo_proto_rawDescData = file_directmq_v1_echo_proto_rawDesc
+)
+
+func file_directmq_v1_echo_proto_rawDescGZIP() []byte {
+	file_directmq_v1_echo_proto_rawDescOnce.Do(func() {
+		file_directmq_v1_echo_proto_rawDescData = protoimpl.X.CompressGZIP(file_directmq_v1_echo_proto_rawDescData)
+	})
+	return file_directmq_v1_echo_proto_rawDescData
+}
+
+var file_directmq_v1_echo_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_directmq_v1_echo_proto_goTypes = []interface{}{
+	(*EchoRequest)(nil), // 0: directmq.v1.EchoRequest
+	(*EchoReply)(nil),   // 1: directmq.v1.EchoReply
+}
+var file_directmq_v1_echo_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_directmq_v1_echo_proto_init() }
+func file_directmq_v1_echo_proto_init() {
+	if File_directmq_v1_echo_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_directmq_v1_echo_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EchoRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_directmq_v1_echo_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EchoReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_directmq_v1_echo_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_directmq_v1_echo_proto_goTypes,
+		DependencyIndexes: file_directmq_v1_echo_proto_depIdxs,
+		MessageInfos:      file_directmq_v1_echo_proto_msgTypes,
+	}.Build()
+	File_directmq_v1_echo_proto = out.File
+	file_directmq_v1_echo_proto_rawDesc = nil
+	file_directmq_v1_echo_proto_goTypes = nil
+	file_directmq_v1_echo_proto_depIdxs = nil
+}
@@ -0,0 +1,152 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.1
+// 	protoc        (unknown)
+// source: directmq/v1/flow_control.proto
+
+package protocol
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type FlowCredit struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Frames uint32 `protobuf:"varint,1,opt,name=frames,proto3" json:"frames,omitempty"`
+	Bytes  uint64 `protobuf:"varint,2,opt,name=bytes,proto3" json:"bytes,omitempty"`
+}
+
+func (x *FlowCredit) Reset() {
+	*x = FlowCredit{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_directmq_v1_flow_control_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FlowCredit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlowCredit) ProtoMessage() {}
+
+func (x *FlowCredit) ProtoReflect() protoreflect.Message {
+	mi := &file_directmq_v1_flow_control_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlowCredit.ProtoReflect.Descriptor instead.
+func (*FlowCredit) Descriptor() ([]byte, []int) {
+	return file_directmq_v1_flow_control_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *FlowCredit) GetFrames() uint32 {
+	if x != nil {
+		return x.Frames
+	}
+	return 0
+}
+
+func (x *FlowCredit) GetBytes() uint64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+var File_directmq_v1_flow_control_proto protoreflect.FileDescriptor
+
+var file_directmq_v1_flow_control_proto_rawDesc = []byte{
+	0x0a, 0x1e, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71, 0x2f, 0x76, 0x31, 0x2f, 0x66, 0x6c,
+	0x6f, 0x77, 0x5f, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x12, 0x0b, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71, 0x2e, 0x76, 0x31, 0x22, 0x3a, 0x0a,
+	0x0a, 0x46, 0x6c, 0x6f, 0x77, 0x43, 0x72, 0x65, 0x64, 0x69, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x66,
+	0x72, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x66, 0x72, 0x61,
+	0x6d, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x05, 0x62, 0x79, 0x74, 0x65, 0x73, 0x42, 0x0c, 0x5a, 0x0a, 0x2e, 0x2f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_directmq_v1_flow_control_proto_rawDescOnce sync.Once
+	file_directmq_v1_flow_control_proto_rawDescData = file_directmq_v1_flow_control_proto_rawDesc
+)
+
+func file_directmq_v1_flow_control_proto_rawDescGZIP() []byte {
+	file_directmq_v1_flow_control_proto_rawDescOnce.Do(func() {
+		file_directmq_v1_flow_control_proto_rawDescData = protoimpl.X.CompressGZIP(file_directmq_v1_flow_control_proto_rawDescData)
+	})
+	return file_directmq_v1_flow_control_proto_rawDescData
+}
+
+var file_directmq_v1_flow_control_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_directmq_v1_flow_control_proto_goTypes = []interface{}{
+	(*FlowCredit)(nil), // 0: directmq.v1.FlowCredit
+}
+var file_directmq_v1_flow_control_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_directmq_v1_flow_control_proto_init() }
+func file_directmq_v1_flow_control_proto_init() {
+	if File_directmq_v1_flow_control_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_directmq_v1_flow_control_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FlowCredit); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_directmq_v1_flow_control_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_directmq_v1_flow_control_proto_goTypes,
+		DependencyIndexes: file_directmq_v1_flow_control_proto_depIdxs,
+		MessageInfos:      file_directmq_v1_flow_control_proto_msgTypes,
+	}.Build()
+	File_directmq_v1_flow_control_proto = out.File
+	file_directmq_v1_flow_control_proto_rawDesc = nil
+	file_directmq_v1_flow_control_proto_goTypes = nil
+	file_directmq_v1_flow_control_proto_depIdxs = nil
+}
@@ -75,6 +75,11 @@ type Publish struct {
 	DeliveryStrategy DeliveryStrategy `protobuf:"varint,2,opt,name=delivery_strategy,json=deliveryStrategy,proto3,enum=directmq.v1.DeliveryStrategy" json:"delivery_strategy,omitempty"`
 	Size             uint64           `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
 	Payload          []byte           `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	// Alias of the topic assigned by the sender for the edge,
+	// 0 means no alias. Frame carrying both topic and alias
+	// binds the alias to the topic, frame carrying only
+	// the alias refers to the previously bound topic.
+	TopicAlias uint32 `protobuf:"varint,5,opt,name=topic_alias,json=topicAlias,proto3" json:"topic_alias,omitempty"`
 }
 
 func (x *Publish) Reset() {
@@ -137,12 +142,19 @@ func (x *Publish) GetPayload() []byte {
 	return nil
 }
 
+func (x *Publish) GetTopicAlias() uint32 {
+	if x != nil {
+		return x.TopicAlias
+	}
+	return 0
+}
+
 var File_directmq_v1_publish_proto protoreflect.FileDescriptor
 
 var file_directmq_v1_publish_proto_rawDesc = []byte{
 	0x0a, 0x19, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71, 0x2f, 0x76, 0x31, 0x2f, 0x70, 0x75,
 	0x62, 0x6c, 0x69, 0x73, 0x68, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x64, 0x69, 0x72,
-	0x65, 0x63, 0x74, 0x6d, 0x71, 0x2e, 0x76, 0x31, 0x22, 0x99, 0x01, 0x0a, 0x07, 0x50, 0x75, 0x62,
+	0x65, 0x63, 0x74, 0x6d, 0x71, 0x2e, 0x76, 0x31, 0x22, 0xba, 0x01, 0x0a, 0x07, 0x50, 0x75, 0x62,
 	0x6c, 0x69, 0x73, 0x68, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x18, 0x01, 0x20,
 	0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x12, 0x4a, 0x0a, 0x11, 0x64, 0x65,
 	0x6c, 0x69, 0x76, 0x65, 0x72, 0x79, 0x5f, 0x73, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x18,
@@ -152,15 +164,17 @@ var file_directmq_v1_publish_proto_rawDesc = []byte{
 	0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x03,
 	0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61,
 	0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x61, 0x79,
-	0x6c, 0x6f, 0x61, 0x64, 0x2a, 0x67, 0x0a, 0x10, 0x44, 0x65, 0x6c, 0x69, 0x76, 0x65, 0x72, 0x79,
-	0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x12, 0x2f, 0x0a, 0x2b, 0x44, 0x45, 0x4c, 0x49,
-	0x56, 0x45, 0x52, 0x59, 0x5f, 0x53, 0x54, 0x52, 0x41, 0x54, 0x45, 0x47, 0x59, 0x5f, 0x41, 0x54,
-	0x5f, 0x4c, 0x45, 0x41, 0x53, 0x54, 0x5f, 0x4f, 0x4e, 0x43, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50,
-	0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x22, 0x0a, 0x1e, 0x44, 0x45, 0x4c,
+	0x6c, 0x6f, 0x61, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x5f, 0x61, 0x6c,
+	0x69, 0x61, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x74, 0x6f, 0x70, 0x69, 0x63,
+	0x41, 0x6c, 0x69, 0x61, 0x73, 0x2a, 0x67, 0x0a, 0x10, 0x44, 0x65, 0x6c, 0x69, 0x76, 0x65, 0x72,
+	0x79, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x12, 0x2f, 0x0a, 0x2b, 0x44, 0x45, 0x4c,
 	0x49, 0x56, 0x45, 0x52, 0x59, 0x5f, 0x53, 0x54, 0x52, 0x41, 0x54, 0x45, 0x47, 0x59, 0x5f, 0x41,
-	0x54, 0x5f, 0x4d, 0x4f, 0x53, 0x54, 0x5f, 0x4f, 0x4e, 0x43, 0x45, 0x10, 0x01, 0x42, 0x0c, 0x5a,
-	0x0a, 0x2e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x33,
+	0x54, 0x5f, 0x4c, 0x45, 0x41, 0x53, 0x54, 0x5f, 0x4f, 0x4e, 0x43, 0x45, 0x5f, 0x55, 0x4e, 0x53,
+	0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x22, 0x0a, 0x1e, 0x44, 0x45,
+	0x4c, 0x49, 0x56, 0x45, 0x52, 0x59, 0x5f, 0x53, 0x54, 0x52, 0x41, 0x54, 0x45, 0x47, 0x59, 0x5f,
+	0x41, 0x54, 0x5f, 0x4d, 0x4f, 0x53, 0x54, 0x5f, 0x4f, 0x4e, 0x43, 0x45, 0x10, 0x01, 0x42, 0x0c,
+	0x5a, 0x0a, 0x2e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -0,0 +1,238 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.1
+// 	protoc        (unknown)
+// source: directmq/v1/topology.proto
+
+package protocol
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TopologyProbe struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ProbeId uint64 `protobuf:"varint,1,opt,name=probe_id,json=probeId,proto3" json:"probe_id,omitempty"`
+}
+
+func (x *TopologyProbe) Reset() {
+	*x = TopologyProbe{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_directmq_v1_topology_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TopologyProbe) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopologyProbe) ProtoMessage() {}
+
+func (x *TopologyProbe) ProtoReflect() protoreflect.Message {
+	mi := &file_directmq_v1_topology_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopologyProbe.ProtoReflect.Descriptor instead.
+func (*TopologyProbe) Descriptor() ([]byte, []int) {
+	return file_directmq_v1_topology_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TopologyProbe) GetProbeId() uint64 {
+	if x != nil {
+		return x.ProbeId
+	}
+	return 0
+}
+
+type TopologyReport struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ProbeId        uint64   `protobuf:"varint,1,opt,name=probe_id,json=probeId,proto3" json:"probe_id,omitempty"`
+	NodeId         string   `protobuf:"bytes,2,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	BridgedNodeIds []string `protobuf:"bytes,3,rep,name=bridged_node_ids,json=bridgedNodeIds,proto3" json:"bridged_node_ids,omitempty"`
+	// nodes the report has to pass through to reach the probe origin,
+	// the origin is the first one and the next hop is the last one
+	Route []string `protobuf:"bytes,4,rep,name=route,proto3" json:"route,omitempty"`
+}
+
+func (x *TopologyReport) Reset() {
+	*x = TopologyReport{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_directmq_v1_topology_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TopologyReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopologyReport) ProtoMessage() {}
+
+func (x *TopologyReport) ProtoReflect() protoreflect.Message {
+	mi := &file_directmq_v1_topology_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopologyReport.ProtoReflect.Descriptor instead.
+func (*TopologyReport) Descriptor() ([]byte, []int) {
+	return file_directmq_v1_topology_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TopologyReport) GetProbeId() uint64 {
+	if x != nil {
+		return x.ProbeId
+	}
+	return 0
+}
+
+func (x *TopologyReport) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *TopologyReport) GetBridgedNodeIds() []string {
+	if x != nil {
+		return x.BridgedNodeIds
+	}
+	return nil
+}
+
+func (x *TopologyReport) GetRoute() []string {
+	if x != nil {
+		return x.Route
+	}
+	return nil
+}
+
+var File_directmq_v1_topology_proto protoreflect.FileDescriptor
+
+var file_directmq_v1_topology_proto_rawDesc = []byte{
+	0x0a, 0x1a, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6d, 0x71, 0x2f, 0x76, 0x31, 0x2f, 0x74, 0x6f,
+	0x70, 0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x64, 0x69,
+	0x72, 0x65, 0x63, 0x74, 0x6d, 0x71, 0x2e, 0x76, 0x31, 0x22, 0x2a, 0x0a, 0x0d, 0x54, 0x6f, 0x70,
+	0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x70, 0x72,
+	0x6f, 0x62, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x70, 0x72,
+	0x6f, 0x62, 0x65, 0x49, 0x64, 0x22, 0x84, 0x01, 0x0a, 0x0e, 0x54, 0x6f, 0x70, 0x6f, 0x6c, 0x6f,
+	0x67, 0x79, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x62,
+	0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x62,
+	0x65, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x6e, 0x6f, 0x64, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x12, 0x28, 0x0a, 0x10,
+	0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x64, 0x5f, 0x6e, 0x6f, 0x64, 0x65, 0x5f, 0x69, 0x64, 0x73,
+	0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0e, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x64, 0x4e,
+	0x6f, 0x64, 0x65, 0x49, 0x64, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x18,
+	0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x42, 0x0c, 0x5a, 0x0a,
+	0x2e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
+}
+
+var (
+	file_directmq_v1_topology_proto_rawDescOnce sync.Once
+	file_directmq_v1_topology_proto_rawDescData = file_directmq_v1_topology_proto_rawDesc
+)
+
+func file_directmq_v1_topology_proto_rawDescGZIP() []byte {
+	file_directmq_v1_topology_proto_rawDescOnce.Do(func() {
+		file_directmq_v1_topology_proto_rawDescData = protoimpl.X.CompressGZIP(file_directmq_v1_topology_proto_rawDescData)
+	})
+	return file_directmq_v1_topology_proto_rawDescData
+}
+
+var file_directmq_v1_topology_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_directmq_v1_topology_proto_goTypes = []interface{}{
+	(*TopologyProbe)(nil),  // 0: directmq.v1.TopologyProbe
+	(*TopologyReport)(nil), // 1: directmq.v1.TopologyReport
+}
+var file_directmq_v1_topology_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_directmq_v1_topology_proto_init() }
+func file_directmq_v1_topology_proto_init() {
+	if File_directmq_v1_topology_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_directmq_v1_topology_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TopologyProbe); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_directmq_v1_topology_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TopologyReport); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_directmq_v1_topology_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_directmq_v1_topology_proto_goTypes,
+		DependencyIndexes: file_directmq_v1_topology_proto_depIdxs,
+		MessageInfos:      file_directmq_v1_topology_proto_msgTypes,
+	}.Build()
+	File_directmq_v1_topology_proto = out.File
+	file_directmq_v1_topology_proto_rawDesc = nil
+	file_directmq_v1_topology_proto_goTypes = nil
+	file_directmq_v1_topology_proto_depIdxs = nil
+}
@@ -0,0 +1,35 @@
+package directmq
+
+type ProtocolFeatures uint64
+
+const (
+	NO_PROTOCOL_FEATURES ProtocolFeatures = 0
+
+	// Node understands flow credit frames and waits
+	// for the credit granted by the bridged node
+	// before sending any publications to it.
+	FEATURE_FLOW_CONTROL ProtocolFeatures = 1 << 0
+
+	// Node answers and forwards topology probes
+	// and routes topology reports back to the probe origin.
+	FEATURE_TOPOLOGY_DISCOVERY ProtocolFeatures = 1 << 1
+
+	// Node answers and forwards echo requests
+	// and routes echo replies back to the request origin.
+	FEATURE_ECHO ProtocolFeatures = 1 << 2
+
+	// Node accepts publications carrying the numeric topic alias
+	// instead of the topic, aliases are bound per edge.
+	FEATURE_TOPIC_ALIASES ProtocolFeatures = 1 << 3
+)
+
+// Features supported by this implementation of the protocol.
+const SUPPORTED_PROTOCOL_FEATURES = FEATURE_FLOW_CONTROL | FEATURE_TOPOLOGY_DISCOVERY | FEATURE_ECHO | FEATURE_TOPIC_ALIASES
+
+func (f ProtocolFeatures) Has(feature ProtocolFeatures) bool {
+	return f&feature == feature
+}
+
+func getHostFeatures(config NetworkNodeConfig) ProtocolFeatures {
+	return config.HostFeatures & SUPPORTED_PROTOCOL_FEATURES
+}
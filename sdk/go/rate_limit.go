@@ -0,0 +1,341 @@
+package directmq
+
+import (
+	"sync"
+	"time"
+)
+
+type RateLimitDirection uint8
+
+const (
+	INBOUND_TRAFFIC  RateLimitDirection = 1 << 0
+	OUTBOUND_TRAFFIC RateLimitDirection = 1 << 1
+	ALL_TRAFFIC                         = INBOUND_TRAFFIC | OUTBOUND_TRAFFIC
+)
+
+type RateLimitUnit uint8
+
+const (
+	RATE_LIMIT_PUBLICATIONS RateLimitUnit = 0
+	RATE_LIMIT_BYTES        RateLimitUnit = 1
+)
+
+type RateLimitAction uint8
+
+const (
+	// Excess publications are dropped.
+	RATE_LIMIT_DROP RateLimitAction = 0
+
+	// Excess publications are queued and sent when the bucket refills.
+	RATE_LIMIT_DELAY RateLimitAction = 1
+
+	// Only the latest excess publication of every topic is kept
+	// and sent when the bucket refills.
+	RATE_LIMIT_COALESCE RateLimitAction = 2
+)
+
+const (
+	ALL_BRIDGED_NODES             = ""
+	ALL_TOPICS                    = ""
+	DEFAULT_RATE_LIMIT_QUEUE_SIZE = 64
+)
+
+// RateLimitRule describes a token bucket applied to the publications
+// exchanged with the bridged node. Every edge gets its own bucket
+// for every rule, and only the first matching rule is applied
+// to the publication.
+type RateLimitRule struct {
+	BridgedNodeID string
+	TopicPattern  string
+	Direction     RateLimitDirection
+
+	Unit  RateLimitUnit
+	Rate  float64 // tokens per second
+	Burst float64 // bucket capacity
+
+	Action RateLimitAction
+
+	// Maximum number of delayed or coalesced publications,
+	// DEFAULT_RATE_LIMIT_QUEUE_SIZE is used when zero.
+	QueueSize int
+}
+
+func IsCorrectRateLimitRule(rule RateLimitRule) bool {
+	if rule.TopicPattern != ALL_TOPICS && !IsCorrectTopicPattern(rule.TopicPattern) {
+		return false
+	}
+
+	if rule.Direction&ALL_TRAFFIC == 0 {
+		return false
+	}
+
+	return rule.Rate > 0 && rule.Burst > 0
+}
+
+func (r RateLimitRule) matches(bridgedNodeID string, direction RateLimitDirection, topic string) bool {
+	if r.Direction&direction == 0 {
+		return false
+	}
+
+	if r.BridgedNodeID != ALL_BRIDGED_NODES && r.BridgedNodeID != bridgedNodeID {
+		return false
+	}
+
+	return r.TopicPattern == ALL_TOPICS || MatchTopicPattern(r.TopicPattern, topic)
+}
+
+func (r RateLimitRule) costOf(publication PublishMessage) float64 {
+	if r.Unit == RATE_LIMIT_BYTES {
+		return float64(len(publication.Payload))
+	}
+
+	return 1
+}
+
+type tokenBucket struct {
+	rate      float64
+	burst     float64
+	tokens    float64
+	updatedAt time.Time
+}
+
+func newTokenBucket(rate, burst float64, now time.Time) tokenBucket {
+	return tokenBucket{
+		rate:      rate,
+		burst:     burst,
+		tokens:    burst,
+		updatedAt: now,
+	}
+}
+
+// Take removes the cost from the bucket when possible, otherwise it returns
+// the time after which the cost can be taken. Cost larger than the bucket
+// capacity is allowed to be taken from the full bucket, so it is not
+// stalled forever.
+func (b *tokenBucket) Take(cost float64, now time.Time) (taken bool, wait time.Duration) {
+	b.refill(now)
+
+	required := cost
+	if required > b.burst {
+		required = b.burst
+	}
+
+	if b.tokens >= required {
+		b.tokens -= cost
+		return true, 0
+	}
+
+	wait = time.Duration((required - b.tokens) / b.rate * float64(time.Second))
+	if wait <= 0 {
+		wait = time.Millisecond
+	}
+
+	return false, wait
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updatedAt).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+
+	b.tokens += elapsed * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+
+	b.updatedAt = now
+}
+
+type rateLimitResult int
+
+const (
+	publicationPassed rateLimitResult = iota
+	publicationDeferred
+	publicationLimited
+)
+
+type rateLimitRuleState struct {
+	rule   RateLimitRule
+	bucket tokenBucket
+
+	pending   []PublishMessage
+	coalesced map[string]int
+	timer     *time.Timer
+}
+
+// rateLimiter applies rate limit rules to the publications passing
+// through the edge in a single direction. Deferred publications
+// are handed to the release function from the timer goroutine with
+// the owner locked, the publications limited by the owner under the
+// same lock can not overtake them.
+type rateLimiter struct {
+	mutex sync.Mutex
+
+	direction     RateLimitDirection
+	bridgedNodeID string
+	rules         []*rateLimitRuleState
+	owner         sync.Locker
+	release       func(publication PublishMessage)
+}
+
+func newRateLimiter(direction RateLimitDirection, owner sync.Locker, release func(publication PublishMessage)) *rateLimiter {
+	return &rateLimiter{
+		direction: direction,
+		rules:     make([]*rateLimitRuleState, 0),
+		owner:     owner,
+		release:   release,
+	}
+}
+
+func (l *rateLimiter) Reset(rules []RateLimitRule, bridgedNodeID string) {
+	l.Clear()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.bridgedNodeID = bridgedNodeID
+
+	now := time.Now()
+	for _, rule := range rules {
+		if rule.Direction&l.direction == 0 {
+			continue
+		}
+
+		if rule.BridgedNodeID != ALL_BRIDGED_NODES && rule.BridgedNodeID != l.bridgedNodeID {
+			continue
+		}
+
+		l.rules = append(l.rules, &rateLimitRuleState{
+			rule:      rule,
+			bucket:    newTokenBucket(rule.Rate, rule.Burst, now),
+			pending:   make([]PublishMessage, 0),
+			coalesced: make(map[string]int),
+		})
+	}
+}
+
+func (l *rateLimiter) Clear() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, state := range l.rules {
+		if state.timer != nil {
+			state.timer.Stop()
+		}
+
+		// timer could already fire, make sure it will not release anything
+		state.pending = nil
+		state.coalesced = make(map[string]int)
+	}
+
+	l.rules = make([]*rateLimitRuleState, 0)
+}
+
+func (l *rateLimiter) Limit(publication PublishMessage) rateLimitResult {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	state := l.findRule(publication)
+	if state == nil {
+		return publicationPassed
+	}
+
+	// publications waiting in the queue have to be sent first
+	if len(state.pending) == 0 {
+		taken, wait := state.bucket.Take(state.rule.costOf(publication), time.Now())
+		if taken {
+			return publicationPassed
+		}
+
+		if state.rule.Action == RATE_LIMIT_DROP {
+			return publicationLimited
+		}
+
+		state.timer = time.AfterFunc(wait, func() { l.releasePending(state) })
+	}
+
+	if state.rule.Action == RATE_LIMIT_COALESCE {
+		if index, exists := state.coalesced[publication.Topic]; exists {
+			state.pending[index] = publication
+			return publicationDeferred
+		}
+	}
+
+	if len(state.pending) >= state.queueSize() {
+		return publicationLimited
+	}
+
+	state.pending = append(state.pending, publication)
+	if state.rule.Action == RATE_LIMIT_COALESCE {
+		state.coalesced[publication.Topic] = len(state.pending) - 1
+	}
+
+	return publicationDeferred
+}
+
+func (l *rateLimiter) QueueLength() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	length := 0
+	for _, state := range l.rules {
+		length += len(state.pending)
+	}
+
+	return length
+}
+
+func (l *rateLimiter) findRule(publication PublishMessage) *rateLimitRuleState {
+	for _, state := range l.rules {
+		if state.rule.matches(l.bridgedNodeID, l.direction, publication.Topic) {
+			return state
+		}
+	}
+
+	return nil
+}
+
+func (l *rateLimiter) releasePending(state *rateLimitRuleState) {
+	l.owner.Lock()
+	defer l.owner.Unlock()
+
+	released := make([]PublishMessage, 0)
+
+	l.mutex.Lock()
+	for len(state.pending) > 0 {
+		taken, wait := state.bucket.Take(state.rule.costOf(state.pending[0]), time.Now())
+		if !taken {
+			state.timer = time.AfterFunc(wait, func() { l.releasePending(state) })
+			break
+		}
+
+		released = append(released, state.pending[0])
+		state.pending = state.pending[1:]
+		state.reindexCoalesced()
+	}
+	l.mutex.Unlock()
+
+	for _, publication := range released {
+		l.release(publication)
+	}
+}
+
+func (s *rateLimitRuleState) queueSize() int {
+	if s.rule.QueueSize <= 0 {
+		return DEFAULT_RATE_LIMIT_QUEUE_SIZE
+	}
+
+	return s.rule.QueueSize
+}
+
+func (s *rateLimitRuleState) reindexCoalesced() {
+	if s.rule.Action != RATE_LIMIT_COALESCE {
+		return
+	}
+
+	s.coalesced = make(map[string]int, len(s.pending))
+	for i, publication := range s.pending {
+		s.coalesced[publication.Topic] = i
+	}
+}
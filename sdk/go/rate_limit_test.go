@@ -0,0 +1,225 @@
+package directmq
+
+import (
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rate limiting", func() {
+	Context("tokenBucket", func() {
+		now := time.Now()
+
+		It("should allow taking tokens up to the burst", func() {
+			bucket := newTokenBucket(1, 2, now)
+
+			taken, _ := bucket.Take(1, now)
+			Expect(taken).To(BeTrue())
+			taken, _ = bucket.Take(1, now)
+			Expect(taken).To(BeTrue())
+
+			taken, wait := bucket.Take(1, now)
+			Expect(taken).To(BeFalse())
+			Expect(wait).To(Equal(time.Second))
+		})
+
+		It("should refill tokens with time", func() {
+			bucket := newTokenBucket(2, 1, now)
+			bucket.Take(1, now)
+
+			taken, _ := bucket.Take(1, now.Add(500*time.Millisecond))
+			Expect(taken).To(BeTrue())
+		})
+
+		It("should allow taking cost larger than the burst from the full bucket", func() {
+			bucket := newTokenBucket(1, 10, now)
+
+			taken, _ := bucket.Take(100, now)
+			Expect(taken).To(BeTrue())
+
+			taken, _ = bucket.Take(1, now.Add(10*time.Second))
+			Expect(taken).To(BeFalse())
+		})
+	})
+
+	Context("IsCorrectRateLimitRule", func() {
+		DescribeTable(
+			"returns correct result for rule",
+			func(rule RateLimitRule, expected bool) {
+				Expect(IsCorrectRateLimitRule(rule)).To(Equal(expected))
+			},
+			Entry("rule for all topics", RateLimitRule{Direction: ALL_TRAFFIC, Rate: 1, Burst: 1}, true),
+			Entry("rule for topic pattern", RateLimitRule{TopicPattern: "sensor/*", Direction: INBOUND_TRAFFIC, Rate: 1, Burst: 1}, true),
+			Entry("rule with incorrect topic pattern", RateLimitRule{TopicPattern: "sensor/", Direction: ALL_TRAFFIC, Rate: 1, Burst: 1}, false),
+			Entry("rule without direction", RateLimitRule{Rate: 1, Burst: 1}, false),
+			Entry("rule without rate", RateLimitRule{Direction: ALL_TRAFFIC, Burst: 1}, false),
+			Entry("rule without burst", RateLimitRule{Direction: ALL_TRAFFIC, Rate: 1}, false),
+		)
+	})
+
+	Context("rateLimiter", func() {
+		var limiter *rateLimiter
+		var owner sync.Mutex
+		var mutex sync.Mutex
+		var released []PublishMessage
+
+		releasedTopics := func() []string {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			topics := make([]string, 0)
+			for _, publication := range released {
+				topics = append(topics, publication.Topic)
+			}
+
+			return topics
+		}
+
+		publication := func(topic string) PublishMessage {
+			return PublishMessage{Topic: topic, Payload: []byte{1}}
+		}
+
+		rule := func(action RateLimitAction) RateLimitRule {
+			return RateLimitRule{
+				TopicPattern: "sensor/*",
+				Direction:    OUTBOUND_TRAFFIC,
+				Rate:         50,
+				Burst:        1,
+				Action:       action,
+			}
+		}
+
+		BeforeEach(func() {
+			released = make([]PublishMessage, 0)
+			limiter = newRateLimiter(OUTBOUND_TRAFFIC, &owner, func(publication PublishMessage) {
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				released = append(released, publication)
+			})
+		})
+
+		AfterEach(func() {
+			limiter.Clear()
+		})
+
+		It("should pass publications not matching any rule", func() {
+			limiter.Reset([]RateLimitRule{rule(RATE_LIMIT_DROP)}, "node")
+
+			for i := 0; i < 10; i++ {
+				Expect(limiter.Limit(publication("other"))).To(Equal(publicationPassed))
+			}
+		})
+
+		It("should ignore rules of other bridged nodes and directions", func() {
+			otherNode := rule(RATE_LIMIT_DROP)
+			otherNode.BridgedNodeID = "other"
+
+			otherDirection := rule(RATE_LIMIT_DROP)
+			otherDirection.Direction = INBOUND_TRAFFIC
+
+			limiter.Reset([]RateLimitRule{otherNode, otherDirection}, "node")
+
+			for i := 0; i < 10; i++ {
+				Expect(limiter.Limit(publication("sensor/a"))).To(Equal(publicationPassed))
+			}
+		})
+
+		It("should drop excess publications", func() {
+			limiter.Reset([]RateLimitRule{rule(RATE_LIMIT_DROP)}, "node")
+
+			Expect(limiter.Limit(publication("sensor/a"))).To(Equal(publicationPassed))
+			Expect(limiter.Limit(publication("sensor/a"))).To(Equal(publicationLimited))
+		})
+
+		It("should delay excess publications preserving their order", func() {
+			limiter.Reset([]RateLimitRule{rule(RATE_LIMIT_DELAY)}, "node")
+
+			Expect(limiter.Limit(publication("sensor/a"))).To(Equal(publicationPassed))
+			Expect(limiter.Limit(publication("sensor/b"))).To(Equal(publicationDeferred))
+			Expect(limiter.Limit(publication("sensor/c"))).To(Equal(publicationDeferred))
+
+			Eventually(releasedTopics).Should(Equal([]string{"sensor/b", "sensor/c"}))
+		})
+
+		It("should not let the publication limited by the locked owner overtake the released ones", func() {
+			limiter.Reset([]RateLimitRule{rule(RATE_LIMIT_DELAY)}, "node")
+
+			Expect(limiter.Limit(publication("sensor/a"))).To(Equal(publicationPassed))
+			Expect(limiter.Limit(publication("sensor/b"))).To(Equal(publicationDeferred))
+
+			owner.Lock()
+			time.Sleep(60 * time.Millisecond)
+			Expect(limiter.Limit(publication("sensor/c"))).To(Equal(publicationDeferred))
+			owner.Unlock()
+
+			Eventually(releasedTopics).Should(Equal([]string{"sensor/b", "sensor/c"}))
+		})
+
+		It("should coalesce excess publications to the latest value of topic", func() {
+			limiter.Reset([]RateLimitRule{rule(RATE_LIMIT_COALESCE)}, "node")
+
+			latest := publication("sensor/a")
+			latest.Payload = []byte{2}
+
+			Expect(limiter.Limit(publication("sensor/a"))).To(Equal(publicationPassed))
+			Expect(limiter.Limit(publication("sensor/a"))).To(Equal(publicationDeferred))
+			Expect(limiter.Limit(publication("sensor/b"))).To(Equal(publicationDeferred))
+			Expect(limiter.Limit(latest)).To(Equal(publicationDeferred))
+
+			Eventually(releasedTopics).Should(Equal([]string{"sensor/a", "sensor/b"}))
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			Expect(released[0].Payload).To(Equal([]byte{2}))
+		})
+
+		It("should limit the number of delayed publications", func() {
+			limited := rule(RATE_LIMIT_DELAY)
+			limited.QueueSize = 1
+			limiter.Reset([]RateLimitRule{limited}, "node")
+
+			Expect(limiter.Limit(publication("sensor/a"))).To(Equal(publicationPassed))
+			Expect(limiter.Limit(publication("sensor/a"))).To(Equal(publicationDeferred))
+			Expect(limiter.Limit(publication("sensor/a"))).To(Equal(publicationLimited))
+		})
+
+		It("should not release anything after being cleared", func() {
+			limiter.Reset([]RateLimitRule{rule(RATE_LIMIT_DELAY)}, "node")
+
+			limiter.Limit(publication("sensor/a"))
+			limiter.Limit(publication("sensor/b"))
+			limiter.Clear()
+
+			Consistently(releasedTopics, 100*time.Millisecond).Should(BeEmpty())
+		})
+	})
+
+	Context("between nodes", func() {
+		It("should release the throttled publications in order", func() {
+			throttle := func(direction RateLimitDirection) []RateLimitRule {
+				return []RateLimitRule{{Direction: direction, Rate: 200, Burst: 1, Action: RATE_LIMIT_DELAY}}
+			}
+
+			a := newNetworkNode(NetworkNodeConfig{HostID: "a", HostTTL: DEFAULT_TTL, HostRateLimits: throttle(OUTBOUND_TRAFFIC)}, NewProtobufBinaryProtocol())
+			b := newNetworkNode(NetworkNodeConfig{HostID: "b", HostTTL: DEFAULT_TTL, HostRateLimits: throttle(INBOUND_TRAFFIC)}, NewProtobufBinaryProtocol())
+			defer a.CloseNode("test finished")
+
+			received := make(chan []byte, 10)
+			b.Subscribe("sensors/temperature", func(payload []byte) { received <- payload })
+
+			connectTestNodes(a, b)
+			Eventually(a.GetEdges).Should(ContainElement(HaveField("BridgedNodeSubscriptions", ConsistOf("sensors/temperature"))))
+
+			for i := byte(1); i <= 10; i++ {
+				a.Publish("sensors/temperature", []byte{i}, AT_LEAST_ONCE)
+			}
+
+			for i := byte(1); i <= 10; i++ {
+				Eventually(received).Should(Receive(Equal([]byte{i})))
+			}
+		})
+	})
+})
@@ -0,0 +1,94 @@
+package directmq
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// InMemorySpanExporter keeps all exported spans in memory,
+// it is meant to be used in tests and for debugging.
+type InMemorySpanExporter struct {
+	mutex sync.Mutex
+	spans []Span
+}
+
+var _ SpanExporter = (*InMemorySpanExporter)(nil)
+
+func NewInMemorySpanExporter() *InMemorySpanExporter {
+	return &InMemorySpanExporter{
+		spans: make([]Span, 0),
+	}
+}
+
+func (e *InMemorySpanExporter) ExportSpan(span Span) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.spans = append(e.spans, span)
+	return nil
+}
+
+func (e *InMemorySpanExporter) GetSpans() []Span {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	spans := make([]Span, len(e.spans))
+	copy(spans, e.spans)
+	return spans
+}
+
+func (e *InMemorySpanExporter) Reset() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.spans = make([]Span, 0)
+}
+
+// JSONLinesSpanExporter writes every exported span
+// as a single line of JSON.
+type JSONLinesSpanExporter struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+	closer  io.Closer
+}
+
+var _ SpanExporter = (*JSONLinesSpanExporter)(nil)
+
+func NewJSONLinesSpanExporter(w io.Writer) *JSONLinesSpanExporter {
+	return &JSONLinesSpanExporter{
+		encoder: json.NewEncoder(w),
+	}
+}
+
+// NewJSONLinesFileSpanExporter appends the spans to the file,
+// the file is created when it does not exist.
+func NewJSONLinesFileSpanExporter(path string) (*JSONLinesSpanExporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	exporter := NewJSONLinesSpanExporter(file)
+	exporter.closer = file
+	return exporter, nil
+}
+
+func (e *JSONLinesSpanExporter) ExportSpan(span Span) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return e.encoder.Encode(span)
+}
+
+func (e *JSONLinesSpanExporter) Close() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.closer == nil {
+		return nil
+	}
+
+	return e.closer.Close()
+}
@@ -1,6 +1,9 @@
 package directmq
 
-import "math/rand"
+import (
+	"math/rand"
+	"sort"
+)
 
 type SubscriptionID int32
 
@@ -10,29 +13,50 @@ type subscription[THandler any] struct {
 	Handler      THandler
 }
 
+// orderedSubscription keeps the sequence number of the subscription,
+// so the order of adding is restored without reindexing on removal.
+type orderedSubscription[THandler any] struct {
+	subscription[THandler]
+	sequence uint64
+}
+
 type subscriptionList[THandler any] struct {
-	subscriptions []subscription[THandler]
+	subscriptions map[SubscriptionID]orderedSubscription[THandler]
+	sequence      uint64
+
+	index     *topicIndex
+	cover     *topicCoverSet
+	byPattern map[string][]SubscriptionID
 }
 
 func newSubscriptionList[THandler any]() *subscriptionList[THandler] {
 	return &subscriptionList[THandler]{
-		subscriptions: make([]subscription[THandler], 0),
+		subscriptions: make(map[SubscriptionID]orderedSubscription[THandler]),
+
+		index:     newTopicIndex(),
+		cover:     newTopicCoverSet(),
+		byPattern: make(map[string][]SubscriptionID),
 	}
 }
 
 func (l *subscriptionList[THandler]) getRandomID() SubscriptionID {
 	id := SubscriptionID(rand.Int31())
 
-	for _, subscription := range l.subscriptions {
-		if subscription.ID == id {
-			return l.getRandomID()
-		}
+	if _, exists := l.subscriptions[id]; exists {
+		return l.getRandomID()
 	}
 
 	return id
 }
 
 func (l *subscriptionList[THandler]) AddSubscription(topic string, handler *THandler) SubscriptionID {
+	id, _, _ := l.AddSubscriptionWithDiff(topic, handler)
+	return id
+}
+
+// AddSubscriptionWithDiff adds the subscription and returns the changes
+// of the top level subscribed topics caused by it.
+func (l *subscriptionList[THandler]) AddSubscriptionWithDiff(topic string, handler *THandler) (id SubscriptionID, removed []string, added []string) {
 	if handler == nil {
 		panic("handler must not be nil")
 	}
@@ -47,60 +71,112 @@ func (l *subscriptionList[THandler]) AddSubscription(topic string, handler *THan
 		Handler:      *handler,
 	}
 
-	l.subscriptions = append(l.subscriptions, subscription)
+	l.sequence++
+	l.subscriptions[subscription.ID] = orderedSubscription[THandler]{subscription, l.sequence}
+
+	l.index.Add(topic)
+	l.byPattern[topic] = append(l.byPattern[topic], subscription.ID)
 
-	return subscription.ID
+	removed, added = l.cover.Add(topic)
+	return subscription.ID, removed, added
 }
 
 func (l *subscriptionList[THandler]) RemoveSubscription(id SubscriptionID) {
-	for i, subscription := range l.subscriptions {
-		if subscription.ID == id {
-			l.subscriptions = append(l.subscriptions[:i], l.subscriptions[i+1:]...)
-			return
+	l.RemoveSubscriptionWithDiff(id)
+}
+
+// RemoveSubscriptionWithDiff removes the subscription and returns the changes
+// of the top level subscribed topics caused by it.
+func (l *subscriptionList[THandler]) RemoveSubscriptionWithDiff(id SubscriptionID) (removed []string, added []string) {
+	removedSubscription, exists := l.subscriptions[id]
+	if !exists {
+		return []string{}, []string{}
+	}
+
+	topic := removedSubscription.TopicPattern
+	delete(l.subscriptions, id)
+
+	l.index.Remove(topic)
+
+	ids := l.byPattern[topic]
+	for i, subscriptionID := range ids {
+		if subscriptionID == id {
+			ids = append(ids[:i], ids[i+1:]...)
+			break
 		}
 	}
+
+	if len(ids) == 0 {
+		delete(l.byPattern, topic)
+	} else {
+		l.byPattern[topic] = ids
+	}
+
+	return l.cover.Remove(topic)
 }
 
-func (l *subscriptionList[THandler]) GetTriggeredSubscriptions(topic string) []subscription[THandler] {
-	subscriptions := make([]subscription[THandler], 0)
-	for _, subscription := range l.subscriptions {
-		if MatchTopicPattern(subscription.TopicPattern, topic) {
-			subscriptions = append(subscriptions, subscription)
-		}
+// FindSubscriptionByTopicPattern returns the first subscription
+// of the exact topic pattern.
+func (l *subscriptionList[THandler]) FindSubscriptionByTopicPattern(topic string) (id SubscriptionID, found bool) {
+	ids, exists := l.byPattern[topic]
+	if !exists {
+		return 0, false
 	}
 
-	return subscriptions
+	return ids[0], true
 }
 
-func (l *subscriptionList[THandler]) WillHandleTopic(topic string) bool {
-	for _, subscription := range l.subscriptions {
-		if MatchTopicPattern(subscription.TopicPattern, topic) {
-			return true
+// GetTriggeredSubscriptions returns subscriptions matching the topic
+// in the order they were added.
+func (l *subscriptionList[THandler]) GetTriggeredSubscriptions(topic string) []subscription[THandler] {
+	triggered := make([]orderedSubscription[THandler], 0)
+	for _, pattern := range l.index.Match(topic) {
+		for _, id := range l.byPattern[pattern] {
+			triggered = append(triggered, l.subscriptions[id])
 		}
 	}
 
-	return false
+	return sortSubscriptions(triggered)
+}
+
+func (l *subscriptionList[THandler]) WillHandleTopic(topic string) bool {
+	return l.index.HasMatch(topic)
 }
 
 func (l *subscriptionList[THandler]) GetSubscriptions() []subscription[THandler] {
-	subscriptions := make([]subscription[THandler], len(l.subscriptions))
-	copy(subscriptions, l.subscriptions)
+	all := make([]orderedSubscription[THandler], 0, len(l.subscriptions))
+	for _, subscription := range l.subscriptions {
+		all = append(all, subscription)
+	}
 
-	return subscriptions
+	return sortSubscriptions(all)
 }
 
 func (l *subscriptionList[THandler]) GetUniqueSubscribedTopics() []string {
-	topics := make([]string, 0)
-	for _, subscription := range l.subscriptions {
-		topics = append(topics, subscription.TopicPattern)
+	topics := make([]string, 0, len(l.byPattern))
+	for _, subscription := range l.GetSubscriptions() {
+		if l.byPattern[subscription.TopicPattern][0] == subscription.ID {
+			topics = append(topics, subscription.TopicPattern)
+		}
 	}
 
-	return unique(topics)
+	return topics
 }
 
 func (l *subscriptionList[THandler]) GetOnlyTopLevelSubscribedTopics() []string {
-	uniqueTopics := l.GetUniqueSubscribedTopics()
-	topLevelTopics := DeduplicateOverlappingTopics(uniqueTopics)
+	return l.cover.GetTopLevel()
+}
 
-	return topLevelTopics
+// sortSubscriptions returns the subscriptions in the order they were added.
+func sortSubscriptions[THandler any](ordered []orderedSubscription[THandler]) []subscription[THandler] {
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].sequence < ordered[j].sequence
+	})
+
+	subscriptions := make([]subscription[THandler], 0, len(ordered))
+	for _, subscription := range ordered {
+		subscriptions = append(subscriptions, subscription.subscription)
+	}
+
+	return subscriptions
 }
@@ -0,0 +1,177 @@
+package directmq
+
+import (
+	"errors"
+	"sync"
+)
+
+const (
+	NO_TOPIC_ALIAS            = 0
+	DEFAULT_MAX_TOPIC_ALIASES = 16
+)
+
+var errTopicAliasesNotNegotiated = errors.New("unexpected topic alias, topic aliases were not negotiated")
+var errTopicAliasOutOfRange = errors.New("topic alias exceeds the announced maximum number of topic aliases")
+var errUnknownTopicAlias = errors.New("unknown topic alias")
+
+// topicAliases keeps the topic alias tables of the edge.
+//
+// Outgoing aliases are assigned by the host on the first publication
+// of the topic, the frame binding the alias carries both the topic
+// and the alias, next frames carry only the alias. When the table
+// announced by the bridged node is full, the least recently used
+// alias is rebound to the new topic.
+//
+// Incoming aliases are assigned by the bridged node and are bounded
+// by the number of aliases announced by the host. Both tables
+// live only as long as the connection, so they are reset on reconnect.
+//
+// The frame binding the alias is written only once, so the aliases
+// are negotiated only over the reliable portals, see UnreliablePortal.
+type topicAliases struct {
+	mutex sync.Mutex
+
+	enabled bool
+
+	// outgoing direction, alias N is stored at index N-1
+	outgoingTopics   []string
+	outgoingLastUsed []uint64
+	outgoingAliases  map[string]uint32
+	clock            uint64
+
+	// incoming direction, alias N is stored at index N-1
+	incomingTopics []string
+}
+
+func newTopicAliases() *topicAliases {
+	return &topicAliases{
+		outgoingTopics:   make([]string, 0),
+		outgoingLastUsed: make([]uint64, 0),
+		outgoingAliases:  make(map[string]uint32),
+		incomingTopics:   make([]string, 0),
+	}
+}
+
+func (a *topicAliases) Reset(config NetworkNodeConfig, bridgedNode edgeInfo) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.enabled = bridgedNode.NegotiatedFeatures.Has(FEATURE_TOPIC_ALIASES)
+
+	outgoingSize, incomingSize := 0, 0
+	if a.enabled {
+		outgoingSize = int(bridgedNode.BridgedNodeMaxTopicAliases)
+		incomingSize = int(getHostMaxTopicAliases(config))
+	}
+
+	a.outgoingTopics = make([]string, 0, outgoingSize)
+	a.outgoingLastUsed = make([]uint64, 0, outgoingSize)
+	a.outgoingAliases = make(map[string]uint32, outgoingSize)
+	a.clock = 0
+	a.incomingTopics = make([]string, incomingSize)
+}
+
+func (a *topicAliases) Clear() {
+	a.Reset(NetworkNodeConfig{}, edgeInfo{})
+}
+
+// Publish replaces the topic of the publication with its alias
+// and writes it. The write happens under the lock, so the frame
+// binding the alias is always written before the frames using it.
+func (a *topicAliases) Publish(publication PublishMessage, write func(PublishMessage) error) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if !a.enabled || cap(a.outgoingTopics) == 0 {
+		return write(publication)
+	}
+
+	a.clock++
+
+	if alias, bound := a.outgoingAliases[publication.Topic]; bound {
+		a.outgoingLastUsed[alias-1] = a.clock
+		publication.Topic = ""
+		publication.TopicAlias = alias
+		return write(publication)
+	}
+
+	publication.TopicAlias = a.bindOutgoing(publication.Topic)
+	return write(publication)
+}
+
+func (a *topicAliases) bindOutgoing(topic string) uint32 {
+	if len(a.outgoingTopics) < cap(a.outgoingTopics) {
+		a.outgoingTopics = append(a.outgoingTopics, topic)
+		a.outgoingLastUsed = append(a.outgoingLastUsed, a.clock)
+
+		alias := uint32(len(a.outgoingTopics))
+		a.outgoingAliases[topic] = alias
+		return alias
+	}
+
+	leastRecentlyUsed := 0
+	for i, lastUsed := range a.outgoingLastUsed {
+		if lastUsed < a.outgoingLastUsed[leastRecentlyUsed] {
+			leastRecentlyUsed = i
+		}
+	}
+
+	delete(a.outgoingAliases, a.outgoingTopics[leastRecentlyUsed])
+
+	alias := uint32(leastRecentlyUsed + 1)
+	a.outgoingTopics[leastRecentlyUsed] = topic
+	a.outgoingLastUsed[leastRecentlyUsed] = a.clock
+	a.outgoingAliases[topic] = alias
+	return alias
+}
+
+// Resolve binds the alias carried together with the topic
+// or replaces the alias carried alone with the bound topic.
+func (a *topicAliases) Resolve(publication PublishMessage) (PublishMessage, error) {
+	if publication.TopicAlias == NO_TOPIC_ALIAS {
+		return publication, nil
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if !a.enabled {
+		return publication, errTopicAliasesNotNegotiated
+	}
+
+	if int(publication.TopicAlias) > len(a.incomingTopics) {
+		return publication, errTopicAliasOutOfRange
+	}
+
+	index := publication.TopicAlias - 1
+
+	if publication.Topic != "" {
+		a.incomingTopics[index] = publication.Topic
+	} else if a.incomingTopics[index] != "" {
+		publication.Topic = a.incomingTopics[index]
+	} else {
+		return publication, errUnknownTopicAlias
+	}
+
+	publication.TopicAlias = NO_TOPIC_ALIAS
+	return publication, nil
+}
+
+// getHostAnnouncedTopicAliases returns the number of aliases
+// announced to the bridged node, no aliases are announced
+// when the host does not support them.
+func getHostAnnouncedTopicAliases(config NetworkNodeConfig) uint32 {
+	if !getHostFeatures(config).Has(FEATURE_TOPIC_ALIASES) {
+		return 0
+	}
+
+	return getHostMaxTopicAliases(config)
+}
+
+func getHostMaxTopicAliases(config NetworkNodeConfig) uint32 {
+	if config.HostMaxTopicAliases == 0 {
+		return DEFAULT_MAX_TOPIC_ALIASES
+	}
+
+	return config.HostMaxTopicAliases
+}
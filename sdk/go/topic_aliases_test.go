@@ -0,0 +1,213 @@
+package directmq
+
+import (
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// unreliableTestPortal reports the test portal as unreliable.
+type unreliableTestPortal struct {
+	*testPortal
+}
+
+func (p unreliableTestPortal) IsUnreliable() bool {
+	return true
+}
+
+var _ = Describe("topicAliases", func() {
+	var aliases *topicAliases
+	var written []PublishMessage
+
+	write := func(publication PublishMessage) error {
+		written = append(written, publication)
+		return nil
+	}
+
+	config := NetworkNodeConfig{
+		HostFeatures:        FEATURE_TOPIC_ALIASES,
+		HostMaxTopicAliases: 2,
+	}
+
+	bridgedNode := edgeInfo{
+		BridgedNodeMaxTopicAliases: 2,
+		NegotiatedFeatures:         FEATURE_TOPIC_ALIASES,
+	}
+
+	BeforeEach(func() {
+		aliases = newTopicAliases()
+		written = make([]PublishMessage, 0)
+	})
+
+	Context("when topic aliases are not negotiated", func() {
+		It("should send the topic", func() {
+			aliases.Reset(config, edgeInfo{BridgedNodeMaxTopicAliases: 2})
+
+			Expect(aliases.Publish(PublishMessage{Topic: "topic"}, write)).To(Succeed())
+			Expect(aliases.Publish(PublishMessage{Topic: "topic"}, write)).To(Succeed())
+
+			Expect(written).To(Equal([]PublishMessage{{Topic: "topic"}, {Topic: "topic"}}))
+		})
+
+		It("should reject the publication carrying the alias", func() {
+			aliases.Reset(config, edgeInfo{})
+
+			_, err := aliases.Resolve(PublishMessage{Topic: "topic", TopicAlias: 1})
+			Expect(err).To(MatchError(errTopicAliasesNotNegotiated))
+		})
+	})
+
+	Context("when topic aliases are negotiated", func() {
+		BeforeEach(func() {
+			aliases.Reset(config, bridgedNode)
+		})
+
+		It("should bind the alias on the first use and send only the alias later", func() {
+			Expect(aliases.Publish(PublishMessage{Topic: "topic"}, write)).To(Succeed())
+			Expect(aliases.Publish(PublishMessage{Topic: "topic"}, write)).To(Succeed())
+
+			Expect(written).To(Equal([]PublishMessage{
+				{Topic: "topic", TopicAlias: 1},
+				{Topic: "", TopicAlias: 1},
+			}))
+		})
+
+		It("should rebind the least recently used alias when the table is full", func() {
+			for _, topic := range []string{"a", "b", "a", "c", "b"} {
+				Expect(aliases.Publish(PublishMessage{Topic: topic}, write)).To(Succeed())
+			}
+
+			Expect(written).To(Equal([]PublishMessage{
+				{Topic: "a", TopicAlias: 1},
+				{Topic: "b", TopicAlias: 2},
+				{Topic: "", TopicAlias: 1},
+				{Topic: "c", TopicAlias: 2},
+				{Topic: "b", TopicAlias: 1},
+			}))
+		})
+
+		It("should resolve the frames written by the other side", func() {
+			receiver := newTopicAliases()
+			receiver.Reset(config, bridgedNode)
+
+			for _, topic := range []string{"a", "b", "a", "c", "b", "c"} {
+				Expect(aliases.Publish(PublishMessage{Topic: topic}, write)).To(Succeed())
+			}
+
+			resolved := make([]string, 0)
+			for _, publication := range written {
+				publication, err := receiver.Resolve(publication)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(publication.TopicAlias).To(BeEquivalentTo(NO_TOPIC_ALIAS))
+
+				resolved = append(resolved, publication.Topic)
+			}
+
+			Expect(resolved).To(Equal([]string{"a", "b", "a", "c", "b", "c"}))
+		})
+
+		It("should reject the unknown alias", func() {
+			_, err := aliases.Resolve(PublishMessage{TopicAlias: 1})
+			Expect(err).To(MatchError(errUnknownTopicAlias))
+		})
+
+		It("should reject the alias exceeding the announced table size", func() {
+			_, err := aliases.Resolve(PublishMessage{Topic: "topic", TopicAlias: 3})
+			Expect(err).To(MatchError(errTopicAliasOutOfRange))
+		})
+
+		It("should forget every alias on reset", func() {
+			Expect(aliases.Publish(PublishMessage{Topic: "topic"}, write)).To(Succeed())
+			_, err := aliases.Resolve(PublishMessage{Topic: "topic", TopicAlias: 1})
+			Expect(err).ToNot(HaveOccurred())
+
+			aliases.Reset(config, bridgedNode)
+
+			Expect(aliases.Publish(PublishMessage{Topic: "topic"}, write)).To(Succeed())
+			Expect(written[1]).To(Equal(PublishMessage{Topic: "topic", TopicAlias: 1}))
+
+			_, err = aliases.Resolve(PublishMessage{TopicAlias: 1})
+			Expect(err).To(MatchError(errUnknownTopicAlias))
+		})
+	})
+
+	Context("when nodes are bridged", func() {
+		newNode := func(id string, features ProtocolFeatures) *networkNode {
+			return newNetworkNode(NetworkNodeConfig{
+				HostID:       id,
+				HostTTL:      DEFAULT_TTL,
+				HostFeatures: features,
+			}, NewProtobufBinaryProtocol())
+		}
+
+		getEdge := func(node *networkNode) *networkEdge {
+			for _, participant := range node.network.participants {
+				if edge, isEdge := participant.(*networkEdge); isEdge {
+					return edge
+				}
+			}
+
+			return nil
+		}
+
+		deliverPublications := func(publisher, subscriber *networkNode) []string {
+			mutex := sync.Mutex{}
+			received := make([]string, 0)
+
+			subscriber.Subscribe("sensors/*", func(payload []byte) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				received = append(received, string(payload))
+			})
+
+			Eventually(publisher.GetEdges).Should(ContainElement(HaveField("BridgedNodeSubscriptions", HaveLen(1))))
+
+			for _, topic := range []string{"sensors/a", "sensors/b", "sensors/a"} {
+				publisher.Publish(topic, []byte(topic), AT_LEAST_ONCE)
+			}
+
+			Eventually(func() []string {
+				mutex.Lock()
+				defer mutex.Unlock()
+				return append([]string{}, received...)
+			}).Should(Equal([]string{"sensors/a", "sensors/b", "sensors/a"}))
+
+			publisher.network.lock()
+			defer publisher.network.unlock()
+			return append([]string{}, getEdge(publisher).aliases.outgoingTopics...)
+		}
+
+		It("should use the aliases when both nodes support them", func() {
+			a, b := newNode("a", FEATURE_TOPIC_ALIASES), newNode("b", FEATURE_TOPIC_ALIASES)
+			defer a.CloseNode("test finished")
+
+			connectTestNodes(a, b)
+			Eventually(a.GetBridgedNodeIDs).Should(HaveLen(1))
+
+			Expect(deliverPublications(a, b)).To(Equal([]string{"sensors/a", "sensors/b"}))
+		})
+
+		It("should not use the aliases when only one node supports them", func() {
+			a, b := newNode("a", FEATURE_TOPIC_ALIASES), newNode("b", NO_PROTOCOL_FEATURES)
+			defer a.CloseNode("test finished")
+
+			connectTestNodes(a, b)
+			Eventually(a.GetBridgedNodeIDs).Should(HaveLen(1))
+
+			Expect(deliverPublications(a, b)).To(BeEmpty())
+		})
+
+		It("should not use the aliases over the unreliable portal", func() {
+			a, b := newNode("a", FEATURE_TOPIC_ALIASES), newNode("b", FEATURE_TOPIC_ALIASES)
+			defer a.CloseNode("test finished")
+
+			connectingPortal, listeningPortal := newTestPortalPair()
+			go b.AddListeningEdge(listeningPortal)
+			go a.AddConnectingEdge(unreliableTestPortal{connectingPortal})
+			Eventually(a.GetBridgedNodeIDs).Should(HaveLen(1))
+
+			Expect(deliverPublications(a, b)).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,175 @@
+package directmq
+
+import "sort"
+
+// topicCoverSet keeps the reference counted topic patterns together
+// with their top level cover set, the patterns which are not covered
+// by any other pattern of the set. The cover set is updated incrementally,
+// so adding or removing the pattern compares it only with the top level
+// patterns and, when the top level pattern is removed, with the patterns
+// it was covering.
+type topicCoverSet struct {
+	patterns map[string]*topicCoverEntry
+	topLevel map[string]struct{}
+}
+
+type topicCoverEntry struct {
+	tokens []topicPatternToken
+	count  int
+}
+
+func newTopicCoverSet() *topicCoverSet {
+	return &topicCoverSet{
+		patterns: make(map[string]*topicCoverEntry),
+		topLevel: make(map[string]struct{}),
+	}
+}
+
+// Add adds the reference of the pattern and returns
+// the changes of the top level cover set.
+func (s *topicCoverSet) Add(pattern string) (removed []string, added []string) {
+	removed, added = make([]string, 0), make([]string, 0)
+
+	if entry, exists := s.patterns[pattern]; exists {
+		entry.count++
+		return
+	}
+
+	s.patterns[pattern] = &topicCoverEntry{
+		tokens: tokenizeTopicPattern(pattern),
+		count:  1,
+	}
+
+	for topLevel := range s.topLevel {
+		if s.dominates(topLevel, pattern) {
+			return
+		}
+	}
+
+	for topLevel := range s.topLevel {
+		if s.dominates(pattern, topLevel) {
+			delete(s.topLevel, topLevel)
+			removed = append(removed, topLevel)
+		}
+	}
+
+	s.topLevel[pattern] = struct{}{}
+	added = append(added, pattern)
+
+	sort.Strings(removed)
+	return
+}
+
+// Remove removes the reference of the pattern and returns
+// the changes of the top level cover set.
+func (s *topicCoverSet) Remove(pattern string) (removed []string, added []string) {
+	removed, added = make([]string, 0), make([]string, 0)
+
+	entry, exists := s.patterns[pattern]
+	if !exists {
+		return
+	}
+
+	entry.count--
+	if entry.count > 0 {
+		return
+	}
+
+	if _, isTopLevel := s.topLevel[pattern]; !isTopLevel {
+		delete(s.patterns, pattern)
+		return
+	}
+
+	// patterns covered by the removed one are dominated only by it
+	// or by other top level patterns, which still dominate them
+	candidates := make([]string, 0)
+	for candidate := range s.patterns {
+		if _, isTopLevel := s.topLevel[candidate]; !isTopLevel && s.dominates(pattern, candidate) {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	delete(s.patterns, pattern)
+	delete(s.topLevel, pattern)
+	removed = append(removed, pattern)
+
+	for _, candidate := range candidates {
+		if !s.isDominatedByAnyOf(candidate, candidates) && !s.isDominatedByTopLevel(candidate) {
+			added = append(added, candidate)
+		}
+	}
+
+	for _, pattern := range added {
+		s.topLevel[pattern] = struct{}{}
+	}
+
+	sort.Strings(added)
+	return
+}
+
+// Covers checks if the pattern is in the set or any top level
+// pattern matches every topic of it.
+func (s *topicCoverSet) Covers(pattern string) bool {
+	if s.Contains(pattern) {
+		return true
+	}
+
+	tokens := tokenizeTopicPattern(pattern)
+	for topLevel := range s.topLevel {
+		if patternCovers(s.patterns[topLevel].tokens, tokens) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *topicCoverSet) Contains(pattern string) bool {
+	_, exists := s.patterns[pattern]
+	return exists
+}
+
+// GetTopLevel returns the top level patterns sorted alphabetically.
+func (s *topicCoverSet) GetTopLevel() []string {
+	topLevel := make([]string, 0, len(s.topLevel))
+	for pattern := range s.topLevel {
+		topLevel = append(topLevel, pattern)
+	}
+
+	sort.Strings(topLevel)
+	return topLevel
+}
+
+func (s *topicCoverSet) isDominatedByTopLevel(pattern string) bool {
+	for topLevel := range s.topLevel {
+		if s.dominates(topLevel, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *topicCoverSet) isDominatedByAnyOf(pattern string, others []string) bool {
+	for _, other := range others {
+		if s.dominates(other, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dominates checks if the pattern a covers the other pattern b,
+// from the patterns covering each other the alphabetically first one wins.
+func (s *topicCoverSet) dominates(a, b string) bool {
+	if a == b || !s.covers(a, b) {
+		return false
+	}
+
+	return !s.covers(b, a) || a < b
+}
+
+func (s *topicCoverSet) covers(a, b string) bool {
+	return patternCovers(s.patterns[a].tokens, s.patterns[b].tokens)
+}
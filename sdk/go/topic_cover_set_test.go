@@ -0,0 +1,132 @@
+package directmq
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// bruteForceTopLevel returns patterns which are not covered
+// by any other pattern, from the patterns covering each other
+// the alphabetically first one is kept.
+func bruteForceTopLevel(patterns []string) []string {
+	covers := func(a, b string) bool {
+		return patternCovers(tokenizeTopicPattern(a), tokenizeTopicPattern(b))
+	}
+
+	topLevel := make([]string, 0)
+	for _, pattern := range patterns {
+		dominated := false
+		for _, other := range patterns {
+			if other == pattern || !covers(other, pattern) {
+				continue
+			}
+
+			if !covers(pattern, other) || other < pattern {
+				dominated = true
+				break
+			}
+		}
+
+		if !dominated {
+			topLevel = append(topLevel, pattern)
+		}
+	}
+
+	sort.Strings(topLevel)
+	return topLevel
+}
+
+var _ = Describe("topicCoverSet", func() {
+	It("should report the top level patterns changes", func() {
+		cover := newTopicCoverSet()
+
+		removed, added := cover.Add("a/b")
+		Expect(removed).To(BeEmpty())
+		Expect(added).To(Equal([]string{"a/b"}))
+
+		removed, added = cover.Add("a/*")
+		Expect(removed).To(Equal([]string{"a/b"}))
+		Expect(added).To(Equal([]string{"a/*"}))
+
+		removed, added = cover.Add("a/c")
+		Expect(removed).To(BeEmpty())
+		Expect(added).To(BeEmpty())
+
+		removed, added = cover.Remove("a/*")
+		Expect(removed).To(Equal([]string{"a/*"}))
+		Expect(added).To(Equal([]string{"a/b", "a/c"}))
+	})
+
+	It("should keep the pattern until every reference is removed", func() {
+		cover := newTopicCoverSet()
+		cover.Add("a/**")
+		cover.Add("a/**")
+
+		removed, added := cover.Remove("a/**")
+		Expect(removed).To(BeEmpty())
+		Expect(added).To(BeEmpty())
+		Expect(cover.GetTopLevel()).To(Equal([]string{"a/**"}))
+
+		removed, _ = cover.Remove("a/**")
+		Expect(removed).To(Equal([]string{"a/**"}))
+		Expect(cover.Contains("a/**")).To(BeFalse())
+	})
+
+	It("should keep the same top level patterns as the brute force solution", func() {
+		random := rand.New(rand.NewSource(GinkgoRandomSeed()))
+
+		cover := newTopicCoverSet()
+		present := make([]string, 0)
+		topLevel := make(map[string]struct{})
+
+		for i := 0; i < 200; i++ {
+			var removed, added []string
+
+			if len(present) > 0 && random.Intn(3) == 0 {
+				index := random.Intn(len(present))
+				removed, added = cover.Remove(present[index])
+				present = append(present[:index], present[index+1:]...)
+			} else {
+				pattern := randomTopicPattern(random, true)
+				removed, added = cover.Add(pattern)
+				present = append(present, pattern)
+			}
+
+			for _, pattern := range removed {
+				Expect(topLevel).To(HaveKey(pattern))
+				delete(topLevel, pattern)
+			}
+
+			for _, pattern := range added {
+				Expect(topLevel).ToNot(HaveKey(pattern))
+				topLevel[pattern] = struct{}{}
+			}
+
+			expected := bruteForceTopLevel(unique(present))
+			Expect(cover.GetTopLevel()).To(Equal(expected), "step %d", i)
+			Expect(topLevel).To(HaveLen(len(expected)), "step %d", i)
+		}
+	})
+})
+
+func BenchmarkTopicCoverSetChurn(b *testing.B) {
+	for _, size := range []int{100, 1000, 10000} {
+		cover := newTopicCoverSet()
+		for i := 0; i < size; i++ {
+			cover.Add(fmt.Sprintf("devices/%d/telemetry", i))
+			cover.Add(fmt.Sprintf("devices/%d/commands/*", i))
+		}
+
+		b.Run(fmt.Sprintf("patterns=%d", 2*size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				cover.Add("devices/churn/*")
+				cover.Remove("devices/churn/*")
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package directmq
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// TopicGrammar selects the syntax of the topic patterns accepted
+// by the native API of the node. Patterns are always exchanged
+// with bridged nodes in the glob syntax, so nodes using
+// different grammars can be bridged together.
+type TopicGrammar int
+
+const (
+	// Glob patterns, * matches any characters but the separator
+	// and ** matches any characters.
+	TOPIC_GRAMMAR_GLOB TopicGrammar = iota
+
+	// MQTT topic filters, + matches a single level and trailing #
+	// matches the parent level and any number of child levels.
+	TOPIC_GRAMMAR_MQTT
+)
+
+const (
+	MQTT_SINGLE_LEVEL_WILDCARD = "+"
+	MQTT_MULTI_LEVEL_WILDCARD  = "#"
+)
+
+var ErrIncorrectMQTTTopicFilter = errors.New("incorrect MQTT topic filter")
+var ErrNotExpressibleInMQTT = errors.New("topic pattern is not expressible as MQTT topic filter")
+
+var allowedMQTTTopicFilterCharactersRegex = regexp.MustCompile("^[a-zA-Z0-9_/@+#]+$")
+
+// IsCorrectMQTTTopicFilter checks the filter against the MQTT rules
+// restricted to the characters and levels allowed in DirectMQ topics,
+// wildcards have to occupy the whole level and # has to be the last one.
+func IsCorrectMQTTTopicFilter(filter string) bool {
+	if len(filter) == 0 || !allowedMQTTTopicFilterCharactersRegex.MatchString(filter) {
+		return false
+	}
+
+	levels := strings.Split(filter, "/")
+	for i, level := range levels {
+		switch {
+		case len(level) == 0:
+			return false
+		case level == MQTT_MULTI_LEVEL_WILDCARD && i != len(levels)-1:
+			return false
+		case level != MQTT_SINGLE_LEVEL_WILDCARD && level != MQTT_MULTI_LEVEL_WILDCARD && strings.ContainsAny(level, "+#"):
+			return false
+		}
+	}
+
+	return true
+}
+
+// MatchMQTTTopicFilter checks if the topic matches the MQTT filter,
+// topic itself cannot contain any wildcards.
+func MatchMQTTTopicFilter(filter string, topic string) bool {
+	if !IsCorrectMQTTTopicFilter(filter) || !IsCorrectTopicPattern(topic) || strings.Contains(topic, "*") {
+		return false
+	}
+
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+
+	for i, level := range filterLevels {
+		if level == MQTT_MULTI_LEVEL_WILDCARD {
+			return true
+		}
+
+		if i >= len(topicLevels) {
+			return false
+		}
+
+		if level != MQTT_SINGLE_LEVEL_WILDCARD && level != topicLevels[i] {
+			return false
+		}
+	}
+
+	return len(filterLevels) == len(topicLevels)
+}
+
+// MQTTFilterToTopicPatterns converts the MQTT filter into the glob
+// patterns matching the same topics. Filter ending with /# matches
+// its parent level too, so it is converted into two patterns.
+func MQTTFilterToTopicPatterns(filter string) ([]string, error) {
+	if !IsCorrectMQTTTopicFilter(filter) {
+		return nil, ErrIncorrectMQTTTopicFilter
+	}
+
+	levels := strings.Split(filter, "/")
+	for i, level := range levels {
+		if level == MQTT_SINGLE_LEVEL_WILDCARD {
+			levels[i] = "*"
+		}
+	}
+
+	if levels[len(levels)-1] != MQTT_MULTI_LEVEL_WILDCARD {
+		return []string{strings.Join(levels, "/")}, nil
+	}
+
+	if len(levels) == 1 {
+		return []string{"**"}, nil
+	}
+
+	parent := strings.Join(levels[:len(levels)-1], "/")
+	return []string{parent, parent + "/**"}, nil
+}
+
+// TopicPatternToMQTTFilter converts the glob pattern into the MQTT filter.
+// Only wildcards occupying the whole level are expressible in MQTT
+// and ** only as the last level. Pattern ending with /** is converted
+// into the filter ending with /#, which matches the parent level as well.
+func TopicPatternToMQTTFilter(pattern string) (string, error) {
+	if !IsCorrectTopicPattern(pattern) {
+		return "", ErrNotExpressibleInMQTT
+	}
+
+	levels := strings.Split(pattern, "/")
+	for i, level := range levels {
+		switch {
+		case level == "*":
+			levels[i] = MQTT_SINGLE_LEVEL_WILDCARD
+		case level == "**" && i == len(levels)-1:
+			levels[i] = MQTT_MULTI_LEVEL_WILDCARD
+		case strings.Contains(level, "*"):
+			return "", ErrNotExpressibleInMQTT
+		}
+	}
+
+	return strings.Join(levels, "/"), nil
+}
+
+// getTopicPatterns converts the pattern given in the grammar
+// into the glob patterns used internally.
+func getTopicPatterns(grammar TopicGrammar, pattern string) ([]string, error) {
+	if grammar == TOPIC_GRAMMAR_MQTT {
+		return MQTTFilterToTopicPatterns(pattern)
+	}
+
+	if !IsCorrectTopicPattern(pattern) {
+		return nil, errors.New("incorrect topic pattern")
+	}
+
+	return []string{pattern}, nil
+}
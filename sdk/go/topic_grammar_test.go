@@ -0,0 +1,125 @@
+package directmq
+
+import (
+	"math/rand"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MQTT topic grammar", func() {
+	DescribeTable("IsCorrectMQTTTopicFilter",
+		func(filter string, expected bool) {
+			Expect(IsCorrectMQTTTopicFilter(filter)).To(Equal(expected))
+		},
+		Entry("topic", "sensors/temperature", true),
+		Entry("single level wildcard", "sensors/+/temperature", true),
+		Entry("multi level wildcard", "sensors/#", true),
+		Entry("multi level wildcard only", "#", true),
+		Entry("both wildcards", "+/+/#", true),
+		Entry("empty filter", "", false),
+		Entry("empty level", "sensors//temperature", false),
+		Entry("multi level wildcard not at the end", "sensors/#/temperature", false),
+		Entry("wildcard as a part of the level", "sensors/temp+", false),
+		Entry("glob wildcard", "sensors/*", false),
+	)
+
+	DescribeTable("MatchMQTTTopicFilter",
+		func(filter string, topic string, expected bool) {
+			Expect(MatchMQTTTopicFilter(filter, topic)).To(Equal(expected))
+		},
+		Entry("same topic", "a/b", "a/b", true),
+		Entry("single level", "a/+", "a/b", true),
+		Entry("single level not matching deeper levels", "a/+", "a/b/c", false),
+		Entry("multi level", "a/#", "a/b/c", true),
+		Entry("multi level matching the parent", "a/#", "a", true),
+		Entry("multi level only", "#", "a/b", true),
+		Entry("different topic", "a/+/c", "a/b/d", false),
+	)
+
+	Describe("conversion", func() {
+		It("should convert MQTT filters into glob patterns", func() {
+			Expect(MQTTFilterToTopicPatterns("a/+/c")).To(Equal([]string{"a/*/c"}))
+			Expect(MQTTFilterToTopicPatterns("a/+/#")).To(Equal([]string{"a/*", "a/*/**"}))
+			Expect(MQTTFilterToTopicPatterns("#")).To(Equal([]string{"**"}))
+
+			_, err := MQTTFilterToTopicPatterns("a/#/c")
+			Expect(err).To(MatchError(ErrIncorrectMQTTTopicFilter))
+		})
+
+		It("should convert glob patterns into MQTT filters", func() {
+			Expect(TopicPatternToMQTTFilter("a/*/c")).To(Equal("a/+/c"))
+			Expect(TopicPatternToMQTTFilter("a/**")).To(Equal("a/#"))
+
+			_, err := TopicPatternToMQTTFilter("a/b*")
+			Expect(err).To(MatchError(ErrNotExpressibleInMQTT))
+
+			_, err = TopicPatternToMQTTFilter("a/**/c")
+			Expect(err).To(MatchError(ErrNotExpressibleInMQTT))
+		})
+
+		It("should convert filters into patterns matching the same topics", func() {
+			random := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			levels := []string{"a", "b", "+"}
+
+			randomLevels := func(alphabet []string) []string {
+				result := make([]string, 1+random.Intn(3))
+				for i := range result {
+					result[i] = alphabet[random.Intn(len(alphabet))]
+				}
+
+				return result
+			}
+
+			for i := 0; i < 200; i++ {
+				filterLevels := randomLevels(levels)
+				if random.Intn(2) == 0 {
+					filterLevels = append(filterLevels, MQTT_MULTI_LEVEL_WILDCARD)
+				}
+
+				filter := strings.Join(filterLevels, "/")
+				patterns, err := MQTTFilterToTopicPatterns(filter)
+				Expect(err).ToNot(HaveOccurred())
+
+				topic := strings.Join(randomLevels([]string{"a", "b"}), "/")
+
+				matchesPattern := false
+				for _, pattern := range patterns {
+					matchesPattern = matchesPattern || MatchTopicPattern(pattern, topic)
+				}
+
+				Expect(matchesPattern).To(Equal(MatchMQTTTopicFilter(filter, topic)), "filter: %s, topic: %s", filter, topic)
+			}
+		})
+	})
+
+	Context("when the node uses MQTT grammar", func() {
+		var node *networkNode
+
+		BeforeEach(func() {
+			node = newNetworkNode(NetworkNodeConfig{
+				HostID:           "host",
+				HostTTL:          DEFAULT_TTL,
+				HostTopicGrammar: TOPIC_GRAMMAR_MQTT,
+			}, NewProtobufJSONProtocol())
+		})
+
+		It("should deliver publications matching the filter", func() {
+			received := make([]string, 0)
+			id := node.Subscribe("sensors/#", func(payload []byte) { received = append(received, string(payload)) })
+
+			node.Publish("sensors", []byte("parent"), AT_LEAST_ONCE)
+			node.Publish("sensors/kitchen/temperature", []byte("child"), AT_LEAST_ONCE)
+			node.Publish("actuators/kitchen", []byte("other"), AT_LEAST_ONCE)
+			Expect(received).To(Equal([]string{"parent", "child"}))
+
+			node.Unsubscribe(id)
+			Expect(node.api.subscriptions.GetSubscriptions()).To(BeEmpty())
+		})
+
+		It("should panic if the filter is incorrect", func() {
+			Expect(func() { node.Subscribe("sensors/*", func([]byte) {}) }).To(Panic())
+		})
+	})
+})
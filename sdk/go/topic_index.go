@@ -0,0 +1,205 @@
+package directmq
+
+import (
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+// topicIndex is a segment trie of the topic patterns used to find
+// patterns matching the topic without testing every pattern.
+//
+// Literal segments and the single segment wildcards are the edges
+// of the trie, so the patterns like */status are found by walking
+// the trie too. The first segment with the super wildcard, or with
+// the wildcard mixed with other characters, ends the path and the rest
+// of the pattern is matched with the glob compiled once, when the pattern
+// is added. Such patterns are tested one by one by every lookup passing
+// through the node ending their path, e.g. every pattern starting with **
+// is tested by every lookup, see BenchmarkTopicIndexRootWildcards.
+// Every pattern is reference counted, so the same pattern may be added
+// multiple times.
+//
+// The topics are not validated by the lookups, the API accepting
+// the topic from the user or from the bridged node validates it once.
+type topicIndex struct {
+	root *topicIndexNode
+}
+
+type topicIndexNode struct {
+	children map[string]*topicIndexNode
+
+	// child of the * segment, matching any single segment of the topic
+	anySegment *topicIndexNode
+
+	// pattern without the glob segments ending at this node
+	// and the number of its references
+	pattern string
+	count   int
+
+	// patterns with glob segments sharing the path to this node
+	wildcards map[string]*topicIndexMatcher
+}
+
+type topicIndexMatcher struct {
+	glob  glob.Glob
+	count int
+}
+
+func newTopicIndex() *topicIndex {
+	return &topicIndex{root: newTopicIndexNode()}
+}
+
+func newTopicIndexNode() *topicIndexNode {
+	return &topicIndexNode{
+		children:  make(map[string]*topicIndexNode),
+		wildcards: make(map[string]*topicIndexMatcher),
+	}
+}
+
+// isGlobSegment checks if the segment can not be the edge of the trie.
+func isGlobSegment(segment string) bool {
+	return segment != "*" && strings.Contains(segment, "*")
+}
+
+func (i *topicIndex) Add(pattern string) {
+	node := i.root
+	for _, segment := range strings.Split(pattern, "/") {
+		if isGlobSegment(segment) {
+			matcher, exists := node.wildcards[pattern]
+			if !exists {
+				matcher = &topicIndexMatcher{glob: glob.MustCompile(pattern, '/')}
+				node.wildcards[pattern] = matcher
+			}
+
+			matcher.count++
+			return
+		}
+
+		child := node.getChild(segment)
+		if child == nil {
+			child = newTopicIndexNode()
+			node.setChild(segment, child)
+		}
+
+		node = child
+	}
+
+	node.pattern = pattern
+	node.count++
+}
+
+func (i *topicIndex) Remove(pattern string) {
+	i.root.remove(pattern, strings.Split(pattern, "/"))
+}
+
+func (n *topicIndexNode) getChild(segment string) *topicIndexNode {
+	if segment == "*" {
+		return n.anySegment
+	}
+
+	return n.children[segment]
+}
+
+func (n *topicIndexNode) setChild(segment string, child *topicIndexNode) {
+	if segment == "*" {
+		n.anySegment = child
+		return
+	}
+
+	if child == nil {
+		delete(n.children, segment)
+		return
+	}
+
+	n.children[segment] = child
+}
+
+func (n *topicIndexNode) remove(pattern string, segments []string) {
+	if len(segments) == 0 {
+		if n.count > 0 {
+			n.count--
+		}
+
+		if n.count == 0 {
+			n.pattern = ""
+		}
+
+		return
+	}
+
+	if isGlobSegment(segments[0]) {
+		if matcher, exists := n.wildcards[pattern]; exists {
+			matcher.count--
+			if matcher.count <= 0 {
+				delete(n.wildcards, pattern)
+			}
+		}
+
+		return
+	}
+
+	child := n.getChild(segments[0])
+	if child == nil {
+		return
+	}
+
+	child.remove(pattern, segments[1:])
+
+	if child.isEmpty() {
+		n.setChild(segments[0], nil)
+	}
+}
+
+func (n *topicIndexNode) isEmpty() bool {
+	return n.count == 0 && len(n.children) == 0 && n.anySegment == nil && len(n.wildcards) == 0
+}
+
+// Match returns every distinct pattern matching the topic.
+func (i *topicIndex) Match(topic string) []string {
+	patterns := make([]string, 0)
+	i.root.walk(topic, topic, func(pattern string) bool {
+		patterns = append(patterns, pattern)
+		return true
+	})
+
+	return patterns
+}
+
+// HasMatch checks if any pattern matches the topic.
+func (i *topicIndex) HasMatch(topic string) bool {
+	found := false
+	i.root.walk(topic, topic, func(string) bool {
+		found = true
+		return false
+	})
+
+	return found
+}
+
+// walk calls the visit function with every pattern matching the topic,
+// the rest is the part of the topic below this node. It returns false
+// when the visit function stopped the walk by returning false.
+func (n *topicIndexNode) walk(topic string, rest string, visit func(pattern string) bool) bool {
+	for pattern, matcher := range n.wildcards {
+		if matcher.glob.Match(topic) && !visit(pattern) {
+			return false
+		}
+	}
+
+	if rest == "" {
+		return n.count == 0 || visit(n.pattern)
+	}
+
+	segment := rest
+	rest = ""
+	if separator := strings.IndexByte(segment, '/'); separator >= 0 {
+		segment, rest = segment[:separator], segment[separator+1:]
+	}
+
+	if child, exists := n.children[segment]; exists && !child.walk(topic, rest, visit) {
+		return false
+	}
+
+	return n.anySegment == nil || n.anySegment.walk(topic, rest, visit)
+}
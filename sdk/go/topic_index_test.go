@@ -0,0 +1,186 @@
+package directmq
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// randomTopicPattern generates patterns from a small alphabet,
+// so the generated patterns and topics often overlap.
+func randomTopicPattern(random *rand.Rand, withWildcards bool) string {
+	segments := []string{"a", "b", "ab", "c1"}
+	wildcards := []string{"*", "**", "a*", "*b"}
+
+	parts := make([]string, 1+random.Intn(4))
+	for i := range parts {
+		if withWildcards && random.Intn(3) == 0 {
+			parts[i] = wildcards[random.Intn(len(wildcards))]
+		} else {
+			parts[i] = segments[random.Intn(len(segments))]
+		}
+	}
+
+	return strings.Join(parts, "/")
+}
+
+var _ = Describe("topicIndex", func() {
+	It("should match the same patterns as MatchTopicPattern", func() {
+		random := rand.New(rand.NewSource(GinkgoRandomSeed()))
+
+		index := newTopicIndex()
+		patterns := make([]string, 0)
+		for i := 0; i < 100; i++ {
+			pattern := randomTopicPattern(random, true)
+			patterns = append(patterns, pattern)
+			index.Add(pattern)
+		}
+
+		for i := 0; i < 300; i++ {
+			topic := randomTopicPattern(random, false)
+
+			expected := make([]string, 0)
+			for _, pattern := range unique(patterns) {
+				if MatchTopicPattern(pattern, topic) {
+					expected = append(expected, pattern)
+				}
+			}
+
+			Expect(index.Match(topic)).To(ConsistOf(expected), "topic: %s", topic)
+			Expect(index.HasMatch(topic)).To(Equal(len(expected) > 0), "topic: %s", topic)
+		}
+	})
+
+	It("should keep the pattern until every reference is removed", func() {
+		index := newTopicIndex()
+		index.Add("a/*")
+		index.Add("a/*")
+		index.Add("a/b")
+
+		index.Remove("a/*")
+		Expect(index.Match("a/c")).To(ConsistOf("a/*"))
+
+		index.Remove("a/*")
+		index.Remove("a/b")
+		Expect(index.HasMatch("a/b")).To(BeFalse())
+		Expect(index.root.isEmpty()).To(BeTrue())
+	})
+
+	It("should walk the single segment wildcards as the trie edges", func() {
+		index := newTopicIndex()
+		index.Add("*/status")
+		index.Add("*/*")
+		index.Add("a/*")
+
+		Expect(index.root.wildcards).To(BeEmpty())
+		Expect(index.Match("a/status")).To(ConsistOf("*/status", "*/*", "a/*"))
+		Expect(index.Match("b/status")).To(ConsistOf("*/status", "*/*"))
+		Expect(index.Match("b/status/c")).To(BeEmpty())
+
+		index.Remove("*/status")
+		index.Remove("*/*")
+		index.Remove("a/*")
+		Expect(index.root.isEmpty()).To(BeTrue())
+	})
+})
+
+func newBenchmarkSubscriptionList(size int) *subscriptionList[struct{}] {
+	list := newSubscriptionList[struct{}]()
+	handler := struct{}{}
+
+	for i := 0; i < size; i++ {
+		switch i % 4 {
+		case 0:
+			list.AddSubscription(fmt.Sprintf("devices/%d/telemetry", i), &handler)
+		case 1:
+			list.AddSubscription(fmt.Sprintf("devices/%d/*", i), &handler)
+		case 2:
+			list.AddSubscription(fmt.Sprintf("devices/%d/commands/**", i), &handler)
+		case 3:
+			list.AddSubscription(fmt.Sprintf("sensors/%d/temp*", i), &handler)
+		}
+	}
+
+	return list
+}
+
+func BenchmarkSubscriptionListGetTriggeredSubscriptions(b *testing.B) {
+	for _, size := range []int{10, 100, 1000, 10000} {
+		list := newBenchmarkSubscriptionList(size)
+		topic := fmt.Sprintf("devices/%d/telemetry", size/2)
+
+		b.Run(fmt.Sprintf("subscriptions=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				list.GetTriggeredSubscriptions(topic)
+			}
+		})
+	}
+}
+
+func BenchmarkSubscriptionListWillHandleTopic(b *testing.B) {
+	for _, size := range []int{10, 100, 1000, 10000} {
+		list := newBenchmarkSubscriptionList(size)
+
+		b.Run(fmt.Sprintf("subscriptions=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				list.WillHandleTopic("devices/unknown/telemetry")
+			}
+		})
+	}
+}
+
+func BenchmarkSubscriptionListChurn(b *testing.B) {
+	for _, size := range []int{100, 1000, 10000} {
+		list := newBenchmarkSubscriptionList(size)
+		handler := struct{}{}
+
+		b.Run(fmt.Sprintf("subscriptions=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				id := list.AddSubscription("devices/churn/*", &handler)
+				list.RemoveSubscription(id)
+			}
+		})
+	}
+}
+
+// BenchmarkTopicIndexRootWildcards measures the lookups passing through
+// the root wildcards: the patterns starting with ** are tested one by one,
+// while the patterns starting with * are walked in the trie.
+func BenchmarkTopicIndexRootWildcards(b *testing.B) {
+	for _, wildcard := range []string{"**", "*"} {
+		for _, size := range []int{10, 100, 1000} {
+			index := newTopicIndex()
+			for i := 0; i < size; i++ {
+				index.Add(fmt.Sprintf("%s/status%d", wildcard, i))
+			}
+
+			b.Run(fmt.Sprintf("wildcard=%s/subscriptions=%d", wildcard, size), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					index.HasMatch("devices/unknown")
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkLinearTopicMatching is the baseline of matching
+// every subscription pattern one by one.
+func BenchmarkLinearTopicMatching(b *testing.B) {
+	for _, size := range []int{10, 100, 1000} {
+		patterns := newBenchmarkSubscriptionList(size).GetUniqueSubscribedTopics()
+		sort.Strings(patterns)
+
+		b.Run(fmt.Sprintf("subscriptions=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, pattern := range patterns {
+					MatchTopicPattern(pattern, "devices/unknown/telemetry")
+				}
+			}
+		})
+	}
+}
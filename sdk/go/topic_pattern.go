@@ -1,15 +1,17 @@
 package directmq
 
 import (
+	"errors"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/gobwas/glob"
 )
 
-func IsCorrectTopicPattern(pattern string) bool {
-	allowedTopicPatternCharactersRegex := "^[a-zA-Z0-9_*/@]+$"
+var allowedTopicPatternCharactersRegex = regexp.MustCompile("^[a-zA-Z0-9_*/@]+$")
 
+func IsCorrectTopicPattern(pattern string) bool {
 	if len(pattern) == 0 {
 		return false
 	}
@@ -30,10 +32,15 @@ func IsCorrectTopicPattern(pattern string) bool {
 		return false
 	}
 
-	if !regexp.MustCompile(allowedTopicPatternCharactersRegex).Match([]byte(pattern)) {
+	if !allowedTopicPatternCharactersRegex.MatchString(pattern) {
 		return false
 	}
 
+	if !strings.Contains(pattern, "*") {
+		// only wildcards could make the glob invalid
+		return true
+	}
+
 	_, err := glob.Compile(pattern)
 
 	return err == nil
@@ -53,36 +60,115 @@ func MatchTopicPattern(pattern string, topic string) bool {
 	return g.Match(topic)
 }
 
+// IsSubtopicPattern checks if every topic matched by the target pattern
+// is matched by the top level pattern as well.
 func IsSubtopicPattern(topLevelPattern string, target string) bool {
-	return MatchTopicPattern(topLevelPattern, target)
+	if !IsCorrectTopicPattern(topLevelPattern) || !IsCorrectTopicPattern(target) {
+		return false
+	}
+
+	return patternCovers(tokenizeTopicPattern(topLevelPattern), tokenizeTopicPattern(target))
 }
 
-func DeduplicateOverlappingTopics(topics []string) []string {
-	topLevelTopics := make([]string, 0)
+type topicPatternToken int16
 
-	for _, topic := range unique(topics) {
-		isSubtopic := false
+const (
+	TOKEN_WILDCARD       topicPatternToken = -1 // *, any characters but the separator
+	TOKEN_SUPER_WILDCARD topicPatternToken = -2 // **, any characters
+)
 
-		for _, topLevelTopic := range topLevelTopics {
-			if topic != topLevelTopic && IsSubtopicPattern(topLevelTopic, topic) {
-				isSubtopic = true
-				break
-			}
+// tokenizeTopicPattern splits the pattern into the characters
+// and wildcards, every run of two or more stars is the super wildcard.
+func tokenizeTopicPattern(pattern string) []topicPatternToken {
+	tokens := make([]topicPatternToken, 0, len(pattern))
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '*' {
+			tokens = append(tokens, topicPatternToken(pattern[i]))
+			continue
 		}
 
-		if !isSubtopic {
-			topLevelTopics = append(topLevelTopics, topic)
+		run := 1
+		for i+1 < len(pattern) && pattern[i+1] == '*' {
+			run++
+			i++
+		}
+
+		if run == 1 {
+			tokens = append(tokens, TOKEN_WILDCARD)
+		} else {
+			tokens = append(tokens, TOKEN_SUPER_WILDCARD)
 		}
 	}
 
-	for _, topic := range topLevelTopics {
-		for _, otherTopic := range topLevelTopics {
-			if topic != otherTopic && IsSubtopicPattern(topic, otherTopic) {
-				return DeduplicateOverlappingTopics(reverse(topLevelTopics))
+	return tokens
+}
+
+// patternCovers checks if the pattern a matches the pattern b treating
+// the wildcards of b as symbols, the wildcard of a absorbs the characters
+// and wildcards of b, but never the separator or the super wildcard.
+func patternCovers(a, b []topicPatternToken) bool {
+	// the characters of a before its first wildcard have to match
+	// the same characters of b, most unrelated patterns fail here
+	// without allocating the table
+	for i := 0; i < len(a) && a[i] >= 0; i++ {
+		if i >= len(b) || a[i] != b[i] {
+			return false
+		}
+	}
+
+	// covers[i][j] tells if a[i:] covers b[j:]
+	covers := make([][]bool, len(a)+1)
+	for i := range covers {
+		covers[i] = make([]bool, len(b)+1)
+	}
+
+	covers[len(a)][len(b)] = true
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b); j >= 0; j-- {
+			switch a[i] {
+			case TOKEN_SUPER_WILDCARD:
+				covers[i][j] = covers[i+1][j] || (j < len(b) && covers[i][j+1])
+			case TOKEN_WILDCARD:
+				absorbs := j < len(b) && b[j] != '/' && b[j] != TOKEN_SUPER_WILDCARD
+				covers[i][j] = covers[i+1][j] || (absorbs && covers[i][j+1])
+			default:
+				covers[i][j] = j < len(b) && b[j] == a[i] && covers[i+1][j+1]
 			}
 		}
 	}
 
+	return covers[0][0]
+}
+
+// DeduplicateOverlappingTopics returns only the topics which are not
+// covered by any other topic, in the order of their first occurrence.
+// From the topics covering each other the alphabetically first one is kept.
+func DeduplicateOverlappingTopics(topics []string) []string {
+	cover := newTopicCoverSet()
+	topLevel := make(map[string]struct{})
+
+	for _, topic := range topics {
+		if IsCorrectTopicPattern(topic) {
+			cover.Add(topic)
+		} else {
+			// incorrect patterns neither cover nor are covered
+			topLevel[topic] = struct{}{}
+		}
+	}
+
+	for _, topic := range cover.GetTopLevel() {
+		topLevel[topic] = struct{}{}
+	}
+
+	topLevelTopics := make([]string, 0, len(topLevel))
+	for _, topic := range topics {
+		if _, isTopLevel := topLevel[topic]; isTopLevel {
+			topLevelTopics = append(topLevelTopics, topic)
+			delete(topLevel, topic)
+		}
+	}
+
 	return topLevelTopics
 }
 
@@ -108,3 +194,208 @@ func GetDeduplicatedOverlappingTopicsDiff(oldTopics []string, newTopics []string
 
 	return
 }
+
+// PatternsOverlap checks if there is any topic
+// matched by both of the patterns.
+func PatternsOverlap(a string, b string) bool {
+	if !IsCorrectTopicPattern(a) || !IsCorrectTopicPattern(b) {
+		return false
+	}
+
+	return patternsOverlap(tokenizeTopicPattern(a), tokenizeTopicPattern(b))
+}
+
+// Maximum number of the patterns expressing the intersection,
+// with chained super wildcards it grows combinatorially.
+const MAX_TOPIC_PATTERN_INTERSECTIONS = 256
+
+var ErrPatternIntersectionTooLarge = errors.New("intersection of the topic patterns exceeds the maximum number of patterns")
+
+// IntersectPatterns returns the top level patterns matching exactly
+// the topics matched by both of the patterns. The intersection
+// of two wildcard patterns is not always expressible as a single
+// pattern, e.g. a/** and **/b intersect in a/b and a/**/b.
+// Empty list is returned when the patterns do not overlap, and
+// ErrPatternIntersectionTooLarge when the intersection needs more
+// than MAX_TOPIC_PATTERN_INTERSECTIONS patterns.
+func IntersectPatterns(a string, b string) ([]string, error) {
+	if !PatternsOverlap(a, b) {
+		return []string{}, nil
+	}
+
+	intersection := newPatternIntersection(tokenizeTopicPattern(a), tokenizeTopicPattern(b))
+	suffixes := intersection.suffixes(0, 0)
+	if intersection.tooLarge {
+		return nil, ErrPatternIntersectionTooLarge
+	}
+
+	patterns := make([]string, 0)
+	for _, tokens := range suffixes {
+		pattern := renderTopicPattern(tokens)
+		// patterns with empty segments match only incorrect topics
+		if IsCorrectTopicPattern(pattern) {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	patterns = DeduplicateOverlappingTopics(patterns)
+	sort.Strings(patterns)
+	return patterns, nil
+}
+
+func isWildcardToken(token topicPatternToken) bool {
+	return token == TOKEN_WILDCARD || token == TOKEN_SUPER_WILDCARD
+}
+
+// canConsume checks if the token matches the single character.
+func canConsume(token topicPatternToken, character topicPatternToken) bool {
+	switch token {
+	case TOKEN_SUPER_WILDCARD:
+		return true
+	case TOKEN_WILDCARD:
+		return character != '/'
+	default:
+		return token == character
+	}
+}
+
+// patternsOverlap walks both patterns at once, every step either skips
+// the wildcard matching nothing or consumes the character of one pattern
+// with the matching token of the other one.
+func patternsOverlap(a, b []topicPatternToken) bool {
+	// overlaps[i][j] tells if a[i:] and b[j:] overlap
+	overlaps := make([][]bool, len(a)+1)
+	for i := range overlaps {
+		overlaps[i] = make([]bool, len(b)+1)
+	}
+
+	for i := len(a); i >= 0; i-- {
+		for j := len(b); j >= 0; j-- {
+			if i == len(a) && j == len(b) {
+				overlaps[i][j] = true
+				continue
+			}
+
+			result := false
+
+			if i < len(a) && isWildcardToken(a[i]) {
+				result = result || overlaps[i+1][j]
+				if j < len(b) && !isWildcardToken(b[j]) && canConsume(a[i], b[j]) {
+					result = result || overlaps[i][j+1]
+				}
+			}
+
+			if j < len(b) && isWildcardToken(b[j]) {
+				result = result || overlaps[i][j+1]
+				if i < len(a) && !isWildcardToken(a[i]) && canConsume(b[j], a[i]) {
+					result = result || overlaps[i+1][j]
+				}
+			}
+
+			if i < len(a) && j < len(b) && !isWildcardToken(a[i]) && a[i] == b[j] {
+				result = result || overlaps[i+1][j+1]
+			}
+
+			overlaps[i][j] = result
+		}
+	}
+
+	return overlaps[0][0]
+}
+
+type patternIntersection struct {
+	a, b []topicPatternToken
+	memo map[[2]int][][]topicPatternToken
+
+	// set when any of the suffix lists was cut
+	// at MAX_TOPIC_PATTERN_INTERSECTIONS
+	tooLarge bool
+}
+
+func newPatternIntersection(a, b []topicPatternToken) *patternIntersection {
+	return &patternIntersection{a: a, b: b, memo: make(map[[2]int][][]topicPatternToken)}
+}
+
+// suffixes returns the patterns matching the intersection of a[i:] and b[j:].
+func (p *patternIntersection) suffixes(i, j int) [][]topicPatternToken {
+	key := [2]int{i, j}
+	if result, exists := p.memo[key]; exists {
+		return result
+	}
+
+	a, b := p.a, p.b
+	result := make([][]topicPatternToken, 0)
+
+	prefixed := func(token topicPatternToken, suffixes [][]topicPatternToken) {
+		for _, suffix := range suffixes {
+			result = append(result, append([]topicPatternToken{token}, suffix...))
+		}
+	}
+
+	switch {
+	case i == len(a) && j == len(b):
+		result = append(result, []topicPatternToken{})
+
+	case i < len(a) && j < len(b) && isWildcardToken(a[i]) && isWildcardToken(b[j]):
+		// both wildcards match the common part, then one of them ends
+		common := TOKEN_WILDCARD
+		if a[i] == TOKEN_SUPER_WILDCARD && b[j] == TOKEN_SUPER_WILDCARD {
+			common = TOKEN_SUPER_WILDCARD
+		}
+
+		prefixed(common, p.suffixes(i+1, j))
+		prefixed(common, p.suffixes(i, j+1))
+
+	case i < len(a) && isWildcardToken(a[i]):
+		result = append(result, p.suffixes(i+1, j)...)
+		if j < len(b) && canConsume(a[i], b[j]) {
+			prefixed(b[j], p.suffixes(i, j+1))
+		}
+
+	case j < len(b) && isWildcardToken(b[j]):
+		result = append(result, p.suffixes(i, j+1)...)
+		if i < len(a) && canConsume(b[j], a[i]) {
+			prefixed(a[i], p.suffixes(i+1, j))
+		}
+
+	case i < len(a) && j < len(b) && a[i] == b[j]:
+		prefixed(a[i], p.suffixes(i+1, j+1))
+	}
+
+	if len(result) > MAX_TOPIC_PATTERN_INTERSECTIONS {
+		p.tooLarge = true
+		result = result[:MAX_TOPIC_PATTERN_INTERSECTIONS]
+	}
+
+	p.memo[key] = result
+	return result
+}
+
+// renderTopicPattern joins the tokens into the pattern, the adjacent
+// wildcards are merged into the super wildcard if any of them is one.
+func renderTopicPattern(tokens []topicPatternToken) string {
+	pattern := make([]byte, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		if !isWildcardToken(tokens[i]) {
+			pattern = append(pattern, byte(tokens[i]))
+			continue
+		}
+
+		wildcard := tokens[i]
+		for i+1 < len(tokens) && isWildcardToken(tokens[i+1]) {
+			if tokens[i+1] == TOKEN_SUPER_WILDCARD {
+				wildcard = TOKEN_SUPER_WILDCARD
+			}
+
+			i++
+		}
+
+		if wildcard == TOKEN_SUPER_WILDCARD {
+			pattern = append(pattern, '*', '*')
+		} else {
+			pattern = append(pattern, '*')
+		}
+	}
+
+	return string(pattern)
+}
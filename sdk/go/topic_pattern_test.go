@@ -2,6 +2,9 @@ package directmq
 
 import (
 	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -78,6 +81,157 @@ var _ = Describe("Topic pattern util", func() {
 		)
 	})
 
+	Context("IsSubtopicPattern", func() {
+		DescribeTable(
+			"returns correct result for patterns",
+			func(topLevelPattern string, target string, expected bool) {
+				Expect(IsSubtopicPattern(topLevelPattern, target)).To(Equal(expected))
+			},
+
+			Entry("same pattern", "topic/*", "topic/*", true),
+			Entry("wildcard covering topic", "topic/*", "topic/level", true),
+			Entry("super wildcard covering wildcard", "topic/**", "topic/*/level", true),
+			Entry("wildcard not covering super wildcard", "*", "**", false),
+			Entry("wildcard not covering deeper levels", "topic/*", "topic/*/level", false),
+			Entry("partial wildcard covering partial wildcard", "topic/*", "topic/level*", true),
+			Entry("partial wildcard not covering wildcard", "topic/level*", "topic/*", false),
+		)
+
+		It("should never cover the pattern matching topics the top level pattern does not match", func() {
+			random := rand.New(rand.NewSource(GinkgoRandomSeed()))
+
+			topics := make([]string, 0)
+			for i := 0; i < 200; i++ {
+				topics = append(topics, randomTopicPattern(random, false))
+			}
+
+			for i := 0; i < 300; i++ {
+				topLevelPattern, target := randomTopicPattern(random, true), randomTopicPattern(random, true)
+				if !IsSubtopicPattern(topLevelPattern, target) {
+					continue
+				}
+
+				for _, topic := range topics {
+					if MatchTopicPattern(target, topic) {
+						Expect(MatchTopicPattern(topLevelPattern, topic)).To(BeTrue(), "%s covers %s, topic: %s", topLevelPattern, target, topic)
+					}
+				}
+			}
+		})
+	})
+
+	Context("PatternsOverlap and IntersectPatterns", func() {
+		DescribeTable(
+			"returns correct result for patterns",
+			func(a string, b string, expected []string) {
+				Expect(IntersectPatterns(a, b)).To(Equal(expected))
+				Expect(IntersectPatterns(b, a)).To(Equal(expected))
+				Expect(PatternsOverlap(a, b)).To(Equal(len(expected) > 0))
+			},
+
+			Entry("same topics", "topic", "topic", []string{"topic"}),
+			Entry("different topics", "topic", "other", []string{}),
+			Entry("wildcard and topic", "topic/*", "topic/level", []string{"topic/level"}),
+			Entry("wildcards on different levels", "a/*/c", "a/b/*", []string{"a/b/c"}),
+			Entry("different depth", "a/*", "a/*/c", []string{}),
+			Entry("super wildcards on both ends", "a/**", "**/b", []string{"a/**/b", "a/b"}),
+			Entry("wildcard and super wildcard", "*", "**", []string{"*"}),
+			Entry("partial wildcards", "a*", "*b", []string{"a*b"}),
+			Entry("incorrect pattern", "a//b", "**", []string{}),
+		)
+
+		It("should refuse the intersection of the chained super wildcards", func() {
+			a := strings.Repeat("**a", 20) + "**"
+			b := strings.Repeat("**b", 20) + "**"
+
+			Expect(PatternsOverlap(a, b)).To(BeTrue())
+
+			_, err := IntersectPatterns(a, b)
+			Expect(err).To(MatchError(ErrPatternIntersectionTooLarge))
+		})
+
+		It("should return the same result as brute force matching", func() {
+			random := rand.New(rand.NewSource(GinkgoRandomSeed()))
+
+			// reference matcher following the wildcards definition,
+			// * matches any characters but the separator, ** any characters
+			matchers := make(map[string]*regexp.Regexp)
+			match := func(pattern string, topic string) bool {
+				if _, exists := matchers[pattern]; !exists {
+					expression := regexp.QuoteMeta(pattern)
+					expression = strings.ReplaceAll(expression, `\*\*`, ".*")
+					expression = strings.ReplaceAll(expression, `\*`, "[^/]*")
+					matchers[pattern] = regexp.MustCompile("^" + expression + "$")
+				}
+
+				return matchers[pattern].MatchString(topic)
+			}
+
+			segments := []string{"a", "b", "ab"}
+			wildcards := []string{"*", "**", "a*", "*b"}
+
+			randomPattern := func() string {
+				parts := make([]string, 1+random.Intn(3))
+				for i := range parts {
+					if random.Intn(2) == 0 {
+						parts[i] = wildcards[random.Intn(len(wildcards))]
+					} else {
+						parts[i] = segments[random.Intn(len(segments))]
+					}
+				}
+
+				return strings.Join(parts, "/")
+			}
+
+			// every overlap of the generated patterns
+			// has a witness of at most five segments
+			topics := []string{}
+			level := []string{""}
+			for depth := 0; depth < 5; depth++ {
+				next := []string{}
+				for _, prefix := range level {
+					for _, segment := range segments {
+						next = append(next, strings.TrimPrefix(prefix+"/"+segment, "/"))
+					}
+				}
+
+				topics = append(topics, next...)
+				level = next
+			}
+
+			for i := 0; i < 300; i++ {
+				a, b := randomPattern(), randomPattern()
+				intersection, err := IntersectPatterns(a, b)
+				Expect(err).ToNot(HaveOccurred())
+
+				overlap := false
+				for _, topic := range topics {
+					matchesBoth := match(a, topic) && match(b, topic)
+					overlap = overlap || matchesBoth
+
+					matchesIntersection := false
+					for _, pattern := range intersection {
+						matchesIntersection = matchesIntersection || match(pattern, topic)
+					}
+
+					Expect(matchesIntersection).To(Equal(matchesBoth), "%s and %s intersect in %v, topic: %s", a, b, intersection, topic)
+				}
+
+				Expect(PatternsOverlap(a, b)).To(Equal(overlap), "%s and %s", a, b)
+			}
+		})
+	})
+
+	Context("DeduplicateOverlappingTopics", func() {
+		It("should keep only top level topics in the order of occurrence", func() {
+			Expect(DeduplicateOverlappingTopics([]string{"b/c", "a/*", "a/b", "b/*", "a/*"})).To(Equal([]string{"a/*", "b/*"}))
+		})
+
+		It("should keep one of the equivalent topics", func() {
+			Expect(DeduplicateOverlappingTopics([]string{"a/**/**", "a/**"})).To(Equal([]string{"a/**"}))
+		})
+	})
+
 	Context("GetDeduplicatedOverlappingTopicsDiff", func() {
 		It("should return the correct removed and added topics", func() {
 			oldTopics := []string{"topic1", "topic2", "topic3"}
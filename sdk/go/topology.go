@@ -0,0 +1,319 @@
+package directmq
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DEFAULT_TOPOLOGY_DISCOVERY_QUIET_PERIOD is the time DiscoverTopology
+// waits for the next report before it gives up on the missing nodes.
+const DEFAULT_TOPOLOGY_DISCOVERY_QUIET_PERIOD = time.Second
+
+type TopologyNode struct {
+	NodeID         string   `json:"node_id"`
+	BridgedNodeIDs []string `json:"bridged_node_ids"`
+	Hops           int      `json:"hops"`
+}
+
+type TopologyLink struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// TopologyGraph contains the nodes which answered the topology probe,
+// nodes known only from the bridged node IDs of other nodes
+// are not present in the Nodes list.
+type TopologyGraph struct {
+	RootNodeID string         `json:"root_node_id"`
+	Nodes      []TopologyNode `json:"nodes"`
+}
+
+func (g TopologyGraph) GetNode(nodeID string) (node TopologyNode, found bool) {
+	for _, node := range g.Nodes {
+		if node.NodeID == nodeID {
+			return node, true
+		}
+	}
+
+	return TopologyNode{}, false
+}
+
+// GetLinks returns every connection between two nodes once,
+// sorted by the node IDs.
+func (g TopologyGraph) GetLinks() []TopologyLink {
+	unique := make(map[TopologyLink]struct{})
+	for _, node := range g.Nodes {
+		for _, bridgedNodeID := range node.BridgedNodeIDs {
+			link := TopologyLink{From: node.NodeID, To: bridgedNodeID}
+			if link.To < link.From {
+				link.From, link.To = link.To, link.From
+			}
+
+			unique[link] = struct{}{}
+		}
+	}
+
+	links := make([]TopologyLink, 0, len(unique))
+	for link := range unique {
+		links = append(links, link)
+	}
+
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].From != links[j].From {
+			return links[i].From < links[j].From
+		}
+
+		return links[i].To < links[j].To
+	})
+
+	return links
+}
+
+// GetUnreachableNodeIDs returns IDs of the nodes which are bridged
+// to the discovered nodes, but did not answer the probe.
+func (g TopologyGraph) GetUnreachableNodeIDs() []string {
+	answered := make(map[string]struct{}, len(g.Nodes))
+	for _, node := range g.Nodes {
+		answered[node.NodeID] = struct{}{}
+	}
+
+	unreachable := make([]string, 0)
+	for _, node := range g.Nodes {
+		for _, bridgedNodeID := range node.BridgedNodeIDs {
+			if _, exists := answered[bridgedNodeID]; !exists {
+				unreachable = append(unreachable, bridgedNodeID)
+			}
+		}
+	}
+
+	unreachable = unique(unreachable)
+	sort.Strings(unreachable)
+	return unreachable
+}
+
+/* globalNetwork topology discovery */
+
+func (d *globalNetwork) TopologyProbed(probe TopologyProbeMessage) {
+	originID := d.config.HostID
+	if len(probe.Traversed) > 0 {
+		originID = probe.Traversed[0]
+	}
+
+	if !d.probes.MarkSeen(originID, probe.ProbeID) {
+		return
+	}
+
+	if len(probe.Traversed) > 0 {
+		d.TopologyReported(TopologyReportMessage{
+			DataFrame: DataFrame{
+				TTL:       int32(d.config.HostTTL),
+				Traversed: []string{},
+			},
+			ProbeID:        probe.ProbeID,
+			NodeID:         d.config.HostID,
+			BridgedNodeIDs: d.getBridgedNodeIDs(),
+			Route:          append([]string{}, probe.Traversed...),
+		})
+	}
+
+	for _, participant := range d.participants {
+		participant.HandleTopologyProbe(probe)
+	}
+}
+
+func (d *globalNetwork) TopologyReported(report TopologyReportMessage) {
+	if len(report.Route) == 0 {
+		d.discoveries.Deliver(report)
+		return
+	}
+
+	for _, participant := range d.participants {
+		if participant.HandleTopologyReport(report) {
+			return
+		}
+	}
+
+	d.logger.Debug("topology report dropped", slog.String("reporting_node_id", report.NodeID), slog.Any("route", report.Route))
+}
+
+func (d *globalNetwork) getBridgedNodeIDs() []string {
+	ids := make([]string, 0)
+	for _, participant := range d.participants {
+		if edge, isEdge := participant.(*networkEdge); isEdge && edge.GetStateName() == stateConnected {
+			ids = append(ids, edge.info.BridgedNodeID)
+		}
+	}
+
+	return ids
+}
+
+/* networkNode topology discovery */
+
+// DiscoverTopology floods the topology probe through the network
+// and collects the reports. It returns when every discovered node
+// answered, when no report arrived for the quiet period or when
+// the context is done, whichever comes first.
+// Only nodes with FEATURE_TOPOLOGY_DISCOVERY negotiated
+// on the whole path and within the TTL answer the probe, so the
+// returned graph may be incomplete, see GetUnreachableNodeIDs.
+func (n *networkNode) DiscoverTopology(ctx context.Context) TopologyGraph {
+	probeID := newProbeID()
+
+	discovery := n.network.discoveries.Start(probeID)
+	defer n.network.discoveries.Finish(probeID)
+
+	// the node is locked only to send the probe, the reports
+	// are collected while the edges keep handling the frames
+	n.network.lock()
+	discovery.Add(TopologyNode{
+		NodeID:         n.network.config.HostID,
+		BridgedNodeIDs: n.network.getBridgedNodeIDs(),
+		Hops:           0,
+	})
+
+	n.network.TopologyProbed(TopologyProbeMessage{
+		DataFrame: DataFrame{
+			TTL:       int32(n.network.config.HostTTL),
+			Traversed: []string{},
+		},
+		ProbeID: probeID,
+	})
+	n.network.unlock()
+
+	quiet := time.NewTimer(DEFAULT_TOPOLOGY_DISCOVERY_QUIET_PERIOD)
+	defer quiet.Stop()
+
+	for !discovery.IsComplete() {
+		select {
+		case <-ctx.Done():
+			return discovery.GetGraph(n.network.config.HostID)
+		case <-quiet.C:
+			// the missing nodes do not answer the probe or the reports were lost
+			return discovery.GetGraph(n.network.config.HostID)
+		case <-discovery.updated:
+			if !quiet.Stop() {
+				<-quiet.C
+			}
+
+			quiet.Reset(DEFAULT_TOPOLOGY_DISCOVERY_QUIET_PERIOD)
+		}
+	}
+
+	return discovery.GetGraph(n.network.config.HostID)
+}
+
+func newProbeID() uint64 {
+	var id [8]byte
+	rand.Read(id[:])
+	return binary.BigEndian.Uint64(id[:])
+}
+
+/* topology discovery state */
+
+type topologyDiscovery struct {
+	mutex   sync.Mutex
+	nodes   map[string]TopologyNode
+	updated chan struct{}
+}
+
+func (t *topologyDiscovery) Add(node TopologyNode) {
+	t.mutex.Lock()
+	t.nodes[node.NodeID] = node
+	t.mutex.Unlock()
+
+	select {
+	case t.updated <- struct{}{}:
+	default:
+	}
+}
+
+func (t *topologyDiscovery) IsComplete() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, node := range t.nodes {
+		for _, bridgedNodeID := range node.BridgedNodeIDs {
+			if _, answered := t.nodes[bridgedNodeID]; !answered {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (t *topologyDiscovery) GetGraph(rootNodeID string) TopologyGraph {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	nodes := make([]TopologyNode, 0, len(t.nodes))
+	for _, node := range t.nodes {
+		nodes = append(nodes, node)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Hops != nodes[j].Hops {
+			return nodes[i].Hops < nodes[j].Hops
+		}
+
+		return nodes[i].NodeID < nodes[j].NodeID
+	})
+
+	return TopologyGraph{
+		RootNodeID: rootNodeID,
+		Nodes:      nodes,
+	}
+}
+
+type topologyDiscoveries struct {
+	mutex   sync.Mutex
+	pending map[uint64]*topologyDiscovery
+}
+
+func newTopologyDiscoveries() *topologyDiscoveries {
+	return &topologyDiscoveries{
+		pending: make(map[uint64]*topologyDiscovery),
+	}
+}
+
+func (t *topologyDiscoveries) Start(probeID uint64) *topologyDiscovery {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	discovery := &topologyDiscovery{
+		nodes:   make(map[string]TopologyNode),
+		updated: make(chan struct{}, 1),
+	}
+
+	t.pending[probeID] = discovery
+	return discovery
+}
+
+func (t *topologyDiscoveries) Finish(probeID uint64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.pending, probeID)
+}
+
+func (t *topologyDiscoveries) Deliver(report TopologyReportMessage) {
+	t.mutex.Lock()
+	discovery, exists := t.pending[report.ProbeID]
+	t.mutex.Unlock()
+
+	if !exists {
+		// report arrived after the discovery has finished
+		return
+	}
+
+	discovery.Add(TopologyNode{
+		NodeID:         report.NodeID,
+		BridgedNodeIDs: report.BridgedNodeIDs,
+		Hops:           len(report.Traversed),
+	})
+}
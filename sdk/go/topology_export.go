@@ -0,0 +1,72 @@
+package directmq
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// WriteTopologyDOT writes the graph in the Graphviz DOT format,
+// the root node is drawn in bold and the nodes which did not
+// answer the probe are drawn dashed.
+func WriteTopologyDOT(w io.Writer, graph TopologyGraph) error {
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintln(out, "graph directmq {")
+
+	for _, node := range graph.Nodes {
+		label := node.NodeID + "\nhops: " + strconv.Itoa(node.Hops)
+		style := ""
+		if node.NodeID == graph.RootNodeID {
+			style = ", style=bold"
+		}
+
+		fmt.Fprintf(out, "\t%s [label=%s%s];\n", quoteDOT(node.NodeID), quoteDOT(label), style)
+	}
+
+	for _, nodeID := range graph.GetUnreachableNodeIDs() {
+		fmt.Fprintf(out, "\t%s [style=dashed];\n", quoteDOT(nodeID))
+	}
+
+	for _, link := range graph.GetLinks() {
+		fmt.Fprintf(out, "\t%s -- %s;\n", quoteDOT(link.From), quoteDOT(link.To))
+	}
+
+	fmt.Fprintln(out, "}")
+
+	return out.Flush()
+}
+
+// WriteTopologyJSON writes the graph together with its links as JSON.
+func WriteTopologyJSON(w io.Writer, graph TopologyGraph) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(struct {
+		TopologyGraph
+		Links       []TopologyLink `json:"links"`
+		Unreachable []string       `json:"unreachable_node_ids"`
+	}{
+		TopologyGraph: graph,
+		Links:         graph.GetLinks(),
+		Unreachable:   graph.GetUnreachableNodeIDs(),
+	})
+}
+
+func quoteDOT(value string) string {
+	quoted := []byte{'"'}
+	for _, char := range []byte(value) {
+		switch char {
+		case '"', '\\':
+			quoted = append(quoted, '\\', char)
+		case '\n':
+			quoted = append(quoted, '\\', 'n')
+		default:
+			quoted = append(quoted, char)
+		}
+	}
+
+	return string(append(quoted, '"'))
+}
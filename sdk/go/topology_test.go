@@ -0,0 +1,134 @@
+package directmq
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("topology discovery", func() {
+	newNode := func(id string) *networkNode {
+		return newNetworkNode(NetworkNodeConfig{
+			HostID:       id,
+			HostTTL:      DEFAULT_TTL,
+			HostFeatures: FEATURE_TOPOLOGY_DISCOVERY,
+		}, NewProtobufBinaryProtocol())
+	}
+
+	Context("when nodes are connected in a chain", func() {
+		var a, b, c *networkNode
+
+		BeforeEach(func() {
+			a, b, c = newNode("a"), newNode("b"), newNode("c")
+
+			connectTestNodes(a, b)
+			Eventually(b.GetBridgedNodeIDs).Should(HaveLen(1))
+			connectTestNodes(c, b)
+			Eventually(b.GetBridgedNodeIDs).Should(HaveLen(2))
+			Eventually(c.GetBridgedNodeIDs).Should(HaveLen(1))
+			Eventually(a.GetBridgedNodeIDs).Should(HaveLen(1))
+		})
+
+		AfterEach(func() {
+			a.CloseNode("test finished")
+			c.CloseNode("test finished")
+		})
+
+		It("should discover all nodes", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			graph := a.DiscoverTopology(ctx)
+
+			Expect(ctx.Err()).ToNot(HaveOccurred(), "discovery should finish before the timeout")
+			Expect(graph.RootNodeID).To(Equal("a"))
+			Expect(graph.Nodes).To(HaveLen(3))
+
+			node, found := graph.GetNode("c")
+			Expect(found).To(BeTrue())
+			Expect(node.Hops).To(Equal(2))
+			Expect(node.BridgedNodeIDs).To(ConsistOf("b"))
+
+			Expect(graph.GetLinks()).To(Equal([]TopologyLink{
+				{From: "a", To: "b"},
+				{From: "b", To: "c"},
+			}))
+		})
+
+		It("should discover only direct neighbors when TTL is limited", func() {
+			// TTL is decremented before the probe leaves the node
+			a.network.config.HostTTL = 2
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			graph := a.DiscoverTopology(ctx)
+
+			Expect(graph.Nodes).To(HaveLen(2))
+			Expect(graph.GetUnreachableNodeIDs()).To(Equal([]string{"c"}))
+		})
+	})
+
+	Context("when the bridged node does not support the discovery", func() {
+		It("should return the incomplete graph without the deadline", func() {
+			a := newNode("a")
+			b := newNetworkNode(NetworkNodeConfig{HostID: "b", HostTTL: DEFAULT_TTL}, NewProtobufBinaryProtocol())
+			defer a.CloseNode("test finished")
+
+			connectTestNodes(a, b)
+			Eventually(a.GetBridgedNodeIDs).Should(HaveLen(1))
+
+			graph := make(chan TopologyGraph, 1)
+			go func() {
+				graph <- a.DiscoverTopology(context.Background())
+			}()
+
+			Eventually(graph, 2*DEFAULT_TOPOLOGY_DISCOVERY_QUIET_PERIOD).Should(Receive(WithTransform(
+				func(graph TopologyGraph) []string { return graph.GetUnreachableNodeIDs() },
+				Equal([]string{"b"}),
+			)))
+		})
+	})
+
+	Describe("exporters", func() {
+		graph := TopologyGraph{
+			RootNodeID: "a",
+			Nodes: []TopologyNode{
+				{NodeID: "a", BridgedNodeIDs: []string{"b"}, Hops: 0},
+				{NodeID: "b", BridgedNodeIDs: []string{"a", "c\"x"}, Hops: 1},
+			},
+		}
+
+		It("should export the graph in DOT format", func() {
+			out := &strings.Builder{}
+			Expect(WriteTopologyDOT(out, graph)).To(Succeed())
+
+			Expect(out.String()).To(Equal(strings.Join([]string{
+				"graph directmq {",
+				`	"a" [label="a\nhops: 0", style=bold];`,
+				`	"b" [label="b\nhops: 1"];`,
+				`	"c\"x" [style=dashed];`,
+				`	"a" -- "b";`,
+				`	"b" -- "c\"x";`,
+				"}",
+				"",
+			}, "\n")))
+		})
+
+		It("should export the graph in JSON format", func() {
+			out := &strings.Builder{}
+			Expect(WriteTopologyJSON(out, graph)).To(Succeed())
+
+			decoded := make(map[string]any)
+			Expect(json.Unmarshal([]byte(out.String()), &decoded)).To(Succeed())
+			Expect(decoded).To(HaveKeyWithValue("root_node_id", "a"))
+			Expect(decoded["nodes"]).To(HaveLen(2))
+			Expect(decoded["links"]).To(HaveLen(2))
+			Expect(decoded["unreachable_node_ids"]).To(ConsistOf("c\"x"))
+		})
+	})
+})
@@ -0,0 +1,226 @@
+package directmq
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+type SpanName string
+
+const (
+	SPAN_PUBLISH SpanName = "publish"
+	SPAN_RECEIVE SpanName = "receive"
+	SPAN_FORWARD SpanName = "forward"
+)
+
+type SpanOutcome string
+
+const (
+	SPAN_OUTCOME_DELIVERED SpanOutcome = "delivered"
+	SPAN_OUTCOME_FORWARDED SpanOutcome = "forwarded"
+	SPAN_OUTCOME_DROPPED   SpanOutcome = "dropped"
+)
+
+const (
+	TRACE_PARENT_VERSION      = "00"
+	TRACE_FLAG_SAMPLED   byte = 0x01
+)
+
+var ErrInvalidTraceParent = errors.New("invalid traceparent")
+
+type TraceID [16]byte
+type SpanID [8]byte
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+func (t TraceID) IsValid() bool  { return t != TraceID{} }
+func (s SpanID) String() string  { return hex.EncodeToString(s[:]) }
+func (s SpanID) IsValid() bool   { return s != SpanID{} }
+
+// TraceContext is the W3C trace context carried in the DataFrame
+// as the traceparent header value.
+type TraceContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Flags   byte
+}
+
+func (c TraceContext) IsSampled() bool {
+	return c.Flags&TRACE_FLAG_SAMPLED != 0
+}
+
+func (c TraceContext) String() string {
+	return TRACE_PARENT_VERSION + "-" + c.TraceID.String() + "-" + c.SpanID.String() + "-" + hex.EncodeToString([]byte{c.Flags})
+}
+
+// ParseTraceParent parses the traceparent header value,
+// values of the future versions are parsed as version 00.
+func ParseTraceParent(traceParent string) (TraceContext, error) {
+	parts := strings.Split(traceParent, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+
+	if parts[0] == TRACE_PARENT_VERSION && len(parts) != 4 {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+
+	var context TraceContext
+	var flags [1]byte
+
+	if !decodeTraceField(parts[1], context.TraceID[:]) || !decodeTraceField(parts[2], context.SpanID[:]) || !decodeTraceField(parts[3], flags[:]) {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+
+	if !context.TraceID.IsValid() || !context.SpanID.IsValid() {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+
+	context.Flags = flags[0]
+	return context, nil
+}
+
+func decodeTraceField(field string, out []byte) bool {
+	if len(field) != hex.EncodedLen(len(out)) || strings.ToLower(field) != field {
+		return false
+	}
+
+	_, err := hex.Decode(out, []byte(field))
+	return err == nil
+}
+
+// Span describes a single step of the publication passing through the node.
+type Span struct {
+	TraceID      string      `json:"trace_id"`
+	SpanID       string      `json:"span_id"`
+	ParentSpanID string      `json:"parent_span_id,omitempty"`
+	Name         SpanName    `json:"name"`
+	NodeID       string      `json:"node_id"`
+	Edge         string      `json:"edge,omitempty"` // bridged node ID
+	Topic        string      `json:"topic"`
+	TTL          int32       `json:"ttl"`
+	Outcome      SpanOutcome `json:"outcome"`
+	DropReason   DropReason  `json:"drop_reason,omitempty"`
+	StartTime    time.Time   `json:"start_time"`
+	EndTime      time.Time   `json:"end_time"`
+}
+
+type SpanExporter interface {
+	ExportSpan(span Span) error
+}
+
+// tracer creates spans for the publications passing through the node,
+// it is disabled when no span exporter is configured.
+type tracer struct {
+	nodeID   string
+	exporter SpanExporter
+}
+
+func newTracer(config NetworkNodeConfig) *tracer {
+	return &tracer{
+		nodeID:   config.HostID,
+		exporter: config.SpanExporter,
+	}
+}
+
+// StartSpan creates the child span of the publication trace context,
+// or the new trace when the publication is not traced yet.
+// Returns nil when tracing is disabled.
+func (t *tracer) StartSpan(name SpanName, bridgedNodeID string, publication PublishMessage) *activeSpan {
+	if t.exporter == nil {
+		return nil
+	}
+
+	parent, err := ParseTraceParent(publication.TraceParent)
+	if err != nil {
+		parent = TraceContext{TraceID: newTraceID(), Flags: TRACE_FLAG_SAMPLED}
+	}
+
+	context := TraceContext{
+		TraceID: parent.TraceID,
+		SpanID:  newSpanID(),
+		Flags:   parent.Flags,
+	}
+
+	span := Span{
+		TraceID:   context.TraceID.String(),
+		SpanID:    context.SpanID.String(),
+		Name:      name,
+		NodeID:    t.nodeID,
+		Edge:      bridgedNodeID,
+		Topic:     publication.Topic,
+		TTL:       publication.TTL,
+		StartTime: time.Now(),
+	}
+
+	if parent.SpanID.IsValid() {
+		span.ParentSpanID = parent.SpanID.String()
+	}
+
+	return &activeSpan{
+		exporter: t.exporter,
+		context:  context,
+		span:     span,
+	}
+}
+
+type activeSpan struct {
+	exporter SpanExporter
+	context  TraceContext
+	span     Span
+}
+
+// Propagate returns the frame carrying the span as the parent
+// of the spans created by the next nodes.
+func (s *activeSpan) Propagate(frame DataFrame) DataFrame {
+	if s == nil {
+		return frame
+	}
+
+	frame.TraceParent = s.context.String()
+	return frame
+}
+
+func (s *activeSpan) End(outcome SpanOutcome, reason DropReason) {
+	if s == nil || !s.context.IsSampled() {
+		return
+	}
+
+	s.span.Outcome = outcome
+	s.span.DropReason = reason
+	s.span.EndTime = time.Now()
+
+	// tracing must never affect the publication delivery
+	_ = s.exporter.ExportSpan(s.span)
+}
+
+func (s *activeSpan) EndPublication(result publicationResult) {
+	switch {
+	case result.delivered:
+		s.End(SPAN_OUTCOME_DELIVERED, "")
+	case result.forwarded:
+		s.End(SPAN_OUTCOME_FORWARDED, "")
+	default:
+		s.End(SPAN_OUTCOME_DROPPED, result.dropReason)
+	}
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+
+	return id
+}
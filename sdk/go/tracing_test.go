@@ -0,0 +1,138 @@
+package directmq
+
+import (
+	"encoding/json"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("tracing", func() {
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	Describe("ParseTraceParent", func() {
+		It("should parse and format the traceparent value", func() {
+			context, err := ParseTraceParent(traceParent)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(context.TraceID.String()).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+			Expect(context.SpanID.String()).To(Equal("00f067aa0ba902b7"))
+			Expect(context.IsSampled()).To(BeTrue())
+			Expect(context.String()).To(Equal(traceParent))
+		})
+
+		It("should accept values of the future versions", func() {
+			context, err := ParseTraceParent("cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(context.IsSampled()).To(BeFalse())
+		})
+
+		DescribeTable("should reject invalid values",
+			func(value string) {
+				_, err := ParseTraceParent(value)
+				Expect(err).To(MatchError(ErrInvalidTraceParent))
+			},
+			Entry("empty", ""),
+			Entry("forbidden version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"),
+			Entry("zero trace ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01"),
+			Entry("zero span ID", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"),
+			Entry("uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"),
+			Entry("short trace ID", "00-4bf92f3577b34da6-00f067aa0ba902b7-01"),
+			Entry("extra fields in version 00", traceParent+"-extra"),
+		)
+	})
+
+	Describe("tracer", func() {
+		publication := PublishMessage{
+			DataFrame: DataFrame{TTL: 4, Traversed: []string{"a"}, TraceParent: traceParent},
+			Topic:     "topic",
+		}
+
+		It("should be disabled without exporter", func() {
+			span := newTracer(NetworkNodeConfig{HostID: "host"}).StartSpan(SPAN_RECEIVE, "a", publication)
+			Expect(span).To(BeNil())
+			Expect(span.Propagate(publication.DataFrame)).To(Equal(publication.DataFrame))
+			span.End(SPAN_OUTCOME_DELIVERED, "")
+		})
+
+		It("should create the child span of the publication trace", func() {
+			exporter := NewInMemorySpanExporter()
+			span := newTracer(NetworkNodeConfig{HostID: "host", SpanExporter: exporter}).StartSpan(SPAN_RECEIVE, "a", publication)
+
+			frame := span.Propagate(publication.DataFrame)
+			span.End(SPAN_OUTCOME_DROPPED, DROP_REASON_RATE_LIMIT)
+
+			child, err := ParseTraceParent(frame.TraceParent)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(child.TraceID.String()).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+
+			spans := exporter.GetSpans()
+			Expect(spans).To(HaveLen(1))
+			Expect(spans[0].TraceID).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+			Expect(spans[0].SpanID).To(Equal(child.SpanID.String()))
+			Expect(spans[0].ParentSpanID).To(Equal("00f067aa0ba902b7"))
+			Expect(spans[0].NodeID).To(Equal("host"))
+			Expect(spans[0].Edge).To(Equal("a"))
+			Expect(spans[0].Topic).To(Equal("topic"))
+			Expect(spans[0].TTL).To(Equal(int32(4)))
+			Expect(spans[0].Outcome).To(Equal(SPAN_OUTCOME_DROPPED))
+			Expect(spans[0].DropReason).To(Equal(DROP_REASON_RATE_LIMIT))
+		})
+
+		It("should not export spans of the not sampled trace", func() {
+			exporter := NewInMemorySpanExporter()
+			notSampled := publication
+			notSampled.TraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"
+
+			newTracer(NetworkNodeConfig{SpanExporter: exporter}).StartSpan(SPAN_FORWARD, "a", notSampled).End(SPAN_OUTCOME_FORWARDED, "")
+			Expect(exporter.GetSpans()).To(BeEmpty())
+		})
+	})
+
+	Context("when publishing from the native API", func() {
+		It("should start a new trace", func() {
+			exporter := NewInMemorySpanExporter()
+			node := newNetworkNode(NetworkNodeConfig{HostID: "host", HostTTL: DEFAULT_TTL, SpanExporter: exporter}, NewProtobufJSONProtocol())
+
+			var published PublishMessage
+			node.OnPublication(func(message PublishMessage) { published = message })
+			node.Subscribe("topic", func([]byte) {})
+			node.Publish("topic", []byte("payload"), AT_LEAST_ONCE)
+
+			spans := exporter.GetSpans()
+			Expect(spans).To(HaveLen(1))
+			Expect(spans[0].Name).To(Equal(SPAN_PUBLISH))
+			Expect(spans[0].ParentSpanID).To(BeEmpty())
+			Expect(spans[0].Outcome).To(Equal(SPAN_OUTCOME_DELIVERED))
+			Expect(published.TraceParent).To(Equal("00-" + spans[0].TraceID + "-" + spans[0].SpanID + "-01"))
+		})
+
+		It("should report publication without subscribers as dropped", func() {
+			exporter := NewInMemorySpanExporter()
+			node := newNetworkNode(NetworkNodeConfig{HostID: "host", HostTTL: DEFAULT_TTL, SpanExporter: exporter}, NewProtobufJSONProtocol())
+
+			node.Publish("topic", []byte("payload"), AT_LEAST_ONCE)
+
+			Expect(exporter.GetSpans()[0].Outcome).To(Equal(SPAN_OUTCOME_DROPPED))
+			Expect(exporter.GetSpans()[0].DropReason).To(Equal(DROP_REASON_NO_SUBSCRIBERS))
+		})
+	})
+
+	Describe("JSONLinesSpanExporter", func() {
+		It("should write every span as a single line", func() {
+			out := &strings.Builder{}
+			exporter := NewJSONLinesSpanExporter(out)
+
+			Expect(exporter.ExportSpan(Span{SpanID: "1", Outcome: SPAN_OUTCOME_FORWARDED})).To(Succeed())
+			Expect(exporter.ExportSpan(Span{SpanID: "2", Outcome: SPAN_OUTCOME_DROPPED, DropReason: DROP_REASON_TTL})).To(Succeed())
+
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			Expect(lines).To(HaveLen(2))
+
+			var span Span
+			Expect(json.Unmarshal([]byte(lines[1]), &span)).To(Succeed())
+			Expect(span.SpanID).To(Equal("2"))
+			Expect(span.DropReason).To(Equal(DROP_REASON_TTL))
+		})
+	})
+})
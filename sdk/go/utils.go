@@ -35,11 +35,3 @@ func unique[T comparable](items []T) []T {
 
 	return uniqueItems
 }
-
-func reverse[T any](s []T) []T {
-	reversed := make([]T, len(s))
-	for i, v := range s {
-		reversed[len(s)-1-i] = v
-	}
-	return reversed
-}
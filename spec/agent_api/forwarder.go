@@ -19,7 +19,7 @@ type ForwardedMessage struct {
 	Message []byte
 }
 type Forwarder interface {
-	StartWebsocketForwarder() error
+	Start() error
 	OnMessage(handler func(message ForwardedMessage))
 	Abort()
 }
@@ -61,7 +61,7 @@ func NewWebsocketForwarder(from *url.URL, fromAlias string, to *url.URL, toAlias
 	return forwarder
 }
 
-func (f *websocketForwarder) StartWebsocketForwarder() error {
+func (f *websocketForwarder) Start() error {
 	err := f.server.ListenAndServe()
 
 	if err != http.ErrServerClosed {
@@ -30,3 +30,20 @@ func retry[TResult interface{}](maxRetries int, retryInterval time.Duration, fun
 
 	panic(fmt.Sprintf("failed to execute function after %s retries: %v"+strconv.Itoa(maxRetries), panics))
 }
+
+// retryWithError calls the function until it succeeds,
+// it returns the last error after the retries are exhausted.
+func retryWithError[TResult interface{}](maxRetries int, retryInterval time.Duration, funcToExec func() (TResult, error)) (result TResult, err error) {
+	for i := 0; i < maxRetries; i++ {
+		if i > 0 {
+			time.Sleep(retryInterval)
+		}
+
+		result, err = funcToExec()
+		if err == nil {
+			return result, nil
+		}
+	}
+
+	return result, err
+}
@@ -0,0 +1,163 @@
+package dmqspecagent
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	dmqportals "github.com/sync-toys/DirectMQ/sdk/go/portals"
+)
+
+type tcpForwarder struct {
+	fromURL   *url.URL
+	fromAlias string
+
+	toURL   *url.URL
+	toAlias string
+
+	listener       net.Listener
+	incomingPortal *dmqportals.TCPPortal
+	outgoingPortal *dmqportals.TCPPortal
+
+	messageHandler func(message ForwardedMessage)
+
+	aborted    bool
+	abortMutex sync.Mutex
+}
+
+var _ Forwarder = (*tcpForwarder)(nil)
+
+func NewTCPForwarder(from *url.URL, fromAlias string, to *url.URL, toAlias string) *tcpForwarder {
+	return &tcpForwarder{
+		fromURL:   from,
+		fromAlias: fromAlias,
+
+		toURL:   to,
+		toAlias: toAlias,
+
+		abortMutex: sync.Mutex{},
+	}
+}
+
+func (f *tcpForwarder) Start() error {
+	listener, err := net.Listen("tcp", f.fromURL.Host)
+	if err != nil {
+		return err
+	}
+
+	f.abortMutex.Lock()
+	f.listener = listener
+	aborted := f.aborted
+	f.abortMutex.Unlock()
+
+	if aborted {
+		listener.Close()
+		return nil
+	}
+
+	conn, err := listener.Accept()
+	if err != nil {
+		if f.isAborted() {
+			return nil
+		}
+
+		return err
+	}
+
+	incoming := dmqportals.NewTCPPortal(conn)
+
+	outgoing, err := retryWithError(5, 100*time.Millisecond, func() (*dmqportals.TCPPortal, error) {
+		return dmqportals.TCPConnect(f.toURL.Host)
+	})
+
+	if err != nil {
+		incoming.Close()
+		f.Abort()
+		return errors.New("unable to dial to the target tcp listener: " + err.Error())
+	}
+
+	f.abortMutex.Lock()
+	f.incomingPortal, f.outgoingPortal = incoming, outgoing
+	f.abortMutex.Unlock()
+
+	done := make(chan struct{}, 2)
+
+	go f.runForwardingRoutine(incoming, outgoing, f.fromURL, f.fromAlias, f.toURL, f.toAlias, done)
+	go f.runForwardingRoutine(outgoing, incoming, f.toURL, f.toAlias, f.fromURL, f.fromAlias, done)
+
+	<-done
+	<-done
+
+	f.Abort()
+	return nil
+}
+
+func (f *tcpForwarder) OnMessage(handler func(message ForwardedMessage)) {
+	f.messageHandler = handler
+}
+
+func (f *tcpForwarder) Abort() {
+	f.abortMutex.Lock()
+	defer f.abortMutex.Unlock()
+
+	f.aborted = true
+
+	if f.listener != nil {
+		f.listener.Close()
+	}
+
+	if f.incomingPortal != nil {
+		f.incomingPortal.Close()
+		f.incomingPortal = nil
+	}
+
+	if f.outgoingPortal != nil {
+		f.outgoingPortal.Close()
+		f.outgoingPortal = nil
+	}
+}
+
+func (f *tcpForwarder) isAborted() bool {
+	f.abortMutex.Lock()
+	defer f.abortMutex.Unlock()
+
+	return f.aborted
+}
+
+func (f *tcpForwarder) runForwardingRoutine(
+	from, to *dmqportals.TCPPortal,
+	fromURL *url.URL, fromAlias string,
+	toURL *url.URL, toAlias string,
+	done chan struct{},
+) {
+	defer func() { done <- struct{}{} }()
+
+	for {
+		data, err := from.ReadPacket()
+		if err != nil {
+			// closing the other side stops the opposite routine
+			to.Close()
+			return
+		}
+
+		message := ForwardedMessage{
+			FromURL:   fromURL,
+			FromAlias: fromAlias,
+
+			ToURL:   toURL,
+			ToAlias: toAlias,
+
+			Message: data,
+		}
+
+		if f.messageHandler != nil {
+			f.messageHandler(message)
+		}
+
+		if err := to.WritePacket(data); err != nil {
+			return
+		}
+	}
+}
@@ -96,9 +96,19 @@ func handleListenCommand(cmd dmqspecagent.ListenCommand) {
 	ctx = context.Background()
 
 	go func() {
-		err = dmqportals.WebsocketListen(u, dmqportals.BinaryMessages, node, ctx)
+		switch u.Scheme {
+		case "tcp":
+			err = dmqportals.TCPListen(ctx, u.Host, node)
+		default:
+			server := dmqportals.NewWebsocketServer(node, dmqportals.WebsocketServerConfig{
+				MessagesType: dmqportals.BinaryMessages,
+			})
+
+			err = server.ListenAndServe(u)
+		}
+
 		if err != nil {
-			fatal("Failed to listen on websocket: " + err.Error())
+			fatal("Failed to listen on " + u.Scheme + ": " + err.Error())
 		}
 	}()
 }
@@ -111,17 +121,26 @@ func handleConnectCommand(cmd dmqspecagent.ConnectCommand) {
 		fatal("Failed to parse URL: " + err.Error())
 	}
 
-	log("Connecting websocket")
-	wsPortal, err := dmqportals.WebsocketConnect(u, dmqportals.BinaryMessages)
+	var portal directmq.Portal
+
+	switch u.Scheme {
+	case "tcp":
+		log("Connecting tcp")
+		portal, err = dmqportals.TCPConnect(u.Host)
+	default:
+		log("Connecting websocket")
+		portal, err = dmqportals.WebsocketConnect(u, dmqportals.BinaryMessages)
+	}
+
 	if err != nil {
-		fatal("Failed to connect to websocket: " + err.Error())
+		fatal("Failed to connect to " + u.Scheme + ": " + err.Error())
 	}
 
 	log("Starting connection protocol")
 	go func() {
 		log("Starting connection protocol")
-		if err := node.AddConnectingEdge(wsPortal); !dmqportals.IsNetworkConnectionClosedError(err) {
-			fatal("Websocket connection failure: " + err.Error())
+		if err := node.AddConnectingEdge(portal); !dmqportals.IsNetworkConnectionClosedError(err) {
+			fatal(u.Scheme + " connection failure: " + err.Error())
 		}
 	}()
 }
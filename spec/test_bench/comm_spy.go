@@ -2,6 +2,7 @@ package testbench
 
 import (
 	"net/url"
+	"os"
 	"strconv"
 
 	dmqspecagent "github.com/sync-toys/DirectMQ/spec/agent_api"
@@ -11,6 +12,22 @@ type RecordingsLogger func(message []byte, fromNode, toNode string)
 
 const FORWARD_TO_RANDOM_PORT = 0
 
+const (
+	WEBSOCKET_TRANSPORT = "ws"
+	TCP_TRANSPORT       = "tcp"
+)
+
+// Transport used between the agents is selected with the DIRECTMQ_SPEC_TRANSPORT
+// environment variable, websocket is used by default.
+// Only the Go agent supports the tcp transport.
+func getSpecTransport() string {
+	if transport := os.Getenv("DIRECTMQ_SPEC_TRANSPORT"); transport == TCP_TRANSPORT {
+		return TCP_TRANSPORT
+	}
+
+	return WEBSOCKET_TRANSPORT
+}
+
 type NodesCommunicationSpyConfig struct {
 	ForwardToPort int
 
@@ -40,14 +57,16 @@ func NewNodesCommunicationSpy(a dmqspecagent.Agent, b dmqspecagent.Agent, config
 		forwardToPort = getFreeTestingPort()
 	}
 
+	transport := getSpecTransport()
+
 	fromURL := &url.URL{
-		Scheme: "ws",
+		Scheme: transport,
 		Host:   "localhost:" + strconv.Itoa(getFreeTestingPort()),
 		Path:   "/",
 	}
 
 	toURL := &url.URL{
-		Scheme: "ws",
+		Scheme: transport,
 		Host:   "localhost:" + strconv.Itoa(forwardToPort),
 		Path:   "/",
 	}
@@ -62,7 +81,11 @@ func NewNodesCommunicationSpy(a dmqspecagent.Agent, b dmqspecagent.Agent, config
 		ToURL:   toURL,
 	}
 
-	spy.forwarder = dmqspecagent.NewWebsocketForwarder(fromURL, spy.bAgent.GetNodeID(), toURL, spy.aAgent.GetNodeID())
+	if transport == TCP_TRANSPORT {
+		spy.forwarder = dmqspecagent.NewTCPForwarder(fromURL, spy.bAgent.GetNodeID(), toURL, spy.aAgent.GetNodeID())
+	} else {
+		spy.forwarder = dmqspecagent.NewWebsocketForwarder(fromURL, spy.bAgent.GetNodeID(), toURL, spy.aAgent.GetNodeID())
+	}
 	spy.recorder = dmqspecagent.NewRecorder(spy.config.RecordingsComparator)
 
 	spy.forwarder.OnMessage(spy.handleMessage)
@@ -82,8 +105,8 @@ func (spy *NodesCommunicationSpy) handleMessage(message dmqspecagent.ForwardedMe
 	}
 }
 
-func (spy *NodesCommunicationSpy) Start() {
-	spy.forwarder.StartWebsocketForwarder()
+func (spy *NodesCommunicationSpy) Start() error {
+	return spy.forwarder.Start()
 }
 
 func (spy *NodesCommunicationSpy) Stop() {